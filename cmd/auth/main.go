@@ -5,14 +5,21 @@ import (
 	"auth-service/internal/app"
 	"auth-service/internal/logger"
 	"auth-service/internal/shutdown"
+	"flag"
 	"fmt"
 	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 )
 
 func main() {
-	// 1. Инициализация конфига
-	cfg, err := config.LoadConfig()
+	// 1. Инициализация конфига: файл из --config или CONFIG_PATH, поверх него окружение
+	configPath := flag.String("config", os.Getenv(config.PathEnv), "path to a YAML or TOML config file")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
 	if err != nil {
 		panic(fmt.Sprintf("failed to load config: %v", err))
 	}
@@ -23,12 +30,15 @@ func main() {
 	log.Debug("Debug message")
 
 	// 3. Приложение
-	application, err := app.New(log, cfg.GRPC.ServerPort, &cfg.DB, cfg.TokenTTL, cfg.JWTSecret)
+	application, err := app.New(log, cfg)
 	if err != nil {
 		log.Error("failed to create application", slog.String("err", err.Error()))
 		return
 	}
 
+	// проверка готовности: пинг базы до остановки gRPC сервера
+	go application.Health.Run()
+
 	// 4. Запуск gRPC сервера в отдельной горутине
 	go func() {
 		if err := application.GRPCSrv.Run(); err != nil {
@@ -36,9 +46,59 @@ func main() {
 		}
 	}()
 
-	// 5.Shutdown при сигнале
-	shutdown.WaitForSignals(5*time.Second, application.GRPCSrv)
+	// 5. Запуск HTTP сервера (OAuth) в отдельной горутине
+	go func() {
+		if err := application.HTTPSrv.Run(); err != nil {
+			log.Error("http server failed", slog.String("err", err.Error()))
+		}
+	}()
+
+	services := []shutdown.Stoppable{application.GRPCSrv, application.HTTPSrv}
+
+	// 6. Запуск REST шлюза и HTTP сервера с метриками, если они включены
+	if application.GatewaySrv != nil {
+		go func() {
+			if err := application.GatewaySrv.Run(); err != nil {
+				log.Error("gateway server failed", slog.String("err", err.Error()))
+			}
+		}()
+		services = append(services, application.GatewaySrv)
+	}
+
+	if application.MetricsSrv != nil {
+		go func() {
+			if err := application.MetricsSrv.Run(); err != nil {
+				log.Error("metrics server failed", slog.String("err", err.Error()))
+			}
+		}()
+		services = append(services, application.MetricsSrv)
+	}
+
+	// перечитывание конфигурации по SIGHUP
+	go reloadOnSIGHUP(log, application, *configPath)
+
+	// спаны отправляются последними, после остановки серверов
+	services = append(services, application.Tracing)
+
+	// 7.Shutdown при сигнале; gRPC сервер сначала ждёт HEALTH_DRAIN_DELAY
+	shutdown.WaitForSignals(cfg.Health.DrainDelay+5*time.Second, services...)
 
 	log.Info("Application stopped")
 
 }
+
+// reloadOnSIGHUP перечитывает конфигурацию по SIGHUP; при ошибке
+// остаётся прежняя
+func reloadOnSIGHUP(log *slog.Logger, application *app.App, path string) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	for range hup {
+		cfg, err := config.Load(path)
+		if err != nil {
+			log.Error("failed to reload config, keeping the current one", slog.String("err", err.Error()))
+			continue
+		}
+		application.Reload(cfg)
+	}
+}
@@ -2,15 +2,23 @@ package main
 
 import (
 	"auth-service/config"
+	"auth-service/internal/appsecret"
 	"auth-service/internal/db"
 	"auth-service/internal/logger"
+	"auth-service/migrations"
+	"context"
+	"flag"
+	"os"
 
 	"log"
 )
 
 func main() {
-	// 1. Загружаем конфиг
-	cfg, err := config.LoadConfig()
+	// 1. Загружаем конфиг: файл из --config или CONFIG_PATH, поверх него окружение
+	configPath := flag.String("config", os.Getenv(config.PathEnv), "path to a YAML or TOML config file")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
 	if err != nil {
 		log.Fatalf("failed to load config: %v", err)
 	}
@@ -21,11 +29,28 @@ func main() {
 	log.Debug("Debug message")
 
 	// 3. Подключаемся к базе
-	dbConn := db.InitPostgres(&cfg.DB, log)
+	pool, err := db.InitPostgres(context.Background(), &cfg.DB, log)
+	if err != nil {
+		log.Error("failed to connect to database", "error", err)
+		os.Exit(1)
+	}
+	defer pool.Close()
 
-	// 4. Применяем миграции
+	// 4. Go-миграциям нужен ключ для шифрования секретов приложений
+	envelope, err := appsecret.LoadEnvelope(cfg.AppSecretsMasterKey.Reveal())
+	if err != nil {
+		log.Error("failed to load app secrets master key", "error", err)
+		os.Exit(1)
+	}
+	migrations.SetAppSecretsEnvelope(envelope)
+
+	// 5. Применяем миграции
 	migrationsDir := "./migrations"
-	db.Migrate(dbConn, migrationsDir, log)
+	if err := db.Migrate(pool, migrationsDir, log); err != nil {
+		log.Error("failed to apply migrations", "error", err)
+		pool.Close()
+		os.Exit(1)
+	}
 
 	log.Info("All migrations applied successfully")
 }
@@ -1,46 +1,508 @@
 package config
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
 type Config struct {
-	GRPC      GRPCConfig
-	TokenTTL  time.Duration
-	DB        DBConfig
-	LogLevel  string `env:"LOG_LEVEL" env-default:"info"`
-	Env       string `env:"ENV" env-default:"local"`
-	Logger    *slog.Logger
-	JWTSecret string `env:"JWT_SECRET,required"`
+	GRPC GRPCConfig `yaml:"grpc" toml:"grpc"`
+	HTTP HTTPConfig `yaml:"http" toml:"http"`
+	// REST/JSON шлюз к gRPC API
+	Gateway GatewayConfig `yaml:"gateway" toml:"gateway"`
+	OAuth   OAuthConfig   `yaml:"oauth" toml:"oauth"`
+	OIDC    OIDCConfig    `yaml:"oidc" toml:"oidc"`
+	// внешние OIDC провайдеры для входа через корпоративный SSO
+	Federation FederationConfig `yaml:"federation" toml:"federation"`
+	APIKeys    APIKeysConfig    `yaml:"api_keys" toml:"api_keys"`
+	// нормализация адресов email пользователей
+	Email EmailConfig `yaml:"email" toml:"email"`
+	// исходящая почта: подтверждение смены email
+	Mail        MailConfig        `yaml:"mail" toml:"mail"`
+	EmailChange EmailChangeConfig `yaml:"email_change" toml:"email_change"`
+	// каталог LDAP как источник учётных данных для приложений с authenticator "ldap"
+	LDAP LDAPConfig `yaml:"ldap" toml:"ldap"`
+	// HTTP сервер с метриками Prometheus
+	Metrics MetricsConfig `yaml:"metrics" toml:"metrics"`
+	// экспорт трасс OpenTelemetry
+	Tracing TracingConfig `yaml:"tracing" toml:"tracing"`
+	// проверки живости и готовности
+	Health HealthConfig `yaml:"health" toml:"health"`
+	// ограничение частоты вызовов gRPC
+	RateLimit RateLimitConfig `yaml:"rate_limit" toml:"rate_limit"`
+	// время жизни токенов доступа пользователей
+	TokenTTL time.Duration `env:"TOKEN_TTL" env-default:"1h" yaml:"token_ttl" toml:"token_ttl"`
+	DB       DBConfig      `yaml:"db" toml:"db"`
+	LogLevel string        `env:"LOG_LEVEL" env-default:"info" yaml:"log_level" toml:"log_level"`
+	Env      string        `env:"ENV" env-default:"local" yaml:"env" toml:"env"`
+	Logger   *slog.Logger  `yaml:"-" toml:"-"`
+	// секреты можно передать и файлом через {name}_FILE или взять из Vault
+	JWTSecret Secret `env:"JWT_SECRET" yaml:"jwt_secret" toml:"jwt_secret"`
+	// мастер-ключ для шифрования секретов приложений: 32 байта в base64
+	AppSecretsMasterKey Secret `env:"APP_SECRETS_MASTER_KEY" yaml:"app_secrets_master_key" toml:"app_secrets_master_key"`
+	// хранилище секретов с HTTP API Vault
+	Secrets SecretsConfig `yaml:"secrets" toml:"secrets"`
+}
+
+type SecretsConfig struct {
+	// адрес Vault, например http://127.0.0.1:8200; пустой — секреты только из окружения, файлов и конфигурации
+	VaultAddr  string `env:"VAULT_ADDR" yaml:"vault_addr" toml:"vault_addr"`
+	VaultToken Secret `env:"VAULT_TOKEN" yaml:"vault_token" toml:"vault_token"`
+	// путь к секрету после /v1/; его ключи — имена переменных (JWT_SECRET, DB_PASSWORD, …)
+	VaultPath    string        `env:"VAULT_SECRET_PATH" env-default:"secret/data/auth-service" yaml:"vault_path" toml:"vault_path"`
+	VaultTimeout time.Duration `env:"VAULT_TIMEOUT" env-default:"5s" yaml:"vault_timeout" toml:"vault_timeout"`
 }
 
 type DBConfig struct {
-	Host     string `env:"DB_HOST" env-default:"auth_db"`
-	Port     string `env:"DB_PORT" env-default:"5432"`
-	User     string `env:"DB_USER" env-default:"auth"`
-	Password string `env:"DB_PASSWORD" env-default:"authpass"`
-	Name     string `env:"DB_NAME" env-default:"auth_db"`
-	SSLMode  string `env:"DB_SSLMODE" env-default:"disable"`
+	Host     string `env:"DB_HOST" env-default:"auth_db" yaml:"host" toml:"host"`
+	Port     string `env:"DB_PORT" env-default:"5432" yaml:"port" toml:"port"`
+	User     string `env:"DB_USER" env-default:"auth" yaml:"user" toml:"user"`
+	Password Secret `env:"DB_PASSWORD" env-default:"authpass" yaml:"password" toml:"password"`
+	Name     string `env:"DB_NAME" env-default:"auth_db" yaml:"name" toml:"name"`
+	SSLMode  string `env:"DB_SSLMODE" env-default:"disable" yaml:"ssl_mode" toml:"ssl_mode"`
+
+	// размер пула соединений
+	MaxConns int32 `env:"DB_MAX_CONNS" env-default:"25" yaml:"max_conns" toml:"max_conns"`
+	// сколько соединений пул держит открытыми даже без нагрузки
+	MinConns int32 `env:"DB_MIN_CONNS" env-default:"2" yaml:"min_conns" toml:"min_conns"`
+	// соединение закрывается после MaxConnLifetime работы или MaxConnIdleTime простоя
+	MaxConnLifetime time.Duration `env:"DB_MAX_CONN_LIFETIME" env-default:"5m" yaml:"max_conn_lifetime" toml:"max_conn_lifetime"`
+	MaxConnIdleTime time.Duration `env:"DB_MAX_CONN_IDLE_TIME" env-default:"30m" yaml:"max_conn_idle_time" toml:"max_conn_idle_time"`
+	// как часто пул проверяет простаивающие соединения
+	HealthCheckPeriod time.Duration `env:"DB_HEALTH_CHECK_PERIOD" env-default:"1m" yaml:"health_check_period" toml:"health_check_period"`
+	// таймаут установки одного соединения
+	ConnectTimeout time.Duration `env:"DB_CONNECT_TIMEOUT" env-default:"5s" yaml:"connect_timeout" toml:"connect_timeout"`
+	// попытки подключиться при старте; пауза между ними растёт вдвое
+	// от ConnectBackoff до ConnectMaxBackoff
+	ConnectAttempts   int           `env:"DB_CONNECT_ATTEMPTS" env-default:"10" yaml:"connect_attempts" toml:"connect_attempts"`
+	ConnectBackoff    time.Duration `env:"DB_CONNECT_BACKOFF" env-default:"500ms" yaml:"connect_backoff" toml:"connect_backoff"`
+	ConnectMaxBackoff time.Duration `env:"DB_CONNECT_MAX_BACKOFF" env-default:"10s" yaml:"connect_max_backoff" toml:"connect_max_backoff"`
 }
 
 type GRPCConfig struct {
-	ServerHost         string        `env:"GRPC_SERVER_HOST" env-default:"0.0.0.0"`
-	ServerPort         string        `env:"GRPC_SERVER_PORT" env-default:"50051"`
-	ServerReadTimeout  time.Duration `env:"GRPC_SERVER_READ_TIMEOUT" env-default:"5s"`
-	ServerWriteTimeout time.Duration `env:"GRPC_SERVER_WRITE_TIMEOUT" env-default:"10s"`
-	ServerIdleTimeout  time.Duration `env:"GRPC_SERVER_IDLE_TIMEOUT" env-default:"120s"`
+	// адрес, на котором слушает сервер; 0.0.0.0 — все интерфейсы
+	ServerHost string `env:"GRPC_SERVER_HOST" env-default:"0.0.0.0" yaml:"server_host" toml:"server_host"`
+	ServerPort string `env:"GRPC_SERVER_PORT" env-default:"50051" yaml:"server_port" toml:"server_port"`
+	// сколько ждать рукопожатия нового соединения
+	ServerReadTimeout time.Duration `env:"GRPC_SERVER_READ_TIMEOUT" env-default:"5s" yaml:"server_read_timeout" toml:"server_read_timeout"`
+	// наибольшая длительность вызова; дедлайн клиента, если он короче, сохраняется
+	ServerWriteTimeout time.Duration `env:"GRPC_SERVER_WRITE_TIMEOUT" env-default:"10s" yaml:"server_write_timeout" toml:"server_write_timeout"`
+	// через сколько закрывать соединение без вызовов
+	ServerIdleTimeout time.Duration `env:"GRPC_SERVER_IDLE_TIMEOUT" env-default:"120s" yaml:"server_idle_timeout" toml:"server_idle_timeout"`
+	// TLS и проверка клиентских сертификатов
+	TLS GRPCTLSConfig `yaml:"tls" toml:"tls"`
+}
+
+type GRPCTLSConfig struct {
+	// сертификат (с цепочкой) и ключ сервера в PEM; пустые — gRPC без TLS
+	CertFile string `env:"GRPC_TLS_CERT_FILE" yaml:"cert_file" toml:"cert_file"`
+	KeyFile  string `env:"GRPC_TLS_KEY_FILE" yaml:"key_file" toml:"key_file"`
+	// CA для проверки клиентских сертификатов (mTLS); пустой — сертификаты клиентов не запрашиваются
+	ClientCAFile string `env:"GRPC_TLS_CLIENT_CA_FILE" yaml:"client_ca_file" toml:"client_ca_file"`
+	// отклонять соединения без клиентского сертификата; иначе он обязателен только для методов из AllowedSANs
+	RequireClientCert bool `env:"GRPC_TLS_REQUIRE_CLIENT_CERT" env-default:"false" yaml:"require_client_cert" toml:"require_client_cert"`
+	// методы, которые можно вызывать только с сертификатом с одним из SAN
+	AllowedSANs GRPCAllowedSANs `env:"GRPC_TLS_ALLOWED_SANS" yaml:"allowed_sans" toml:"allowed_sans"`
+	// как часто перечитывать файлы сертификатов и CA с диска
+	ReloadInterval time.Duration `env:"GRPC_TLS_RELOAD_INTERVAL" env-default:"30s" yaml:"reload_interval" toml:"reload_interval"`
+}
+
+// GRPCAllowedSANs — какие SAN клиентского сертификата могут вызывать метод.
+// Ключ — полное имя метода или все методы сервиса, например
+// {"/users.Users/*":["admin-cli.internal"],"/auth.Auth/IsAdmin":["spiffe://corp/billing"]}
+type GRPCAllowedSANs map[string][]string
+
+var grpcMethodRe = regexp.MustCompile(`^/[A-Za-z_][A-Za-z0-9_.]*/([A-Za-z_][A-Za-z0-9_]*|\*)$`)
+
+// SetValue разбирает GRPC_TLS_ALLOWED_SANS (cleanenv.Setter)
+func (a *GRPCAllowedSANs) SetValue(s string) error {
+	var allowed GRPCAllowedSANs
+	if err := json.Unmarshal([]byte(s), &allowed); err != nil {
+		return fmt.Errorf("invalid grpc allowed sans: %w", err)
+	}
+	if err := allowed.validate(); err != nil {
+		return err
+	}
+
+	*a = allowed
+
+	return nil
+}
+
+// validate проверяет правила и из переменной окружения, и из файла
+func (a GRPCAllowedSANs) validate() error {
+	for method, sans := range a {
+		if !grpcMethodRe.MatchString(method) {
+			return fmt.Errorf("grpc allowed sans: invalid method %q", method)
+		}
+		if len(sans) == 0 || slices.Contains(sans, "") {
+			return fmt.Errorf("grpc allowed sans: %s: sans must not be empty", method)
+		}
+	}
+
+	return nil
+}
+
+type RateLimitConfig struct {
+	Enabled bool `env:"RATE_LIMIT_ENABLED" env-default:"true" yaml:"enabled" toml:"enabled"`
+	// memory — корзины в памяти каждой реплики; redis — общие корзины в Redis
+	Store    string `env:"RATE_LIMIT_STORE" env-default:"memory" yaml:"store" toml:"store"`
+	RedisURL string `env:"RATE_LIMIT_REDIS_URL" env-default:"redis://localhost:6379/0" yaml:"redis_url" toml:"redis_url"`
+	// JSON-список правил; если не задан, действуют правила по умолчанию для Register и Login
+	Policies RateLimitPolicies `env:"RATE_LIMIT_POLICIES" yaml:"policies" toml:"policies"`
+}
+
+// ключи, по которым считаются вызовы
+const (
+	RateLimitByIP    = "ip"
+	RateLimitByEmail = "email"
+	RateLimitByApp   = "app_id"
+)
+
+// RateLimitPolicy ограничивает вызовы метода для каждого значения ключа,
+// например {"method":"/auth.Auth/Login","key":"email","rate":0.1,"burst":5}
+// — не больше пяти попыток подряд и затем одна в 10 секунд на email
+type RateLimitPolicy struct {
+	Method string `json:"method" yaml:"method" toml:"method"`
+	// Key — ip, email или app_id
+	Key string `json:"key" yaml:"key" toml:"key"`
+	// Rate — вызовов в секунду в среднем
+	Rate float64 `json:"rate" yaml:"rate" toml:"rate"`
+	// Burst — сколько вызовов можно сделать подряд
+	Burst int `json:"burst" yaml:"burst" toml:"burst"`
+}
+
+type RateLimitPolicies []RateLimitPolicy
+
+// SetValue разбирает RATE_LIMIT_POLICIES (cleanenv.Setter)
+func (p *RateLimitPolicies) SetValue(s string) error {
+	var policies RateLimitPolicies
+	if err := json.Unmarshal([]byte(s), &policies); err != nil {
+		return fmt.Errorf("invalid rate limit policies: %w", err)
+	}
+	if err := policies.validate(); err != nil {
+		return err
+	}
+
+	*p = policies
+
+	return nil
+}
+
+func (p RateLimitPolicies) validate() error {
+	for _, policy := range p {
+		if !grpcMethodRe.MatchString(policy.Method) {
+			return fmt.Errorf("rate limit policies: invalid method %q", policy.Method)
+		}
+		switch policy.Key {
+		case RateLimitByIP, RateLimitByEmail, RateLimitByApp:
+		default:
+			return fmt.Errorf("rate limit policies: %s: unknown key %q", policy.Method, policy.Key)
+		}
+		if policy.Rate <= 0 || policy.Burst < 1 {
+			return fmt.Errorf("rate limit policies: %s: rate and burst must be positive", policy.Method)
+		}
+	}
+
+	return nil
+}
+
+type HTTPConfig struct {
+	ServerPort         string        `env:"HTTP_SERVER_PORT" env-default:"8080" yaml:"server_port" toml:"server_port"`
+	ServerReadTimeout  time.Duration `env:"HTTP_SERVER_READ_TIMEOUT" env-default:"5s" yaml:"server_read_timeout" toml:"server_read_timeout"`
+	ServerWriteTimeout time.Duration `env:"HTTP_SERVER_WRITE_TIMEOUT" env-default:"10s" yaml:"server_write_timeout" toml:"server_write_timeout"`
+	ServerIdleTimeout  time.Duration `env:"HTTP_SERVER_IDLE_TIMEOUT" env-default:"120s" yaml:"server_idle_timeout" toml:"server_idle_timeout"`
+}
+
+type MetricsConfig struct {
+	// адрес HTTP сервера с /metrics; /metrics без аутентификации, поэтому по умолчанию
+	// только loopback. :9090 — на всех интерфейсах; пустой — метрики не отдаются
+	Addr string `env:"METRICS_ADDR" env-default:"127.0.0.1:9090" yaml:"addr" toml:"addr"`
+}
+
+type HealthConfig struct {
+	// как часто пинговать базу для статуса готовности
+	PingInterval time.Duration `env:"HEALTH_PING_INTERVAL" env-default:"5s" yaml:"ping_interval" toml:"ping_interval"`
+	PingTimeout  time.Duration `env:"HEALTH_PING_TIMEOUT" env-default:"2s" yaml:"ping_timeout" toml:"ping_timeout"`
+	// HTTP зеркала /healthz и /readyz на HTTP сервере для проб без поддержки gRPC
+	HTTPEnabled bool `env:"HEALTH_HTTP_ENABLED" env-default:"true" yaml:"http_enabled" toml:"http_enabled"`
+	// сколько при остановке ждать после перевода готовности в NOT_SERVING, прежде чем
+	// перестать принимать вызовы: балансировщик должен успеть исключить реплику
+	DrainDelay time.Duration `env:"HEALTH_DRAIN_DELAY" env-default:"5s" yaml:"drain_delay" toml:"drain_delay"`
+}
+
+type TracingConfig struct {
+	// куда отправлять спаны: otlp, stdout или none
+	Exporter string `env:"TRACING_EXPORTER" env-default:"none" yaml:"exporter" toml:"exporter"`
+	// адрес OTLP/gRPC коллектора host:port
+	OTLPEndpoint string `env:"TRACING_OTLP_ENDPOINT" env-default:"localhost:4317" yaml:"otlp_endpoint" toml:"otlp_endpoint"`
+	// без TLS до коллектора, например sidecar в том же поде
+	OTLPInsecure bool `env:"TRACING_OTLP_INSECURE" env-default:"true" yaml:"otlp_insecure" toml:"otlp_insecure"`
+	// доля записываемых трасс от 0 до 1; решение вызывающего сервиса соблюдается
+	SampleRatio float64 `env:"TRACING_SAMPLE_RATIO" env-default:"1" yaml:"sample_ratio" toml:"sample_ratio"`
+}
+
+type GatewayConfig struct {
+	// порт REST/JSON шлюза; пустой — шлюз выключен. Таймауты общие с HTTP_SERVER_*
+	ServerPort string `env:"GATEWAY_SERVER_PORT" env-default:"8081" yaml:"server_port" toml:"server_port"`
+}
+
+type OAuthConfig struct {
+	// время жизни кода авторизации
+	CodeTTL time.Duration `env:"OAUTH_CODE_TTL" env-default:"1m" yaml:"code_ttl" toml:"code_ttl"`
+	// сколько пользователь может думать на странице согласия
+	ConsentTicketTTL time.Duration `env:"OAUTH_CONSENT_TICKET_TTL" env-default:"5m" yaml:"consent_ticket_ttl" toml:"consent_ticket_ttl"`
+}
+
+type OIDCConfig struct {
+	// внешний адрес HTTP сервера, попадает в iss и discovery
+	Issuer string `env:"OIDC_ISSUER" env-default:"http://localhost:8080" yaml:"issuer" toml:"issuer"`
+	// RSA-ключ для подписи id_token в PEM; если не задан, генерируется при старте
+	SigningKeyFile string `env:"OIDC_SIGNING_KEY_FILE" yaml:"signing_key_file" toml:"signing_key_file"`
+}
+
+type APIKeysConfig struct {
+	// время жизни JWT, выдаваемого в обмен на API ключ
+	TokenTTL time.Duration `env:"API_KEY_TOKEN_TTL" env-default:"15m" yaml:"token_ttl" toml:"token_ttl"`
+	// срок действия ключа, если он не указан при создании
+	DefaultTTL time.Duration `env:"API_KEY_DEFAULT_TTL" env-default:"2160h" yaml:"default_ttl" toml:"default_ttl"`
+}
+
+type EmailConfig struct {
+	// хранить локальную часть адреса (до @) в нижнем регистре; сравнение email без учёта регистра в любом случае
+	FoldLocalPart bool `env:"EMAIL_FOLD_LOCAL_PART" env-default:"true" yaml:"fold_local_part" toml:"fold_local_part"`
+}
+
+type MailConfig struct {
+	// адрес SMTP сервера host:port; пустой — письма только пишутся в лог
+	SMTPAddr     string        `env:"MAIL_SMTP_ADDR" yaml:"smtp_addr" toml:"smtp_addr"`
+	SMTPUsername string        `env:"MAIL_SMTP_USERNAME" yaml:"smtp_username" toml:"smtp_username"`
+	SMTPPassword Secret        `env:"MAIL_SMTP_PASSWORD" yaml:"smtp_password" toml:"smtp_password"`
+	From         string        `env:"MAIL_FROM" env-default:"no-reply@localhost" yaml:"from" toml:"from"`
+	Timeout      time.Duration `env:"MAIL_TIMEOUT" env-default:"10s" yaml:"timeout" toml:"timeout"`
+}
+
+type EmailChangeConfig struct {
+	// сколько действует ссылка подтверждения, отправленная на новый адрес
+	ConfirmTTL time.Duration `env:"EMAIL_CHANGE_CONFIRM_TTL" env-default:"24h" yaml:"confirm_ttl" toml:"confirm_ttl"`
+	// сколько действует ссылка отмены, отправленная на старый адрес (в том числе после подтверждения)
+	CancelTTL time.Duration `env:"EMAIL_CHANGE_CANCEL_TTL" env-default:"168h" yaml:"cancel_ttl" toml:"cancel_ttl"`
+}
+
+type FederationConfig struct {
+	// JSON-список провайдеров, например
+	// [{"name":"corp","issuer":"https://sso.example.com","client_id":"auth","client_secret":"secret"}]
+	Providers FederationProviders `env:"FEDERATION_PROVIDERS" yaml:"providers" toml:"providers"`
+	// сколько живёт cookie со state между редиректом к провайдеру и возвратом
+	StateTTL time.Duration `env:"FEDERATION_STATE_TTL" env-default:"10m" yaml:"state_ttl" toml:"state_ttl"`
+}
+
+// FederationProvider — upstream OIDC провайдер, через которого можно войти
+type FederationProvider struct {
+	// Name попадает в путь /federation/{name}/login и в linked_identities.provider
+	Name         string `json:"name" yaml:"name" toml:"name"`
+	Issuer       string `json:"issuer" yaml:"issuer" toml:"issuer"`
+	ClientID     string `json:"client_id" yaml:"client_id" toml:"client_id"`
+	ClientSecret Secret `json:"client_secret" yaml:"client_secret" toml:"client_secret"`
+	// Scopes по умолчанию openid и email
+	Scopes []string `json:"scopes" yaml:"scopes" toml:"scopes"`
+}
+
+type FederationProviders []FederationProvider
+
+var providerNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// SetValue разбирает FEDERATION_PROVIDERS (cleanenv.Setter)
+func (p *FederationProviders) SetValue(s string) error {
+	var providers FederationProviders
+	if err := json.Unmarshal([]byte(s), &providers); err != nil {
+		return fmt.Errorf("invalid federation providers: %w", err)
+	}
+	if err := providers.validate(); err != nil {
+		return err
+	}
+
+	*p = providers
+
+	return nil
+}
+
+func (p FederationProviders) validate() error {
+	seen := make(map[string]bool, len(p))
+	for _, provider := range p {
+		if !providerNameRe.MatchString(provider.Name) {
+			return fmt.Errorf("invalid federation provider name %q", provider.Name)
+		}
+		// corp-sso и corp_sso читали бы один и тот же секрет
+		if seen[providerSecretName(provider.Name)] {
+			return fmt.Errorf("duplicate federation provider %q", provider.Name)
+		}
+		seen[providerSecretName(provider.Name)] = true
+
+		if provider.Issuer == "" || provider.ClientID == "" {
+			return errors.New("federation provider " + provider.Name + ": issuer and client_id are required")
+		}
+	}
+
+	return nil
+}
+
+type LDAPConfig struct {
+	// адрес сервера, например ldaps://ldap.example.com:636; пустой — LDAP выключен
+	URL      string `env:"LDAP_URL" yaml:"url" toml:"url"`
+	StartTLS bool   `env:"LDAP_START_TLS" env-default:"false" yaml:"start_tls" toml:"start_tls"`
+	// служебная учётная запись для поиска пользователя; пустая — анонимный поиск
+	BindDN       string `env:"LDAP_BIND_DN" yaml:"bind_dn" toml:"bind_dn"`
+	BindPassword Secret `env:"LDAP_BIND_PASSWORD" yaml:"bind_password" toml:"bind_password"`
+	BaseDN       string `env:"LDAP_BASE_DN" yaml:"base_dn" toml:"base_dn"`
+	// фильтр поиска пользователя, %s заменяется экранированным email
+	UserFilter     string         `env:"LDAP_USER_FILTER" env-default:"(mail=%s)" yaml:"user_filter" toml:"user_filter"`
+	EmailAttribute string         `env:"LDAP_EMAIL_ATTRIBUTE" env-default:"mail" yaml:"email_attribute" toml:"email_attribute"`
+	GroupAttribute string         `env:"LDAP_GROUP_ATTRIBUTE" env-default:"memberOf" yaml:"group_attribute" toml:"group_attribute"`
+	GroupRoles     LDAPGroupRoles `env:"LDAP_GROUP_ROLES" yaml:"group_roles" toml:"group_roles"`
+	Timeout        time.Duration  `env:"LDAP_TIMEOUT" env-default:"5s" yaml:"timeout" toml:"timeout"`
+}
+
+// LDAPGroupRoles сопоставляет DN группы роли пользователя,
+// например {"cn=admins,ou=groups,dc=example,dc=com":"admin"}
+type LDAPGroupRoles map[string]string
+
+// SetValue разбирает LDAP_GROUP_ROLES (cleanenv.Setter)
+func (r *LDAPGroupRoles) SetValue(s string) error {
+	var roles LDAPGroupRoles
+	if err := json.Unmarshal([]byte(s), &roles); err != nil {
+		return fmt.Errorf("invalid ldap group roles: %w", err)
+	}
+	if err := roles.validate(); err != nil {
+		return err
+	}
+
+	*r = roles
+
+	return nil
+}
+
+func (r LDAPGroupRoles) validate() error {
+	for group, role := range r {
+		if group == "" || role == "" {
+			return errors.New("ldap group roles: group and role must not be empty")
+		}
+	}
+
+	return nil
 }
 
+// PathEnv — путь к файлу конфигурации, если не задан флаг --config
+const PathEnv = "CONFIG_PATH"
+
+// LoadConfig загружает конфигурацию из файла CONFIG_PATH, если он задан, и переменных окружения
 func LoadConfig() (*Config, error) {
+	return Load(os.Getenv(PathEnv))
+}
+
+// Load читает файл конфигурации path (YAML или TOML по расширению), затем
+// переменные окружения, которые переопределяют значения из файла, подставляет
+// секреты из SecretProviders и проверяет результат. Пустой path — только
+// переменные окружения.
+func Load(path string) (*Config, error) {
+	// значения по умолчанию и переменные окружения
 	cfg := &Config{}
-	err := cleanenv.ReadEnv(cfg)
+	if err := cleanenv.ReadEnv(cfg); err != nil {
+		return nil, err
+	}
+
+	if path != "" {
+		// файл читается поверх значений по умолчанию, а не до них: иначе
+		// false и пустые строки из файла заменялись бы значениями по умолчанию
+		if err := parseFile(path, cfg); err != nil {
+			return nil, err
+		}
+
+		// отдельная копия: декодеры дописывают в карты, уже заполненные из окружения
+		env := &Config{}
+		if err := cleanenv.ReadEnv(env); err != nil {
+			return nil, err
+		}
+		overrideFromEnv(reflect.ValueOf(cfg).Elem(), reflect.ValueOf(env).Elem())
+	}
 
+	ctx := context.Background()
+	providers, err := cfg.SecretProviders(ctx)
 	if err != nil {
 		return nil, err
 	}
+	if err := cfg.ResolveSecrets(ctx, providers); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
 
 	return cfg, nil
 }
+
+func parseFile(path string, cfg *Config) error {
+	ext := strings.ToLower(filepath.Ext(path))
+
+	var parse func(io.Reader, interface{}) error
+	switch ext {
+	case ".yaml", ".yml":
+		parse = cleanenv.ParseYAML
+	case ".toml":
+		parse = cleanenv.ParseTOML
+	default:
+		return fmt.Errorf("config file %s: unsupported format %q, use .yaml, .yml or .toml", path, ext)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := parse(f, cfg); err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// overrideFromEnv возвращает в dst поля из src, для которых задана
+// переменная окружения: окружение важнее файла
+func overrideFromEnv(dst, src reflect.Value) {
+	for i := 0; i < dst.NumField(); i++ {
+		field := dst.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		names, ok := field.Tag.Lookup("env")
+		if !ok {
+			if field.Type.Kind() == reflect.Struct {
+				overrideFromEnv(dst.Field(i), src.Field(i))
+			}
+			continue
+		}
+
+		for _, name := range strings.Split(names, ",") {
+			if _, set := os.LookupEnv(name); set {
+				dst.Field(i).Set(src.Field(i))
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package config_test
+
+import (
+	"auth-service/config"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testMasterKey — 32 байта в base64; без мастер-ключа конфигурация не проходит проверку
+const testMasterKey = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+
+func TestMain(m *testing.M) {
+	_ = os.Setenv("APP_SECRETS_MASTER_KEY", testMasterKey)
+	os.Exit(m.Run())
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_EnvOnly(t *testing.T) {
+	t.Setenv("JWT_SECRET", "secret")
+	t.Setenv("TOKEN_TTL", "30m")
+
+	cfg, err := config.Load("")
+	require.NoError(t, err)
+
+	assert.Equal(t, 30*time.Minute, cfg.TokenTTL)
+	// значения по умолчанию
+	assert.Equal(t, "50051", cfg.GRPC.ServerPort)
+	assert.Equal(t, 10*time.Second, cfg.GRPC.ServerWriteTimeout)
+	// /metrics без аутентификации доступен только с loopback
+	assert.Equal(t, "127.0.0.1:9090", cfg.Metrics.Addr)
+}
+
+func TestLoad_ExampleYAML(t *testing.T) {
+	cfg, err := config.Load("config.example.yaml")
+	require.NoError(t, err)
+
+	assert.Equal(t, config.Secret("change-me"), cfg.JWTSecret)
+	assert.Equal(t, time.Hour, cfg.TokenTTL)
+	assert.Equal(t, "5433", cfg.DB.Port)
+	assert.Equal(t, 2160*time.Hour, cfg.APIKeys.DefaultTTL)
+	require.Len(t, cfg.RateLimit.Policies, 3)
+	assert.Equal(t, config.RateLimitPolicy{Method: "/auth.Auth/Login", Key: "email", Rate: 0.1, Burst: 5}, cfg.RateLimit.Policies[1])
+	// не заданы в файле
+	assert.Equal(t, "no-reply@localhost", cfg.Mail.From)
+	assert.True(t, cfg.RateLimit.Enabled)
+}
+
+func TestLoad_TOMLWithEnvOverrides(t *testing.T) {
+	path := writeFile(t, "auth.toml", `
+jwt_secret = "from-file"
+log_level = "debug"
+
+[grpc]
+server_port = "6000"
+server_write_timeout = "30s"
+
+[[federation.providers]]
+name = "corp"
+issuer = "https://sso.example.com"
+client_id = "auth"
+`)
+	t.Setenv("GRPC_SERVER_PORT", "7000")
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, config.Secret("from-file"), cfg.JWTSecret)
+	assert.Equal(t, "debug", cfg.LogLevel)
+	assert.Equal(t, "7000", cfg.GRPC.ServerPort)
+	assert.Equal(t, 30*time.Second, cfg.GRPC.ServerWriteTimeout)
+	require.Len(t, cfg.Federation.Providers, 1)
+	assert.Equal(t, "auth", cfg.Federation.Providers[0].ClientID)
+}
+
+func TestLoad_FileOverridesDefaultsWithZeroValues(t *testing.T) {
+	path := writeFile(t, "auth.yaml", `
+jwt_secret: secret
+rate_limit:
+  enabled: false
+health:
+  http_enabled: false
+email:
+  fold_local_part: false
+gateway:
+  server_port: ""
+metrics:
+  addr: ""
+`)
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+
+	assert.False(t, cfg.RateLimit.Enabled)
+	assert.False(t, cfg.Health.HTTPEnabled)
+	assert.False(t, cfg.Email.FoldLocalPart)
+	assert.Empty(t, cfg.Gateway.ServerPort)
+	assert.Empty(t, cfg.Metrics.Addr)
+	// не заданы в файле
+	assert.Equal(t, "50051", cfg.GRPC.ServerPort)
+
+	// перечитывание по SIGHUP видит те же значения
+	cfg, err = config.Load(path)
+	require.NoError(t, err)
+	assert.False(t, cfg.RateLimit.Enabled)
+	assert.Empty(t, cfg.Metrics.Addr)
+}
+
+func TestLoad_EnvOverridesZeroValuesFromFile(t *testing.T) {
+	path := writeFile(t, "auth.toml", `
+jwt_secret = "secret"
+
+[rate_limit]
+enabled = false
+
+[gateway]
+server_port = ""
+`)
+	t.Setenv("RATE_LIMIT_ENABLED", "true")
+	t.Setenv("GATEWAY_SERVER_PORT", "8081")
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+
+	assert.True(t, cfg.RateLimit.Enabled)
+	assert.Equal(t, "8081", cfg.Gateway.ServerPort)
+}
+
+func TestLoad_UnsupportedFormat(t *testing.T) {
+	_, err := config.Load(writeFile(t, "auth.ini", "jwt_secret=x"))
+	assert.ErrorContains(t, err, "unsupported format")
+}
+
+func TestLoad_ReportsEveryInvalidField(t *testing.T) {
+	path := writeFile(t, "auth.yaml", `
+jwt_secret: secret
+log_level: verbose
+token_ttl: -1s
+grpc:
+  server_port: "70000"
+db:
+  max_conns: 2
+  min_conns: 5
+tracing:
+  sample_ratio: 2
+rate_limit:
+  policies:
+    - {method: Login, key: ip, rate: 1, burst: 1}
+federation:
+  providers:
+    - {name: corp}
+`)
+
+	_, err := config.Load(path)
+	require.Error(t, err)
+
+	for _, name := range []string{
+		"LOG_LEVEL",
+		"TOKEN_TTL",
+		"GRPC_SERVER_PORT",
+		"DB_MIN_CONNS",
+		"TRACING_SAMPLE_RATIO",
+		"RATE_LIMIT_POLICIES",
+		"FEDERATION_PROVIDERS",
+	} {
+		assert.ErrorContains(t, err, name+":")
+	}
+}
+
+func TestValidate_TLS(t *testing.T) {
+	t.Setenv("JWT_SECRET", "secret")
+	t.Setenv("GRPC_TLS_CERT_FILE", "server.pem")
+
+	_, err := config.Load("")
+	assert.ErrorContains(t, err, "GRPC_TLS_CERT_FILE and GRPC_TLS_KEY_FILE must be set together")
+}
+
+func TestValidate_MasterKey(t *testing.T) {
+	t.Setenv("JWT_SECRET", "secret")
+
+	t.Setenv("APP_SECRETS_MASTER_KEY", "")
+	_, err := config.Load("")
+	assert.ErrorContains(t, err, "APP_SECRETS_MASTER_KEY: is required")
+
+	t.Setenv("APP_SECRETS_MASTER_KEY", "c2hvcnQ=")
+	_, err = config.Load("")
+	assert.ErrorContains(t, err, "APP_SECRETS_MASTER_KEY: must be 32 bytes in base64")
+}
@@ -0,0 +1,235 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// Secret — пароль, ключ или токен. В логах и выводе fmt вместо значения
+// печатается [REDACTED]; само значение возвращает Reveal.
+type Secret string
+
+func (s Secret) Reveal() string {
+	return string(s)
+}
+
+// String скрывает значение; пустой секрет остаётся пустым, чтобы было видно, что он не задан
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+func (s Secret) GoString() string {
+	return strconv.Quote(s.String())
+}
+
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(s.String())
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// SecretProvider возвращает секрет по имени переменной окружения (JWT_SECRET,
+// DB_PASSWORD, …); ok == false, если у провайдера такого секрета нет
+type SecretProvider interface {
+	Secret(ctx context.Context, name string) (value string, ok bool, err error)
+}
+
+// EnvSecrets читает секрет из переменной окружения с тем же именем
+type EnvSecrets struct{}
+
+func (EnvSecrets) Secret(_ context.Context, name string) (string, bool, error) {
+	value, ok := os.LookupEnv(name)
+	return value, ok && value != "", nil
+}
+
+// FileSecrets читает секрет из файла, путь к которому задан в {name}_FILE
+// (Docker и Kubernetes secrets); завершающий перевод строки отбрасывается
+type FileSecrets struct{}
+
+func (FileSecrets) Secret(_ context.Context, name string) (string, bool, error) {
+	path := os.Getenv(name + "_FILE")
+	if path == "" {
+		return "", false, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("%s_FILE: %w", name, err)
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+// VaultSecrets читает секреты из KV хранилища с HTTP API Vault (или совместимого,
+// например OpenBao). Ключи секрета по пути path — имена переменных окружения.
+// Секрет запрашивается один раз, при первом обращении.
+type VaultSecrets struct {
+	addr   string
+	token  string
+	path   string
+	client *http.Client
+	data   map[string]string
+}
+
+// NewVaultSecrets returns a new instance of the VaultSecrets. path — путь после /v1/,
+// например secret/data/auth-service для KV v2.
+func NewVaultSecrets(addr, token, path string, client *http.Client) *VaultSecrets {
+	return &VaultSecrets{
+		addr:   strings.TrimSuffix(addr, "/"),
+		token:  token,
+		path:   strings.Trim(path, "/"),
+		client: client,
+	}
+}
+
+func (v *VaultSecrets) Secret(ctx context.Context, name string) (string, bool, error) {
+	if v.data == nil {
+		data, err := v.read(ctx)
+		if err != nil {
+			return "", false, err
+		}
+		v.data = data
+	}
+
+	value, ok := v.data[name]
+	return value, ok && value != "", nil
+}
+
+func (v *VaultSecrets) read(ctx context.Context) (map[string]string, error) {
+	const op = "config.VaultSecrets"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.addr+"/v1/"+v.path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer resp.Body.Close()
+
+	// 404 — секретов по пути нет, остаются значения из других источников
+	if resp.StatusCode == http.StatusNotFound {
+		return map[string]string{}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: read %s: unexpected status %s", op, v.path, resp.Status)
+	}
+
+	// KV v2 кладёт значения в data.data, KV v1 — прямо в data
+	var body struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("%s: decode %s: %w", op, v.path, err)
+	}
+
+	var kv struct {
+		Data     map[string]any `json:"data"`
+		Metadata map[string]any `json:"metadata"`
+	}
+	if err := json.Unmarshal(body.Data, &kv); err != nil {
+		return nil, fmt.Errorf("%s: decode %s: %w", op, v.path, err)
+	}
+
+	values := kv.Data
+	if kv.Metadata == nil {
+		if err := json.Unmarshal(body.Data, &values); err != nil {
+			return nil, fmt.Errorf("%s: decode %s: %w", op, v.path, err)
+		}
+	}
+
+	data := make(map[string]string, len(values))
+	for key, value := range values {
+		if s, ok := value.(string); ok {
+			data[key] = s
+		}
+	}
+
+	return data, nil
+}
+
+// secrets — поля с секретами по имени переменной окружения
+func (c *Config) secrets() map[string]*Secret {
+	secrets := map[string]*Secret{
+		"JWT_SECRET":             &c.JWTSecret,
+		"APP_SECRETS_MASTER_KEY": &c.AppSecretsMasterKey,
+		"DB_PASSWORD":            &c.DB.Password,
+		"MAIL_SMTP_PASSWORD":     &c.Mail.SMTPPassword,
+		"LDAP_BIND_PASSWORD":     &c.LDAP.BindPassword,
+	}
+	for i := range c.Federation.Providers {
+		p := &c.Federation.Providers[i]
+		secrets[providerSecretName(p.Name)] = &p.ClientSecret
+	}
+
+	return secrets
+}
+
+// providerSecretName — имя секрета провайдера федерации:
+// FEDERATION_{NAME}_CLIENT_SECRET, дефисы в имени заменяются на _
+func providerSecretName(name string) string {
+	return "FEDERATION_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_CLIENT_SECRET"
+}
+
+// SecretProviders — источники секретов по убыванию приоритета: окружение,
+// файлы из {name}_FILE и Vault, если задан VAULT_ADDR. Токен Vault берётся
+// из окружения или VAULT_TOKEN_FILE.
+func (c *Config) SecretProviders(ctx context.Context) ([]SecretProvider, error) {
+	providers := []SecretProvider{EnvSecrets{}, FileSecrets{}}
+
+	s := &c.Secrets
+	if s.VaultAddr == "" {
+		return providers, nil
+	}
+
+	if err := resolveSecret(ctx, providers, "VAULT_TOKEN", &s.VaultToken); err != nil {
+		return nil, err
+	}
+
+	vault := NewVaultSecrets(s.VaultAddr, s.VaultToken.Reveal(), s.VaultPath, &http.Client{Timeout: s.VaultTimeout})
+
+	return append(providers, vault), nil
+}
+
+// ResolveSecrets заменяет секреты значениями первого провайдера, у которого
+// они есть; если нет ни у одного, остаётся значение из файла конфигурации
+func (c *Config) ResolveSecrets(ctx context.Context, providers []SecretProvider) error {
+	for name, secret := range c.secrets() {
+		if err := resolveSecret(ctx, providers, name, secret); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func resolveSecret(ctx context.Context, providers []SecretProvider, name string, secret *Secret) error {
+	for _, p := range providers {
+		value, ok, err := p.Secret(ctx, name)
+		if err != nil {
+			return fmt.Errorf("secret %s: %w", name, err)
+		}
+		if ok {
+			*secret = Secret(value)
+			return nil
+		}
+	}
+
+	return nil
+}
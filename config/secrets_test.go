@@ -0,0 +1,171 @@
+package config_test
+
+import (
+	"auth-service/config"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// vaultStub отвечает как KV v2 Vault на GET /v1/secret/data/auth-service
+func vaultStub(t *testing.T, token string, data map[string]string) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != token {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/v1/secret/data/auth-service" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"data": map[string]any{
+				"data":     data,
+				"metadata": map[string]any{"version": 1},
+			},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestLoad_SecretsFromFiles(t *testing.T) {
+	t.Setenv("JWT_SECRET_FILE", writeFile(t, "jwt_secret", "from-file\n"))
+	t.Setenv("DB_PASSWORD_FILE", writeFile(t, "db_password", "db-pass"))
+
+	cfg, err := config.Load("")
+	require.NoError(t, err)
+
+	assert.Equal(t, "from-file", cfg.JWTSecret.Reveal())
+	assert.Equal(t, "db-pass", cfg.DB.Password.Reveal())
+}
+
+func TestLoad_EnvOverridesSecretFile(t *testing.T) {
+	t.Setenv("JWT_SECRET", "from-env")
+	t.Setenv("JWT_SECRET_FILE", writeFile(t, "jwt_secret", "from-file"))
+
+	cfg, err := config.Load("")
+	require.NoError(t, err)
+
+	assert.Equal(t, "from-env", cfg.JWTSecret.Reveal())
+}
+
+func TestLoad_MissingSecretFile(t *testing.T) {
+	t.Setenv("JWT_SECRET_FILE", "/nonexistent/jwt_secret")
+
+	_, err := config.Load("")
+	assert.ErrorContains(t, err, "JWT_SECRET_FILE")
+}
+
+func TestLoad_SecretsFromVault(t *testing.T) {
+	vault := vaultStub(t, "root-token", map[string]string{
+		"JWT_SECRET":  "from-vault",
+		"DB_PASSWORD": "vault-db-pass",
+	})
+	t.Setenv("VAULT_ADDR", vault.URL)
+	t.Setenv("VAULT_TOKEN_FILE", writeFile(t, "vault_token", "root-token\n"))
+	// переменная окружения важнее Vault
+	t.Setenv("DB_PASSWORD", "env-db-pass")
+
+	cfg, err := config.Load("")
+	require.NoError(t, err)
+
+	assert.Equal(t, "from-vault", cfg.JWTSecret.Reveal())
+	assert.Equal(t, "env-db-pass", cfg.DB.Password.Reveal())
+}
+
+func TestLoad_VaultDenied(t *testing.T) {
+	vault := vaultStub(t, "root-token", nil)
+	t.Setenv("VAULT_ADDR", vault.URL)
+	t.Setenv("VAULT_TOKEN", "wrong-token")
+
+	_, err := config.Load("")
+	assert.ErrorContains(t, err, "403")
+}
+
+func TestLoad_FederationClientSecrets(t *testing.T) {
+	vault := vaultStub(t, "root-token", map[string]string{
+		"JWT_SECRET":                           "jwt-secret",
+		"FEDERATION_PARTNER_SSO_CLIENT_SECRET": "from-vault",
+	})
+	t.Setenv("VAULT_ADDR", vault.URL)
+	t.Setenv("VAULT_TOKEN", "root-token")
+	t.Setenv("FEDERATION_PROVIDERS", `[
+		{"name":"corp","issuer":"https://sso.example.com","client_id":"auth"},
+		{"name":"partner-sso","issuer":"https://partner.example.com","client_id":"auth"},
+		{"name":"legacy","issuer":"https://legacy.example.com","client_id":"auth","client_secret":"inline"}
+	]`)
+	t.Setenv("FEDERATION_CORP_CLIENT_SECRET_FILE", writeFile(t, "corp_secret", "from-file\n"))
+
+	cfg, err := config.Load("")
+	require.NoError(t, err)
+
+	providers := cfg.Federation.Providers
+	require.Len(t, providers, 3)
+	assert.Equal(t, "from-file", providers[0].ClientSecret.Reveal())
+	assert.Equal(t, "from-vault", providers[1].ClientSecret.Reveal())
+	// ни у одного источника нет секрета: остаётся значение из конфигурации
+	assert.Equal(t, "inline", providers[2].ClientSecret.Reveal())
+}
+
+func TestLoad_FederationProvidersSharingSecretName(t *testing.T) {
+	t.Setenv("FEDERATION_PROVIDERS", `[
+		{"name":"corp-sso","issuer":"https://sso.example.com","client_id":"auth"},
+		{"name":"corp_sso","issuer":"https://sso.example.com","client_id":"auth"}
+	]`)
+
+	_, err := config.Load("")
+	assert.ErrorContains(t, err, `duplicate federation provider "corp_sso"`)
+}
+
+func TestVaultSecrets_KVv1(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"JWT_SECRET":"v1-secret"}}`))
+	}))
+	defer srv.Close()
+
+	vault := config.NewVaultSecrets(srv.URL, "token", "kv/auth-service", srv.Client())
+
+	value, ok, err := vault.Secret(context.Background(), "JWT_SECRET")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "v1-secret", value)
+
+	_, ok, err = vault.Secret(context.Background(), "DB_PASSWORD")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestSecret_Redacted(t *testing.T) {
+	t.Setenv("JWT_SECRET", "top-secret-jwt")
+	t.Setenv("DB_PASSWORD", "top-secret-db")
+
+	cfg, err := config.Load("")
+	require.NoError(t, err)
+
+	var logs bytes.Buffer
+	slog.New(slog.NewJSONHandler(&logs, nil)).Info("config", slog.Any("cfg", cfg), slog.Any("jwt", cfg.JWTSecret))
+	slog.New(slog.NewTextHandler(&logs, nil)).Info("config", slog.Any("cfg", cfg), slog.Any("db", cfg.DB))
+
+	for _, out := range []string{
+		fmt.Sprint(cfg),
+		fmt.Sprintf("%+v", *cfg),
+		fmt.Sprintf("%#v", *cfg),
+		fmt.Sprintf("%s %q", cfg.JWTSecret, cfg.DB.Password),
+		logs.String(),
+	} {
+		assert.NotContains(t, out, "top-secret")
+		assert.Contains(t, out, "[REDACTED]")
+	}
+}
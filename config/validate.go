@@ -0,0 +1,227 @@
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// допустимые значения перечислимых параметров
+var (
+	logLevels       = []string{"debug", "info", "warn", "error"}
+	envs            = []string{"local", "dev", "prod"}
+	sslModes        = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+	tracingExporter = []string{"none", "stdout", "otlp"}
+	rateLimitStores = []string{"memory", "redis"}
+)
+
+// длина мастер-ключа секретов приложений после base64
+const masterKeyLen = 32
+
+// problems собирает все ошибки конфигурации, чтобы показать их при старте разом
+type problems []error
+
+// addf добавляет ошибку параметра; name — имя переменной окружения
+func (p *problems) addf(name, format string, args ...any) {
+	*p = append(*p, fmt.Errorf("%s: %s", name, fmt.Sprintf(format, args...)))
+}
+
+func (p *problems) port(name, value string, optional bool) {
+	if value == "" && optional {
+		return
+	}
+	if n, err := strconv.Atoi(value); err != nil || n < 1 || n > 65535 {
+		p.addf(name, "must be a port number 1-65535, got %q", value)
+	}
+}
+
+func (p *problems) positive(name string, d time.Duration) {
+	if d <= 0 {
+		p.addf(name, "must be a positive duration, got %s", d)
+	}
+}
+
+func (p *problems) oneOf(name, value string, allowed []string) {
+	if !slices.Contains(allowed, value) {
+		p.addf(name, "must be one of %s, got %q", strings.Join(allowed, ", "), value)
+	}
+}
+
+func (p *problems) hostPort(name, value string) {
+	if _, port, err := net.SplitHostPort(value); err != nil || port == "" {
+		p.addf(name, "must be host:port, got %q", value)
+	}
+}
+
+func (p *problems) absURL(name, value string, schemes ...string) {
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" || !slices.Contains(schemes, u.Scheme) {
+		p.addf(name, "must be an absolute %s URL, got %q", strings.Join(schemes, "/"), value)
+	}
+}
+
+func (p *problems) check(name string, err error) {
+	if err != nil {
+		p.addf(name, "%v", err)
+	}
+}
+
+// Validate проверяет все параметры и возвращает ошибку со списком всех нарушений
+func (c *Config) Validate() error {
+	var p problems
+
+	p.oneOf("LOG_LEVEL", c.LogLevel, logLevels)
+	p.oneOf("ENV", c.Env, envs)
+	if c.JWTSecret == "" {
+		p.addf("JWT_SECRET", "is required")
+	}
+	// без мастер-ключа сервис не может хранить секреты приложений и не стартует
+	if c.AppSecretsMasterKey == "" {
+		p.addf("APP_SECRETS_MASTER_KEY", "is required")
+	} else if key, err := base64.StdEncoding.DecodeString(c.AppSecretsMasterKey.Reveal()); err != nil || len(key) != masterKeyLen {
+		p.addf("APP_SECRETS_MASTER_KEY", "must be %d bytes in base64", masterKeyLen)
+	}
+	p.positive("TOKEN_TTL", c.TokenTTL)
+
+	if c.DB.Host == "" {
+		p.addf("DB_HOST", "is required")
+	}
+	p.port("DB_PORT", c.DB.Port, false)
+	if c.DB.User == "" {
+		p.addf("DB_USER", "is required")
+	}
+	if c.DB.Name == "" {
+		p.addf("DB_NAME", "is required")
+	}
+	p.oneOf("DB_SSLMODE", c.DB.SSLMode, sslModes)
+	if c.DB.MaxConns < 1 {
+		p.addf("DB_MAX_CONNS", "must be at least 1, got %d", c.DB.MaxConns)
+	}
+	if c.DB.MinConns < 0 || c.DB.MinConns > c.DB.MaxConns {
+		p.addf("DB_MIN_CONNS", "must be between 0 and DB_MAX_CONNS, got %d", c.DB.MinConns)
+	}
+	p.positive("DB_MAX_CONN_LIFETIME", c.DB.MaxConnLifetime)
+	p.positive("DB_MAX_CONN_IDLE_TIME", c.DB.MaxConnIdleTime)
+	p.positive("DB_HEALTH_CHECK_PERIOD", c.DB.HealthCheckPeriod)
+	p.positive("DB_CONNECT_TIMEOUT", c.DB.ConnectTimeout)
+	if c.DB.ConnectAttempts < 1 {
+		p.addf("DB_CONNECT_ATTEMPTS", "must be at least 1, got %d", c.DB.ConnectAttempts)
+	}
+	p.positive("DB_CONNECT_BACKOFF", c.DB.ConnectBackoff)
+	if c.DB.ConnectMaxBackoff < c.DB.ConnectBackoff {
+		p.addf("DB_CONNECT_MAX_BACKOFF", "must not be less than DB_CONNECT_BACKOFF, got %s", c.DB.ConnectMaxBackoff)
+	}
+
+	c.GRPC.validate(&p)
+
+	p.port("HTTP_SERVER_PORT", c.HTTP.ServerPort, false)
+	p.positive("HTTP_SERVER_READ_TIMEOUT", c.HTTP.ServerReadTimeout)
+	p.positive("HTTP_SERVER_WRITE_TIMEOUT", c.HTTP.ServerWriteTimeout)
+	p.positive("HTTP_SERVER_IDLE_TIMEOUT", c.HTTP.ServerIdleTimeout)
+	p.port("GATEWAY_SERVER_PORT", c.Gateway.ServerPort, true)
+	if c.Metrics.Addr != "" {
+		p.hostPort("METRICS_ADDR", c.Metrics.Addr)
+	}
+
+	p.positive("HEALTH_PING_INTERVAL", c.Health.PingInterval)
+	p.positive("HEALTH_PING_TIMEOUT", c.Health.PingTimeout)
+	if c.Health.DrainDelay < 0 {
+		p.addf("HEALTH_DRAIN_DELAY", "must not be negative, got %s", c.Health.DrainDelay)
+	}
+
+	p.oneOf("TRACING_EXPORTER", c.Tracing.Exporter, tracingExporter)
+	if c.Tracing.Exporter == "otlp" {
+		p.hostPort("TRACING_OTLP_ENDPOINT", c.Tracing.OTLPEndpoint)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		p.addf("TRACING_SAMPLE_RATIO", "must be between 0 and 1, got %v", c.Tracing.SampleRatio)
+	}
+
+	p.oneOf("RATE_LIMIT_STORE", c.RateLimit.Store, rateLimitStores)
+	if c.RateLimit.Store == "redis" {
+		p.absURL("RATE_LIMIT_REDIS_URL", c.RateLimit.RedisURL, "redis", "rediss")
+	}
+	p.check("RATE_LIMIT_POLICIES", c.RateLimit.Policies.validate())
+
+	p.positive("OAUTH_CODE_TTL", c.OAuth.CodeTTL)
+	p.positive("OAUTH_CONSENT_TICKET_TTL", c.OAuth.ConsentTicketTTL)
+	p.absURL("OIDC_ISSUER", c.OIDC.Issuer, "http", "https")
+	p.check("FEDERATION_PROVIDERS", c.Federation.Providers.validate())
+	p.positive("FEDERATION_STATE_TTL", c.Federation.StateTTL)
+	p.positive("API_KEY_TOKEN_TTL", c.APIKeys.TokenTTL)
+	p.positive("API_KEY_DEFAULT_TTL", c.APIKeys.DefaultTTL)
+
+	if c.Mail.SMTPAddr != "" {
+		p.hostPort("MAIL_SMTP_ADDR", c.Mail.SMTPAddr)
+	}
+	if _, err := mail.ParseAddress(c.Mail.From); err != nil {
+		p.addf("MAIL_FROM", "must be an email address, got %q", c.Mail.From)
+	}
+	p.positive("MAIL_TIMEOUT", c.Mail.Timeout)
+	p.positive("EMAIL_CHANGE_CONFIRM_TTL", c.EmailChange.ConfirmTTL)
+	p.positive("EMAIL_CHANGE_CANCEL_TTL", c.EmailChange.CancelTTL)
+
+	c.LDAP.validate(&p)
+
+	if c.Secrets.VaultAddr != "" {
+		p.absURL("VAULT_ADDR", c.Secrets.VaultAddr, "http", "https")
+		if c.Secrets.VaultToken == "" {
+			p.addf("VAULT_TOKEN", "is required when VAULT_ADDR is set")
+		}
+		p.positive("VAULT_TIMEOUT", c.Secrets.VaultTimeout)
+	}
+
+	if len(p) > 0 {
+		return fmt.Errorf("invalid config:\n%w", errors.Join(p...))
+	}
+	return nil
+}
+
+func (g *GRPCConfig) validate(p *problems) {
+	if g.ServerHost != "" && net.ParseIP(g.ServerHost) == nil && strings.ContainsAny(g.ServerHost, ":/ ") {
+		p.addf("GRPC_SERVER_HOST", "must be an IP address or host name, got %q", g.ServerHost)
+	}
+	p.port("GRPC_SERVER_PORT", g.ServerPort, false)
+	p.positive("GRPC_SERVER_READ_TIMEOUT", g.ServerReadTimeout)
+	p.positive("GRPC_SERVER_WRITE_TIMEOUT", g.ServerWriteTimeout)
+	p.positive("GRPC_SERVER_IDLE_TIMEOUT", g.ServerIdleTimeout)
+
+	t := g.TLS
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		p.addf("GRPC_TLS_CERT_FILE", "GRPC_TLS_CERT_FILE and GRPC_TLS_KEY_FILE must be set together")
+	}
+	if t.CertFile == "" && t.ClientCAFile != "" {
+		p.addf("GRPC_TLS_CLIENT_CA_FILE", "requires GRPC_TLS_CERT_FILE")
+	}
+	if t.ClientCAFile == "" && (t.RequireClientCert || len(t.AllowedSANs) > 0) {
+		p.addf("GRPC_TLS_CLIENT_CA_FILE", "is required for client certificate checks")
+	}
+	p.check("GRPC_TLS_ALLOWED_SANS", t.AllowedSANs.validate())
+	p.positive("GRPC_TLS_RELOAD_INTERVAL", t.ReloadInterval)
+}
+
+func (l *LDAPConfig) validate(p *problems) {
+	if l.URL == "" {
+		return
+	}
+
+	p.absURL("LDAP_URL", l.URL, "ldap", "ldaps")
+	if l.BaseDN == "" {
+		p.addf("LDAP_BASE_DN", "is required when LDAP_URL is set")
+	}
+	if strings.Count(l.UserFilter, "%s") != 1 {
+		p.addf("LDAP_USER_FILTER", "must contain %%s exactly once, got %q", l.UserFilter)
+	}
+	if l.EmailAttribute == "" {
+		p.addf("LDAP_EMAIL_ATTRIBUTE", "is required when LDAP_URL is set")
+	}
+	p.check("LDAP_GROUP_ROLES", l.GroupRoles.validate())
+	p.positive("LDAP_TIMEOUT", l.Timeout)
+}
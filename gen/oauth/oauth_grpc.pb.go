@@ -0,0 +1,125 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v6.32.0
+// source: oauth/oauth.proto
+
+package oauth
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	OAuth_ClientCredentials_FullMethodName = "/oauth.OAuth/ClientCredentials"
+)
+
+// OAuthClient is the client API for OAuth service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// OAuth — выдача токенов по грантам OAuth 2.0 без участия пользователя.
+type OAuthClient interface {
+	ClientCredentials(ctx context.Context, in *ClientCredentialsRequest, opts ...grpc.CallOption) (*TokenResponse, error)
+}
+
+type oAuthClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewOAuthClient(cc grpc.ClientConnInterface) OAuthClient {
+	return &oAuthClient{cc}
+}
+
+func (c *oAuthClient) ClientCredentials(ctx context.Context, in *ClientCredentialsRequest, opts ...grpc.CallOption) (*TokenResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TokenResponse)
+	err := c.cc.Invoke(ctx, OAuth_ClientCredentials_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// OAuthServer is the server API for OAuth service.
+// All implementations must embed UnimplementedOAuthServer
+// for forward compatibility.
+//
+// OAuth — выдача токенов по грантам OAuth 2.0 без участия пользователя.
+type OAuthServer interface {
+	ClientCredentials(context.Context, *ClientCredentialsRequest) (*TokenResponse, error)
+	mustEmbedUnimplementedOAuthServer()
+}
+
+// UnimplementedOAuthServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedOAuthServer struct{}
+
+func (UnimplementedOAuthServer) ClientCredentials(context.Context, *ClientCredentialsRequest) (*TokenResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ClientCredentials not implemented")
+}
+func (UnimplementedOAuthServer) mustEmbedUnimplementedOAuthServer() {}
+func (UnimplementedOAuthServer) testEmbeddedByValue()               {}
+
+// UnsafeOAuthServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to OAuthServer will
+// result in compilation errors.
+type UnsafeOAuthServer interface {
+	mustEmbedUnimplementedOAuthServer()
+}
+
+func RegisterOAuthServer(s grpc.ServiceRegistrar, srv OAuthServer) {
+	// If the following call pancis, it indicates UnimplementedOAuthServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&OAuth_ServiceDesc, srv)
+}
+
+func _OAuth_ClientCredentials_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClientCredentialsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OAuthServer).ClientCredentials(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OAuth_ClientCredentials_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OAuthServer).ClientCredentials(ctx, req.(*ClientCredentialsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// OAuth_ServiceDesc is the grpc.ServiceDesc for OAuth service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var OAuth_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "oauth.OAuth",
+	HandlerType: (*OAuthServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ClientCredentials",
+			Handler:    _OAuth_ClientCredentials_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "oauth/oauth.proto",
+}
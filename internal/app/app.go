@@ -3,40 +3,377 @@ package app
 import (
 	"auth-service/config"
 	"auth-service/internal/app/grpcapp"
+	"auth-service/internal/app/httpapp"
+	"auth-service/internal/appsecret"
 	"auth-service/internal/db"
+	"auth-service/internal/dynamic"
+	"auth-service/internal/emailaddr"
+	"auth-service/internal/federation"
+	"auth-service/internal/grpc/authgrpc"
+	"auth-service/internal/grpc/grpcauth"
+	"auth-service/internal/grpc/grpcratelimit"
+	"auth-service/internal/grpc/grpctls"
+	"auth-service/internal/health"
+	"auth-service/internal/http/emailhttp"
+	"auth-service/internal/http/federationhttp"
+	"auth-service/internal/http/gatewayhttp"
+	"auth-service/internal/http/healthhttp"
+	"auth-service/internal/http/oauthhttp"
+	"auth-service/internal/http/oidchttp"
+	"auth-service/internal/jwt"
+	"auth-service/internal/ldapauth"
+	"auth-service/internal/logger"
+	"auth-service/internal/mail"
+	"auth-service/internal/metrics"
+	"auth-service/internal/model"
+	"auth-service/internal/ratelimit"
 	"auth-service/internal/repository"
 	"auth-service/internal/service"
-
+	"auth-service/internal/tracing"
+	"auth-service/internal/validation"
+	"context"
+	"fmt"
 	"log/slog"
-	"time"
+	"net"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
 )
 
 type App struct {
 	log     *slog.Logger
 	GRPCSrv *grpcapp.App
+	HTTPSrv *httpapp.App
+	// GatewaySrv — REST/JSON шлюз; nil, если GATEWAY_SERVER_PORT пуст
+	GatewaySrv *httpapp.App
+	// MetricsSrv отдаёт /metrics; nil, если METRICS_ADDR пуст
+	MetricsSrv *httpapp.App
+	// Tracing отправляет накопленные спаны при остановке
+	Tracing *tracing.Provider
+	// Health пингует базу для статуса готовности; запускается через Run
+	Health *health.Checker
+
+	// параметры, которые меняет Reload
+	limiter *grpcratelimit.Limiter
+	ttls    ttls
+}
+
+// ttls — сроки жизни, общие для сервисов и обработчиков
+type ttls struct {
+	token        *dynamic.Duration
+	apiKeyToken  *dynamic.Duration
+	apiKey       *dynamic.Duration
+	oauthCode    *dynamic.Duration
+	oauthConsent *dynamic.Duration
+	emailConfirm *dynamic.Duration
+	emailCancel  *dynamic.Duration
+	federation   *dynamic.Duration
+}
+
+func newTTLs(cfg *config.Config) ttls {
+	return ttls{
+		token:        dynamic.NewDuration(cfg.TokenTTL),
+		apiKeyToken:  dynamic.NewDuration(cfg.APIKeys.TokenTTL),
+		apiKey:       dynamic.NewDuration(cfg.APIKeys.DefaultTTL),
+		oauthCode:    dynamic.NewDuration(cfg.OAuth.CodeTTL),
+		oauthConsent: dynamic.NewDuration(cfg.OAuth.ConsentTicketTTL),
+		emailConfirm: dynamic.NewDuration(cfg.EmailChange.ConfirmTTL),
+		emailCancel:  dynamic.NewDuration(cfg.EmailChange.CancelTTL),
+		federation:   dynamic.NewDuration(cfg.Federation.StateTTL),
+	}
 }
 
-func New(log *slog.Logger, grpcPort string, dbCfg *config.DBConfig, tokenTTL time.Duration, jwtSecret string) (*App, error) {
+func (t ttls) set(cfg *config.Config) {
+	t.token.Set(cfg.TokenTTL)
+	t.apiKeyToken.Set(cfg.APIKeys.TokenTTL)
+	t.apiKey.Set(cfg.APIKeys.DefaultTTL)
+	t.oauthCode.Set(cfg.OAuth.CodeTTL)
+	t.oauthConsent.Set(cfg.OAuth.ConsentTicketTTL)
+	t.emailConfirm.Set(cfg.EmailChange.ConfirmTTL)
+	t.emailCancel.Set(cfg.EmailChange.CancelTTL)
+	t.federation.Set(cfg.Federation.StateTTL)
+}
+
+func New(log *slog.Logger, cfg *config.Config) (*App, error) {
+	const op = "app.New"
+
+	// 0. Ключ для шифрования секретов приложений
+	envelope, err := appsecret.LoadEnvelope(cfg.AppSecretsMasterKey.Reveal())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// трассировка ставится до всего остального, чтобы спаны были и у первых запросов
+	tracer, err := tracing.New(context.Background(), log, cfg.Tracing)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// сроки жизни читаются при каждом вызове, чтобы их менял Reload
+	ttl := newTTLs(cfg)
+
 	// 1. Инициализация базы данных
-	db := db.InitPostgres(dbCfg, log)
+	db, err := db.InitPostgres(context.Background(), &cfg.DB, log)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := metrics.RegisterDB(db, cfg.DB.Name); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// готовность сервиса следует за доступностью базы
+	checker := health.New(log, db, cfg.Health.PingInterval, cfg.Health.PingTimeout)
 
 	// 2. Создание репозитория пользователей (реализует UserSaver, UserProvider, AppProvider)
 	userRepo := repository.NewUserRepository(db)
 
-	// 3. Создание сервиса аутентификации
+	// email приводится к одному виду при регистрации, входе, смене адреса
+	// и для пользователей из LDAP и внешних провайдеров
+	emails := emailaddr.Normalizer{FoldLocalPart: cfg.Email.FoldLocalPart}
+
+	// 3. Создание сервиса аутентификации: локальные пароли и, если настроен, LDAP
+	authenticators := map[string]service.Authenticator{}
+	if cfg.LDAP.URL != "" {
+		authenticators[model.AuthenticatorLDAP] = service.NewLDAPAuthenticator(
+			log,
+			ldapauth.New(cfg.LDAP),
+			userRepo,
+			emails,
+			len(cfg.LDAP.GroupRoles) > 0,
+		)
+	}
 	authSrv := service.New(
 		log,
 		userRepo, // UserSaver
 		userRepo, // UserProvider
 		userRepo, // AppProvider
-		tokenTTL,
-		jwtSecret,
+		authenticators,
+		emails,
+		ttl.token,
+		cfg.JWTSecret.Reveal(),
 	)
-	// 4. Создание приложения с gRPC сервером
-	grpcApp := grpcapp.New(log, grpcPort, authSrv)
+	// 4. Сервис управления приложениями с журналом аудита
+	appsSrv := service.NewApps(
+		log,
+		repository.NewAppRepository(db),
+		envelope,
+	)
+	// проверка bearer-токенов и прав вызывающего по правилам методов
+	authenticator := grpcauth.New(log, cfg.JWTSecret.Reveal(), userRepo, grpcauth.DefaultRules)
+	// API ключи пользователей и сервисных аккаунтов
+	apiKeysSrv := service.NewAPIKeys(
+		log,
+		repository.NewAPIKeyRepository(db),
+		userRepo, // UserByIDProvider
+		userRepo, // AppProvider
+		cfg.JWTSecret.Reveal(),
+		ttl.apiKeyToken,
+		ttl.apiKey,
+	)
+
+	// администрирование пользователей
+	usersSrv := service.NewUsers(log, userRepo, emails)
+
+	// внешний адрес HTTP сервера: issuer OIDC и ссылки из писем
+	issuer := strings.TrimSuffix(cfg.OIDC.Issuer, "/")
+
+	// профиль текущего пользователя и смена email с подтверждением по почте
+	profileSrv := service.NewProfile(log, userRepo, userRepo)
+	emailChangeSrv := service.NewEmailChange(
+		log,
+		userRepo,
+		repository.NewEmailChangeRepository(db),
+		mail.New(cfg.Mail, log),
+		emails,
+		issuer,
+		ttl.emailConfirm,
+		ttl.emailCancel,
+	)
+
+	// проверка app_id в запросах общая для gRPC и REST шлюза
+	appChecker := validation.NewAppChecker(userRepo)
+
+	// TLS для gRPC; без GRPC_TLS_CERT_FILE сервер работает без шифрования
+	grpcCreds, err := grpctls.New(log, cfg.GRPC.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// ограничение частоты вызовов; без RATE_LIMIT_POLICIES — правила по умолчанию
+	limiter, err := newLimiter(log, cfg.RateLimit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// 5. Создание приложения с gRPC сервером
+	grpcApp := grpcapp.New(
+		log,
+		cfg.GRPC,
+		authSrv,
+		appsSrv,
+		apiKeysSrv,
+		usersSrv,
+		profileSrv,
+		emailChangeSrv,
+		authenticator,
+		appChecker,
+		checker,
+		cfg.Health.DrainDelay,
+		grpcCreds,
+		limiter,
+		ttl.token,
+		ttl.apiKeyToken,
+	)
+
+	// 6. OAuth 2.0 authorization code + PKCE и OpenID Connect поверх тех же репозиториев
+	idTokenSigner, err := newIDTokenSigner(log, cfg.OIDC.SigningKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	oauthRepo := repository.NewOAuthRepository(db)
+	oauthSrv := service.NewOAuth(
+		log,
+		authSrv,  // UserAuthenticator
+		userRepo, // UserByIDProvider
+		userRepo, // AppProvider
+		oauthRepo,
+		oauthRepo,
+		idTokenSigner,
+		envelope,
+		issuer,
+		cfg.JWTSecret.Reveal(),
+		ttl.token,
+		ttl.oauthCode,
+		ttl.oauthConsent,
+	)
+
+	// 7. Вход через внешних OIDC провайдеров
+	providers := make([]service.IdentityProvider, 0, len(cfg.Federation.Providers))
+	for _, p := range cfg.Federation.Providers {
+		redirectURL := issuer + "/federation/" + p.Name + "/callback"
+		providers = append(providers, federation.NewProvider(p, redirectURL))
+	}
+	federationSrv := service.NewFederation(
+		log,
+		providers,
+		userRepo,
+		repository.NewIdentityRepository(db),
+		userRepo, // AppProvider
+		emails,
+		cfg.JWTSecret.Reveal(),
+		ttl.token,
+		ttl.federation,
+	)
+
+	// 8. HTTP сервер рядом с gRPC
+	mux := http.NewServeMux()
+	oauthhttp.Register(mux, oauthSrv, authSrv, limiter, ttl.token, log)
+	oidchttp.Register(mux, oauthSrv, idTokenSigner, issuer, log)
+	federationhttp.Register(mux, federationSrv, ttl.token, strings.HasPrefix(issuer, "https://"), log)
+	emailhttp.Register(mux, emailChangeSrv, log)
+	if cfg.Health.HTTPEnabled {
+		healthhttp.Register(mux, checker)
+	}
+	httpApp := httpapp.New(
+		log,
+		net.JoinHostPort("", cfg.HTTP.ServerPort),
+		mux,
+		cfg.HTTP.ServerReadTimeout,
+		cfg.HTTP.ServerWriteTimeout,
+		cfg.HTTP.ServerIdleTimeout,
+	)
+
+	// 9. REST/JSON шлюз к тем же обработчикам, что и gRPC
+	var gatewayApp *httpapp.App
+	if cfg.Gateway.ServerPort != "" {
+		gatewayMux := http.NewServeMux()
+		gatewayhttp.Register(
+			gatewayMux,
+			authgrpc.NewServer(authSrv, appChecker, log),
+			[]grpc.UnaryServerInterceptor{limiter.UnaryServerInterceptor(), authenticator.UnaryServerInterceptor()},
+			log,
+		)
+		gatewayApp = httpapp.New(
+			log,
+			net.JoinHostPort("", cfg.Gateway.ServerPort),
+			gatewayMux,
+			cfg.HTTP.ServerReadTimeout,
+			cfg.HTTP.ServerWriteTimeout,
+			cfg.HTTP.ServerIdleTimeout,
+		)
+	}
+
+	// 10. Метрики Prometheus на отдельном адресе, чтобы не открывать их вместе с OAuth
+	var metricsApp *httpapp.App
+	if cfg.Metrics.Addr != "" {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("GET /metrics", metrics.Handler())
+		metricsApp = httpapp.New(
+			log,
+			cfg.Metrics.Addr,
+			metricsMux,
+			cfg.HTTP.ServerReadTimeout,
+			cfg.HTTP.ServerWriteTimeout,
+			cfg.HTTP.ServerIdleTimeout,
+		)
+	}
 
 	return &App{
-		log:     log,
-		GRPCSrv: grpcApp,
+		log:        log,
+		GRPCSrv:    grpcApp,
+		HTTPSrv:    httpApp,
+		GatewaySrv: gatewayApp,
+		MetricsSrv: metricsApp,
+		Tracing:    tracer,
+		Health:     checker,
+		limiter:    limiter,
+		ttls:       ttl,
 	}, nil
 }
+
+// Reload применяет новую конфигурацию без перезапуска: уровень логов,
+// правила ограничения частоты и сроки жизни. Адреса, TLS, база и остальные
+// параметры вступают в силу только после перезапуска.
+func (a *App) Reload(cfg *config.Config) {
+	logger.SetLevel(cfg.LogLevel)
+	a.limiter.SetPolicies(limiterPolicies(cfg.RateLimit))
+	a.ttls.set(cfg)
+
+	a.log.Info("config reloaded")
+}
+
+// newIDTokenSigner загружает ключ подписи id_token или генерирует временный
+func newIDTokenSigner(log *slog.Logger, keyFile string) (*jwt.IDTokenSigner, error) {
+	if keyFile != "" {
+		return jwt.LoadIDTokenSigner(keyFile)
+	}
+
+	log.Warn("OIDC_SIGNING_KEY_FILE is not set, using an ephemeral id_token signing key")
+
+	return jwt.GenerateIDTokenSigner()
+}
+
+// newLimiter создаёт ограничитель вызовов. Хранилище создаётся и для
+// выключенного, чтобы RATE_LIMIT_ENABLED можно было включить через Reload.
+func newLimiter(log *slog.Logger, cfg config.RateLimitConfig) (*grpcratelimit.Limiter, error) {
+	store, err := ratelimit.NewStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return grpcratelimit.New(log, store, limiterPolicies(cfg)), nil
+}
+
+// limiterPolicies — правила из конфигурации; выключенный ограничитель
+// не ограничивает ничего, без RATE_LIMIT_POLICIES действуют правила по умолчанию
+func limiterPolicies(cfg config.RateLimitConfig) config.RateLimitPolicies {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.Policies == nil {
+		return grpcratelimit.DefaultPolicies
+	}
+	return cfg.Policies
+}
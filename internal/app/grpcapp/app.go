@@ -1,31 +1,125 @@
 package grpcapp
 
 import (
+	"auth-service/config"
+	"auth-service/internal/dynamic"
+	"auth-service/internal/grpc/apikeysgrpc"
+	"auth-service/internal/grpc/appsgrpc"
 	"auth-service/internal/grpc/authgrpc"
+	"auth-service/internal/grpc/grpcauth"
+	"auth-service/internal/grpc/grpcerr"
+	"auth-service/internal/grpc/grpclog"
+	"auth-service/internal/grpc/grpcmetrics"
+	"auth-service/internal/grpc/grpcratelimit"
+	"auth-service/internal/grpc/grpctls"
+	"auth-service/internal/grpc/oauthgrpc"
+	"auth-service/internal/grpc/profilegrpc"
+	"auth-service/internal/grpc/usersgrpc"
+	"auth-service/internal/health"
 	"auth-service/internal/service"
+	"auth-service/internal/validation"
 	"context"
 	"fmt"
 	"log/slog"
 	"net"
+	"time"
 
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
 )
 
 type App struct {
 	log        *slog.Logger
 	gRPCServer *grpc.Server
-	port       string
+	health     *health.Checker
+	drainDelay time.Duration
+	addr       string
 	listener   net.Listener
 }
 
-func New(log *slog.Logger, addr string, authSvc *service.Auth) *App {
-	gRPCServer := grpc.NewServer()
-	authgrpc.Register(gRPCServer, authSvc, log) // <- передаём готовый экземпляр Auth
+func New(
+	log *slog.Logger,
+	// адрес, таймауты и TLS из GRPC_*
+	cfg config.GRPCConfig,
+	authSvc *service.Auth,
+	appsSvc *service.Apps,
+	apiKeysSvc *service.APIKeys,
+	usersSvc *service.Users,
+	profileSvc *service.Profile,
+	emailChangeSvc *service.EmailChange,
+	authenticator *grpcauth.Authenticator,
+	appChecker *validation.AppChecker,
+	checker *health.Checker,
+	// пауза между переводом готовности в NOT_SERVING и остановкой приёма вызовов
+	drainDelay time.Duration,
+	// creds включает TLS; nil — сервер без TLS
+	creds *grpctls.Credentials,
+	limiter *grpcratelimit.Limiter,
+	tokenTTL *dynamic.Duration,
+	apiKeyTokenTTL *dynamic.Duration,
+) *App {
+	// otelgrpc открывает серверный спан по trace context из metadata
+	// раньше перехватчиков, поэтому trace_id уже есть в логгере запроса;
+	// метрики снаружи всех перехватчиков, чтобы видеть итоговый код вызова;
+	// grpclog за ними: request ID и access-лог охватывают весь вызов,
+	// паника в любом перехватчике или обработчике становится Internal;
+	// проверка SAN клиентского сертификата, лимиты и проверка токена отклоняют
+	// вызов до обработчика;
+	// ошибки предметной области переводятся в статусы в одном месте
+	unary := []grpc.UnaryServerInterceptor{
+		grpcmetrics.UnaryServerInterceptor(),
+		grpclog.UnaryServerInterceptor(log),
+		callTimeout(cfg.ServerWriteTimeout),
+	}
+	stream := []grpc.StreamServerInterceptor{
+		grpcmetrics.StreamServerInterceptor(),
+		grpclog.StreamServerInterceptor(log),
+	}
+	opts := []grpc.ServerOption{
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ConnectionTimeout(cfg.ServerReadTimeout),
+		grpc.KeepaliveParams(keepalive.ServerParameters{MaxConnectionIdle: cfg.ServerIdleTimeout}),
+	}
+	if creds != nil {
+		opts = append(opts, creds.ServerOption())
+		unary = append(unary, creds.UnaryServerInterceptor())
+		stream = append(stream, creds.StreamServerInterceptor())
+	}
+	unary = append(unary,
+		limiter.UnaryServerInterceptor(),
+		authenticator.UnaryServerInterceptor(),
+		grpcerr.UnaryServerInterceptor(log),
+	)
+	stream = append(stream, authenticator.StreamServerInterceptor())
+	opts = append(opts, grpc.ChainUnaryInterceptor(unary...), grpc.ChainStreamInterceptor(stream...))
+
+	gRPCServer := grpc.NewServer(opts...)
+	authgrpc.Register(gRPCServer, authSvc, appChecker, log) // <- передаём готовый экземпляр Auth
+	appsgrpc.Register(gRPCServer, appsSvc, log)
+	oauthgrpc.Register(gRPCServer, authSvc, tokenTTL, log)
+	apikeysgrpc.Register(gRPCServer, apiKeysSvc, appChecker, apiKeyTokenTTL, log)
+	usersgrpc.Register(gRPCServer, usersSvc, log)
+	profilegrpc.Register(gRPCServer, profileSvc, emailChangeSvc, log)
+	healthpb.RegisterHealthServer(gRPCServer, checker.Server())
 
 	return &App{
 		log:        log,
 		gRPCServer: gRPCServer,
-		port:       addr,
+		health:     checker,
+		drainDelay: drainDelay,
+		addr:       net.JoinHostPort(cfg.ServerHost, cfg.ServerPort),
+	}
+}
+
+// callTimeout ограничивает длительность унарного вызова; более короткий
+// дедлайн клиента остаётся в силе
+func callTimeout(timeout time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return handler(ctx, req)
 	}
 }
 
@@ -35,10 +129,10 @@ func (a *App) Run() error {
 
 	log := a.log.With(
 		slog.String("op", op),
-		slog.String("port", a.port),
+		slog.String("addr", a.addr),
 	)
 
-	l, err := net.Listen("tcp", fmt.Sprintf(":%s", a.port))
+	l, err := net.Listen("tcp", a.addr)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
@@ -56,7 +150,16 @@ func (a *App) Run() error {
 // Shutdown реализует интерфейс Stoppable для graceful shutdown
 func (a *App) Shutdown(ctx context.Context) error {
 	const op = "grpcapp.Shutdown"
-	a.log.With(slog.String("op", op)).Info("starting graceful shutdown", slog.String("port", a.port))
+	a.log.With(slog.String("op", op)).Info("starting graceful shutdown", slog.String("addr", a.addr))
+
+	// проба готовности видит NOT_SERVING, а сервер ещё drainDelay принимает
+	// новые вызовы, пока балансировщик не исключит реплику
+	a.health.Shutdown()
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(a.drainDelay):
+	}
 
 	done := make(chan struct{})
 	go func() {
@@ -78,7 +181,7 @@ func (a *App) Shutdown(ctx context.Context) error {
 func (a *App) Stop() {
 	const op = "grpcapp.Stop"
 
-	a.log.With(slog.String("op", op)).Info("stopping gRPC server", slog.String("port", a.port))
+	a.log.With(slog.String("op", op)).Info("stopping gRPC server", slog.String("addr", a.addr))
 
 	a.gRPCServer.GracefulStop()
 }
@@ -0,0 +1,76 @@
+package httpapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+type App struct {
+	log        *slog.Logger
+	httpServer *http.Server
+	addr       string
+}
+
+// New создаёт HTTP сервер, который слушает addr (host:port, host может быть пустым)
+func New(log *slog.Logger, addr string, handler http.Handler, readTimeout, writeTimeout, idleTimeout time.Duration) *App {
+	return &App{
+		log: log,
+		httpServer: &http.Server{
+			Addr:              addr,
+			Handler:           handler,
+			ReadHeaderTimeout: readTimeout,
+			ReadTimeout:       readTimeout,
+			WriteTimeout:      writeTimeout,
+			IdleTimeout:       idleTimeout,
+		},
+		addr: addr,
+	}
+}
+
+// Запуск HTTP сервера
+func (a *App) Run() error {
+	const op = "httpapp.Run"
+
+	log := a.log.With(slog.String("op", op))
+
+	l, err := net.Listen("tcp", a.httpServer.Addr)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("http server is running", slog.String("addr", l.Addr().String()))
+
+	if err := a.httpServer.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Shutdown реализует интерфейс Stoppable для graceful shutdown
+func (a *App) Shutdown(ctx context.Context) error {
+	const op = "httpapp.Shutdown"
+	a.log.With(slog.String("op", op)).Info("starting graceful shutdown", slog.String("addr", a.addr))
+
+	if err := a.httpServer.Shutdown(ctx); err != nil {
+		a.log.With(slog.String("op", op)).Warn("graceful shutdown failed", slog.String("err", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	a.log.With(slog.String("op", op)).Info("graceful shutdown complete")
+	return nil
+}
+
+// Остановка сервера
+func (a *App) Stop() {
+	const op = "httpapp.Stop"
+
+	a.log.With(slog.String("op", op)).Info("stopping http server", slog.String("addr", a.addr))
+
+	_ = a.httpServer.Close()
+}
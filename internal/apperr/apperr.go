@@ -0,0 +1,50 @@
+// Package apperr описывает ошибки предметной области: код, по которому
+// транспорт выбирает статус, машинную причину и сообщение, которое безопасно
+// показать клиенту. Остальные ошибки считаются внутренними.
+package apperr
+
+import "errors"
+
+// Code — класс ошибки независимо от транспорта
+type Code int
+
+const (
+	Internal Code = iota
+	InvalidArgument
+	NotFound
+	AlreadyExists
+	Unauthenticated
+	PermissionDenied
+	FailedPrecondition
+)
+
+// Error — ошибка предметной области. Сравнивается по указателю, поэтому
+// объявленные переменные работают с errors.Is как обычные sentinel-ошибки.
+type Error struct {
+	Code Code
+	// Reason — причина в UPPER_SNAKE_CASE для errdetails.ErrorInfo
+	Reason string
+	// Message уходит клиенту как есть
+	Message string
+}
+
+func New(code Code, reason, message string) *Error {
+	return &Error{
+		Code:    code,
+		Reason:  reason,
+		Message: message,
+	}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// As возвращает первую ошибку предметной области в цепочке err
+func As(err error) (*Error, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
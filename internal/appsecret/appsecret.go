@@ -0,0 +1,164 @@
+// Package appsecret отвечает за хранение секретов приложений:
+// хеш для проверки при аутентификации клиента и envelope-шифрование
+// для случаев, когда секрет нужно восстановить (HMAC-подпись).
+package appsecret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+const (
+	// версия формата зашифрованного секрета
+	envelopeVersion byte = 1
+
+	keyLen = 32 // AES-256
+)
+
+var (
+	ErrNoMasterKey      = errors.New("APP_SECRETS_MASTER_KEY is required")
+	ErrInvalidMasterKey = errors.New("master key must be 32 bytes encoded in base64")
+	ErrMalformed        = errors.New("malformed encrypted secret")
+)
+
+// Hash возвращает SHA-256 секрета. Секреты генерируются сервером
+// и имеют 256 бит энтропии, поэтому медленный KDF здесь не нужен.
+func Hash(secret string) []byte {
+	sum := sha256.Sum256([]byte(secret))
+	return sum[:]
+}
+
+// Verify сравнивает секрет с хешем за постоянное время
+func Verify(hash []byte, secret string) bool {
+	return subtle.ConstantTimeCompare(hash, Hash(secret)) == 1
+}
+
+// ParseMasterKey декодирует мастер-ключ из base64
+func ParseMasterKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != keyLen {
+		return nil, ErrInvalidMasterKey
+	}
+	return key, nil
+}
+
+// LoadEnvelope создаёт Envelope из мастер-ключа в base64, заданного в конфиге
+func LoadEnvelope(encodedMasterKey string) (*Envelope, error) {
+	if encodedMasterKey == "" {
+		return nil, ErrNoMasterKey
+	}
+
+	key, err := ParseMasterKey(encodedMasterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewEnvelope(key)
+}
+
+// Envelope шифрует каждый секрет своим ключом данных (DEK),
+// а сам DEK — мастер-ключом из конфигурации.
+//
+// Формат: version | wrapped DEK (nonce+ciphertext) | nonce | ciphertext
+type Envelope struct {
+	master cipher.AEAD
+}
+
+func NewEnvelope(masterKey []byte) (*Envelope, error) {
+	if len(masterKey) != keyLen {
+		return nil, ErrInvalidMasterKey
+	}
+
+	aead, err := newGCM(masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Envelope{master: aead}, nil
+}
+
+// Seal шифрует секрет
+func (e *Envelope) Seal(secret string) ([]byte, error) {
+	dek := make([]byte, keyLen)
+	if _, err := rand.Read(dek); err != nil {
+		return nil, fmt.Errorf("generate dek: %w", err)
+	}
+
+	wrappedDEK, err := seal(e.master, dek)
+	if err != nil {
+		return nil, fmt.Errorf("wrap dek: %w", err)
+	}
+
+	dataAEAD, err := newGCM(dek)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := seal(dataAEAD, []byte(secret))
+	if err != nil {
+		return nil, fmt.Errorf("encrypt secret: %w", err)
+	}
+
+	out := make([]byte, 0, 1+len(wrappedDEK)+len(ciphertext))
+	out = append(out, envelopeVersion)
+	out = append(out, wrappedDEK...)
+	out = append(out, ciphertext...)
+
+	return out, nil
+}
+
+// Open расшифровывает секрет, зашифрованный Seal
+func (e *Envelope) Open(sealed []byte) (string, error) {
+	wrappedLen := e.master.NonceSize() + keyLen + e.master.Overhead()
+	if len(sealed) < 1+wrappedLen || sealed[0] != envelopeVersion {
+		return "", ErrMalformed
+	}
+
+	dek, err := open(e.master, sealed[1:1+wrappedLen])
+	if err != nil {
+		return "", fmt.Errorf("unwrap dek: %w", err)
+	}
+
+	dataAEAD, err := newGCM(dek)
+	if err != nil {
+		return "", err
+	}
+
+	secret, err := open(dataAEAD, sealed[1+wrappedLen:])
+	if err != nil {
+		return "", fmt.Errorf("decrypt secret: %w", err)
+	}
+
+	return string(secret), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal возвращает nonce, за которым следует шифротекст
+func seal(aead cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(aead cipher.AEAD, data []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, ErrMalformed
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, nil)
+}
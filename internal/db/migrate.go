@@ -1,28 +1,34 @@
 package db
 
 import (
-	"database/sql"
+	"fmt"
 	"log/slog"
 
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/pressly/goose/v3"
 )
 
-// Migrate применяет миграции из указанной директории к базе
-func Migrate(db *sql.DB, dir string, logger *slog.Logger) {
+// Migrate применяет миграции из указанной директории к базе. goose работает
+// через database/sql, поэтому пул оборачивается в *sql.DB на время миграций.
+func Migrate(pool *pgxpool.Pool, dir string, logger *slog.Logger) error {
 	const op = "db.Migrate"
 	log := logger.With(slog.String("op", op))
 
+	db := stdlib.OpenDBFromPool(pool)
+	defer func() { _ = db.Close() }()
+
 	// Устанавливаем диалект базы
 	if err := goose.SetDialect("postgres"); err != nil {
-		log.Error("failed to set dialect", "error", err)
-		panic(err)
+		return fmt.Errorf("%s: set dialect: %w", op, err)
 	}
 
 	// Применяем все миграции из директории
 	if err := goose.Up(db, dir); err != nil {
-		log.Error("failed to apply migrations", "error", err)
-		panic(err)
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
 	log.Info("Migrations applied successfully")
+
+	return nil
 }
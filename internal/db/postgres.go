@@ -1,60 +1,91 @@
 package db
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
 	"log/slog"
+	"net"
+	"net/url"
 	"time"
 
 	config "auth-service/config"
 
-	_ "github.com/jackc/pgx/v5/stdlib" // драйвер PostgreSQL для database/sql
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-// InitPostgres создает подключение к PostgreSQL и возвращает *sql.DB
-func InitPostgres(cfg *config.DBConfig, logger *slog.Logger) *sql.DB {
+// InitPostgres создаёт пул соединений с PostgreSQL и дожидается, пока база
+// ответит на ping: до cfg.ConnectAttempts попыток с паузой, растущей вдвое
+func InitPostgres(ctx context.Context, cfg *config.DBConfig, logger *slog.Logger) (*pgxpool.Pool, error) {
 	const op = "db.Postgres"
 
 	log := logger.With(slog.String("op", op))
 
-	dsn := fmt.Sprintf(
-		"postgres://%s:%s@%s:%s/%s?sslmode=%s",
-		cfg.User,
-		cfg.Password,
-		cfg.Host,
-		cfg.Port,
-		cfg.Name,
-		cfg.SSLMode,
-	)
-
-	// Открываем подключение (но пока без проверки)
-	db, err := sql.Open("pgx", dsn)
+	poolCfg, err := pgxpool.ParseConfig(dsn(cfg))
 	if err != nil {
-		log.Error("Failed to open DB connection", "error", err)
-		panic(err)
+		return nil, fmt.Errorf("%s: parse config: %w", op, err)
+	}
+
+	// Настройка пула соединений
+	poolCfg.MaxConns = cfg.MaxConns
+	poolCfg.MinConns = cfg.MinConns
+	poolCfg.MaxConnLifetime = cfg.MaxConnLifetime
+	poolCfg.MaxConnIdleTime = cfg.MaxConnIdleTime
+	poolCfg.HealthCheckPeriod = cfg.HealthCheckPeriod
+	poolCfg.ConnConfig.ConnectTimeout = cfg.ConnectTimeout
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
 	// Пинг с повторными попытками
-	var pingErr error
-	for i := 0; i < 10; i++ {
-		pingErr = db.Ping()
-		if pingErr == nil {
+	backoff := cfg.ConnectBackoff
+	for attempt := 1; ; attempt++ {
+		err = ping(ctx, pool, cfg.ConnectTimeout)
+		if err == nil {
 			log.Info("Successfully connected to PostgreSQL")
+			return pool, nil
+		}
+		if attempt >= cfg.ConnectAttempts {
 			break
 		}
-		log.Warn("DB not ready yet, retrying", "attempt", i+1, "error", pingErr)
-		time.Sleep(2 * time.Second)
-	}
 
-	if pingErr != nil {
-		log.Error("Failed to connect to PostgreSQL after 10 attempts", "error", pingErr)
-		panic(pingErr)
+		log.Warn("DB not ready yet, retrying",
+			slog.Int("attempt", attempt),
+			slog.Duration("backoff", backoff),
+			slog.String("error", err.Error()),
+		)
+
+		select {
+		case <-ctx.Done():
+			pool.Close()
+			return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, cfg.ConnectMaxBackoff)
 	}
 
-	// Настройка пула соединений
-	db.SetMaxOpenConns(25)                 // максимум открытых соединений
-	db.SetMaxIdleConns(25)                 // максимум неактивных соединений
-	db.SetConnMaxLifetime(5 * time.Minute) // время жизни соединения
+	pool.Close()
+
+	return nil, fmt.Errorf("%s: connect after %d attempts: %w", op, cfg.ConnectAttempts, err)
+}
+
+func ping(ctx context.Context, pool *pgxpool.Pool, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return pool.Ping(ctx)
+}
+
+// dsn собирает URL подключения; пароль и имя пользователя экранируются
+func dsn(cfg *config.DBConfig) string {
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.User, cfg.Password.Reveal()),
+		Host:     net.JoinHostPort(cfg.Host, cfg.Port),
+		Path:     "/" + cfg.Name,
+		RawQuery: url.Values{"sslmode": {cfg.SSLMode}}.Encode(),
+	}
 
-	return db
+	return u.String()
 }
@@ -0,0 +1,28 @@
+// Package dynamic — настройки, которые меняются без перезапуска
+// при перечитывании конфигурации по SIGHUP.
+package dynamic
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Duration — длительность, которую читают обработчики и меняет перезагрузка
+// конфигурации. Один экземпляр разделяют все, кто использует параметр.
+type Duration struct {
+	ns atomic.Int64
+}
+
+func NewDuration(d time.Duration) *Duration {
+	v := &Duration{}
+	v.Set(d)
+	return v
+}
+
+func (v *Duration) Get() time.Duration {
+	return time.Duration(v.ns.Load())
+}
+
+func (v *Duration) Set(d time.Duration) {
+	v.ns.Store(int64(d))
+}
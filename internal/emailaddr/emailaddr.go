@@ -0,0 +1,45 @@
+// Package emailaddr приводит адреса email к каноническому виду, в котором они
+// хранятся в users: без пробелов по краям, с доменом в нижнем регистре и в punycode.
+// Уникальность в базе регистронезависимая (индекс по lower(email)), поэтому
+// Bob@example.com и bob@example.com — одна учётная запись.
+package emailaddr
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+var ErrInvalid = errors.New("invalid email address")
+
+// Normalizer нормализует адреса email
+type Normalizer struct {
+	// FoldLocalPart — хранить локальную часть в нижнем регистре. Без него
+	// она сохраняется как введена при регистрации, но сравнивается всё равно без учёта регистра
+	FoldLocalPart bool
+}
+
+// Normalize возвращает канонический вид адреса или ErrInvalid
+func (n Normalizer) Normalize(addr string) (string, error) {
+	addr = strings.TrimSpace(addr)
+
+	// локальная часть в кавычках может содержать @, домен — нет
+	at := strings.LastIndexByte(addr, '@')
+	if at <= 0 || at == len(addr)-1 {
+		return "", ErrInvalid
+	}
+	local, domain := addr[:at], addr[at+1:]
+
+	// профиль Lookup приводит домен к нижнему регистру (UTS #46) и переводит IDN в punycode
+	domain, err := idna.Lookup.ToASCII(strings.TrimSuffix(domain, "."))
+	if err != nil || domain == "" {
+		return "", ErrInvalid
+	}
+
+	if n.FoldLocalPart {
+		local = strings.ToLower(local)
+	}
+
+	return local + "@" + domain, nil
+}
@@ -0,0 +1,39 @@
+package emailaddr_test
+
+import (
+	"auth-service/internal/emailaddr"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		fold bool
+		in   string
+		want string
+	}{
+		{name: "trim and domain case", in: "  Bob@Example.COM ", want: "Bob@example.com"},
+		{name: "fold local part", fold: true, in: "Bob@Example.COM", want: "bob@example.com"},
+		{name: "idn domain", in: "user@Пример.РФ", want: "user@xn--e1afmkfd.xn--p1ai"},
+		{name: "trailing dot", in: "user@example.com.", want: "user@example.com"},
+		{name: "quoted local part", in: `"a@b"@example.com`, want: `"a@b"@example.com`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := emailaddr.Normalizer{FoldLocalPart: tt.fold}.Normalize(tt.in)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNormalize_Invalid(t *testing.T) {
+	for _, in := range []string{"", "   ", "bob", "@example.com", "bob@", "bob@exa mple.com"} {
+		_, err := emailaddr.Normalizer{}.Normalize(in)
+		assert.ErrorIs(t, err, emailaddr.ErrInvalid, in)
+	}
+}
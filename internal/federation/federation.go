@@ -0,0 +1,148 @@
+package federation
+
+import (
+	"auth-service/config"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// таймаут запросов к провайдеру: discovery, JWKS и обмен кода
+const httpTimeout = 10 * time.Second
+
+var defaultScopes = []string{oidc.ScopeOpenID, "email"}
+
+// Identity — пользователь провайдера из проверенного id_token
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+// Provider — upstream OIDC провайдер. Discovery выполняется при первом
+// обращении и повторяется, пока не удастся, поэтому недоступный провайдер
+// не мешает запуску сервиса.
+type Provider struct {
+	cfg         config.FederationProvider
+	redirectURL string
+	client      *http.Client
+
+	mu       sync.Mutex
+	provider *oidc.Provider
+}
+
+// NewProvider создаёт провайдера; redirectURL — адрес нашего callback
+func NewProvider(cfg config.FederationProvider, redirectURL string) *Provider {
+	return &Provider{
+		cfg:         cfg,
+		redirectURL: redirectURL,
+		client:      &http.Client{Timeout: httpTimeout},
+	}
+}
+
+func (p *Provider) Name() string {
+	return p.cfg.Name
+}
+
+// AuthCodeURL возвращает адрес авторизации у провайдера с state, nonce и PKCE S256
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	provider, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	oauthCfg := p.oauthConfig(provider)
+
+	return oauthCfg.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier)), nil
+}
+
+// Exchange обменивает код на токены, проверяет подпись, iss, aud, срок
+// и nonce id_token и возвращает пользователя провайдера
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (Identity, error) {
+	provider, err := p.discover(ctx)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	ctx = oidc.ClientContext(ctx, p.client)
+	oauthCfg := p.oauthConfig(provider)
+
+	token, err := oauthCfg.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return Identity{}, fmt.Errorf("exchange code: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return Identity{}, errors.New("token response has no id_token")
+	}
+
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: p.cfg.ClientID}).Verify(ctx, rawIDToken)
+	if err != nil {
+		return Identity{}, fmt.Errorf("verify id_token: %w", err)
+	}
+
+	if idToken.Nonce != nonce {
+		return Identity{}, errors.New("id_token nonce mismatch")
+	}
+
+	var claims struct {
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return Identity{}, fmt.Errorf("decode id_token claims: %w", err)
+	}
+
+	return Identity{
+		Subject:       idToken.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+	}, nil
+}
+
+func (p *Provider) discover(ctx context.Context) (*oidc.Provider, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.provider != nil {
+		return p.provider, nil
+	}
+
+	// провайдер запоминает HTTP клиент из контекста и загружает им JWKS
+	discoveryCtx, cancel := context.WithTimeout(ctx, httpTimeout)
+	defer cancel()
+
+	provider, err := oidc.NewProvider(
+		oidc.ClientContext(discoveryCtx, p.client),
+		p.cfg.Issuer,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("discover %s: %w", p.cfg.Issuer, err)
+	}
+
+	p.provider = provider
+
+	return provider, nil
+}
+
+func (p *Provider) oauthConfig(provider *oidc.Provider) oauth2.Config {
+	scopes := p.cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = defaultScopes
+	}
+
+	return oauth2.Config{
+		ClientID:     p.cfg.ClientID,
+		ClientSecret: p.cfg.ClientSecret.Reveal(),
+		Endpoint:     provider.Endpoint(),
+		RedirectURL:  p.redirectURL,
+		Scopes:       scopes,
+	}
+}
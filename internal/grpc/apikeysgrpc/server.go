@@ -0,0 +1,188 @@
+package apikeysgrpc
+
+import (
+	"auth-service/gen/apikeys"
+	"auth-service/internal/dynamic"
+	"auth-service/internal/grpc/grpcauth"
+	"auth-service/internal/model"
+	"auth-service/internal/service"
+	"auth-service/internal/validation"
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 100
+	tokenTypeBearer = "Bearer"
+)
+
+type APIKeys interface {
+	CreateAPIKey(ctx context.Context, actorID int64, key model.APIKey, ttl time.Duration) (model.APIKey, string, error)
+	ListUserAPIKeys(ctx context.Context, userID, afterID int64, limit int) ([]model.APIKey, error)
+	ListServiceAPIKeys(ctx context.Context, appID int, afterID int64, limit int) ([]model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, actorID int64, actorIsAdmin bool, keyID int64) error
+	ExchangeAPIKey(ctx context.Context, raw string) (token string, scopes []string, err error)
+}
+
+type serverAPI struct {
+	apikeys.UnimplementedAPIKeysServer
+	keys     APIKeys
+	apps     *validation.AppChecker
+	tokenTTL *dynamic.Duration
+	log      *slog.Logger
+}
+
+// регистрация обработчика
+func Register(
+	gRPC *grpc.Server,
+	keysSvc *service.APIKeys,
+	apps *validation.AppChecker,
+	tokenTTL *dynamic.Duration,
+	logger *slog.Logger,
+) {
+	apikeys.RegisterAPIKeysServer(gRPC, &serverAPI{
+		keys:     keysSvc,
+		apps:     apps,
+		tokenTTL: tokenTTL,
+		log:      logger,
+	})
+}
+
+func (s *serverAPI) CreateAPIKey(ctx context.Context, req *apikeys.CreateAPIKeyRequest) (*apikeys.CreateAPIKeyResponse, error) {
+	// ключ сервисного аккаунта выпускает администратор (grpcauth.AdminIf), личный — сам пользователь
+	actor, err := grpcauth.Caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validation.ValidateCreateAPIKeyRequest(ctx, s.apps, req); err != nil {
+		return nil, err
+	}
+
+	key := model.APIKey{
+		Name:   req.GetName(),
+		AppID:  int(req.GetAppId()),
+		Scopes: req.GetScopes(),
+	}
+	if !req.GetServiceAccount() {
+		key.UserID = actor.UserID
+	}
+
+	key, raw, err := s.keys.CreateAPIKey(ctx, actor.UserID, key, time.Duration(req.GetTtlSeconds())*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	return &apikeys.CreateAPIKeyResponse{
+		ApiKey: toProto(key),
+		Key:    raw,
+	}, nil
+}
+
+func (s *serverAPI) ListAPIKeys(ctx context.Context, req *apikeys.ListAPIKeysRequest) (*apikeys.ListAPIKeysResponse, error) {
+	caller, err := grpcauth.Caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validation.ValidateListAPIKeysRequest(ctx, s.apps, req); err != nil {
+		return nil, err
+	}
+
+	// page_token — id последнего ключа предыдущей страницы
+	var afterID int64
+	if req.GetPageToken() != "" {
+		id, err := strconv.ParseInt(req.GetPageToken(), 10, 64)
+		if err != nil || id < 0 {
+			return nil, status.Error(codes.InvalidArgument, "invalid page_token")
+		}
+		afterID = id
+	}
+
+	pageSize := int(req.GetPageSize())
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	pageSize = min(pageSize, maxPageSize)
+
+	var list []model.APIKey
+	if req.GetServiceAccount() {
+		list, err = s.keys.ListServiceAPIKeys(ctx, int(req.GetAppId()), afterID, pageSize)
+	} else {
+		list, err = s.keys.ListUserAPIKeys(ctx, caller.UserID, afterID, pageSize)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &apikeys.ListAPIKeysResponse{ApiKeys: make([]*apikeys.APIKey, 0, len(list))}
+	for _, key := range list {
+		resp.ApiKeys = append(resp.ApiKeys, toProto(key))
+	}
+	if len(list) == pageSize {
+		resp.NextPageToken = strconv.FormatInt(list[len(list)-1].ID, 10)
+	}
+
+	return resp, nil
+}
+
+func (s *serverAPI) RevokeAPIKey(ctx context.Context, req *apikeys.RevokeAPIKeyRequest) (*apikeys.RevokeAPIKeyResponse, error) {
+	actor, err := grpcauth.Caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validation.ValidateRevokeAPIKeyRequest(req); err != nil {
+		return nil, err
+	}
+
+	if err := s.keys.RevokeAPIKey(ctx, actor.UserID, actor.IsAdmin, req.GetKeyId()); err != nil {
+		return nil, err
+	}
+
+	return &apikeys.RevokeAPIKeyResponse{}, nil
+}
+
+func (s *serverAPI) ExchangeAPIKey(ctx context.Context, req *apikeys.ExchangeAPIKeyRequest) (*apikeys.ExchangeAPIKeyResponse, error) {
+	if err := validation.ValidateExchangeAPIKeyRequest(req); err != nil {
+		return nil, err
+	}
+
+	token, scopes, err := s.keys.ExchangeAPIKey(ctx, req.GetKey())
+	if err != nil {
+		return nil, err
+	}
+
+	return &apikeys.ExchangeAPIKeyResponse{
+		AccessToken: token,
+		TokenType:   tokenTypeBearer,
+		ExpiresIn:   int64(s.tokenTTL.Get().Seconds()),
+		Scopes:      scopes,
+	}, nil
+}
+
+func toProto(key model.APIKey) *apikeys.APIKey {
+	resp := &apikeys.APIKey{
+		Id:        key.ID,
+		Prefix:    key.Prefix,
+		Name:      key.Name,
+		AppId:     int32(key.AppID),
+		UserId:    key.UserID,
+		Scopes:    key.Scopes,
+		ExpiresAt: timestamppb.New(key.ExpiresAt),
+		CreatedAt: timestamppb.New(key.CreatedAt),
+		Revoked:   !key.RevokedAt.IsZero(),
+	}
+	if !key.LastUsedAt.IsZero() {
+		resp.LastUsedAt = timestamppb.New(key.LastUsedAt)
+	}
+	return resp
+}
@@ -0,0 +1,180 @@
+package appsgrpc
+
+import (
+	"auth-service/gen/apps"
+	"auth-service/internal/grpc/grpcauth"
+	"auth-service/internal/model"
+	"auth-service/internal/service"
+	"auth-service/internal/validation"
+	"context"
+	"log/slog"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 100
+)
+
+type Apps interface {
+	CreateApp(ctx context.Context, actorID int64, app model.App) (model.App, string, error)
+	UpdateApp(ctx context.Context, actorID int64, app model.App) (model.App, error)
+	ListApps(ctx context.Context, afterID, limit int) ([]model.App, error)
+	RotateAppSecret(ctx context.Context, actorID int64, appID int) (string, error)
+	DeleteApp(ctx context.Context, actorID int64, appID int) error
+}
+
+type serverAPI struct {
+	apps.UnimplementedAppsServer
+	apps Apps
+	log  *slog.Logger
+}
+
+// регистрация обработчика
+func Register(gRPC *grpc.Server, appsSvc *service.Apps, logger *slog.Logger) {
+	apps.RegisterAppsServer(gRPC, &serverAPI{
+		apps: appsSvc,
+		log:  logger,
+	})
+}
+
+func (s *serverAPI) CreateApp(ctx context.Context, req *apps.CreateAppRequest) (*apps.CreateAppResponse, error) {
+	actor, err := grpcauth.Caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validation.ValidateCreateAppRequest(req); err != nil {
+		return nil, err
+	}
+
+	app, secret, err := s.apps.CreateApp(ctx, actor.UserID, model.App{
+		Name:           req.GetName(),
+		Scopes:         req.GetScopes(),
+		RedirectURIs:   req.GetRedirectUris(),
+		Authenticators: req.GetAuthenticators(),
+		SecretMode:     req.GetSecretMode(),
+		Public:         req.GetPublic(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &apps.CreateAppResponse{
+		App:    toProto(app),
+		Secret: secret,
+	}, nil
+}
+
+func (s *serverAPI) UpdateApp(ctx context.Context, req *apps.UpdateAppRequest) (*apps.UpdateAppResponse, error) {
+	actor, err := grpcauth.Caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validation.ValidateUpdateAppRequest(req); err != nil {
+		return nil, err
+	}
+
+	app, err := s.apps.UpdateApp(ctx, actor.UserID, model.App{
+		ID:             int(req.GetAppId()),
+		Name:           req.GetName(),
+		Scopes:         req.GetScopes(),
+		RedirectURIs:   req.GetRedirectUris(),
+		Authenticators: req.GetAuthenticators(),
+		Public:         req.GetPublic(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &apps.UpdateAppResponse{App: toProto(app)}, nil
+}
+
+func (s *serverAPI) ListApps(ctx context.Context, req *apps.ListAppsRequest) (*apps.ListAppsResponse, error) {
+	if err := validation.ValidateListAppsRequest(req); err != nil {
+		return nil, err
+	}
+
+	// page_token — id последнего приложения предыдущей страницы
+	afterID := 0
+	if req.GetPageToken() != "" {
+		id, err := strconv.Atoi(req.GetPageToken())
+		if err != nil || id < 0 {
+			return nil, status.Error(codes.InvalidArgument, "invalid page_token")
+		}
+		afterID = id
+	}
+
+	pageSize := int(req.GetPageSize())
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	pageSize = min(pageSize, maxPageSize)
+
+	list, err := s.apps.ListApps(ctx, afterID, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &apps.ListAppsResponse{Apps: make([]*apps.App, 0, len(list))}
+	for _, app := range list {
+		resp.Apps = append(resp.Apps, toProto(app))
+	}
+	if len(list) == pageSize {
+		resp.NextPageToken = strconv.Itoa(list[len(list)-1].ID)
+	}
+
+	return resp, nil
+}
+
+func (s *serverAPI) RotateAppSecret(ctx context.Context, req *apps.RotateAppSecretRequest) (*apps.RotateAppSecretResponse, error) {
+	actor, err := grpcauth.Caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validation.ValidateRotateAppSecretRequest(req); err != nil {
+		return nil, err
+	}
+
+	secret, err := s.apps.RotateAppSecret(ctx, actor.UserID, int(req.GetAppId()))
+	if err != nil {
+		return nil, err
+	}
+
+	return &apps.RotateAppSecretResponse{Secret: secret}, nil
+}
+
+func (s *serverAPI) DeleteApp(ctx context.Context, req *apps.DeleteAppRequest) (*apps.DeleteAppResponse, error) {
+	actor, err := grpcauth.Caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validation.ValidateDeleteAppRequest(req); err != nil {
+		return nil, err
+	}
+
+	if err := s.apps.DeleteApp(ctx, actor.UserID, int(req.GetAppId())); err != nil {
+		return nil, err
+	}
+
+	return &apps.DeleteAppResponse{}, nil
+}
+
+func toProto(app model.App) *apps.App {
+	return &apps.App{
+		Id:             int32(app.ID),
+		Name:           app.Name,
+		Scopes:         app.Scopes,
+		RedirectUris:   app.RedirectURIs,
+		Authenticators: app.Authenticators,
+		SecretMode:     app.SecretMode,
+		Public:         app.Public,
+	}
+}
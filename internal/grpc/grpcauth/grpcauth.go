@@ -0,0 +1,214 @@
+// Package grpcauth проверяет bearer-токены вызовов gRPC и права вызывающего
+// по правилам доступа к методам. Проверенный пользователь кладётся
+// в контекст как Principal.
+package grpcauth
+
+import (
+	"auth-service/internal/jwt"
+	"auth-service/internal/logger/sl"
+	"auth-service/internal/model"
+	"auth-service/internal/repository"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	authorizationHeader = "authorization"
+	bearerPrefix        = "bearer "
+)
+
+type UserProvider interface {
+	UserByID(ctx context.Context, userID int64) (model.User, error)
+}
+
+// Principal — пользователь, предъявивший действительный токен
+type Principal struct {
+	UserID  int64
+	Email   string
+	AppID   int
+	Scopes  []string
+	IsAdmin bool
+	// APIKeyID — токен получен обменом личного API ключа
+	APIKeyID int64
+	// Grant — грант OAuth, по которому токен выдан клиенту
+	Grant string
+}
+
+// Session сообщает, что токен выдан входом пользователя, а не OAuth клиенту
+// со scopes или обменом API ключа
+func (p Principal) Session() bool {
+	return p.APIKeyID == 0 && p.Grant == "" && len(p.Scopes) == 0
+}
+
+type ctxKey struct{}
+
+// NewContext возвращает контекст с проверенным пользователем
+func NewContext(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, ctxKey{}, p)
+}
+
+// FromContext возвращает пользователя, которого проверил Authenticator
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(ctxKey{}).(Principal)
+	return p, ok
+}
+
+// Caller возвращает пользователя из контекста или gRPC-ошибку Unauthenticated,
+// если метод вызван без проверки токена
+func Caller(ctx context.Context) (Principal, error) {
+	p, ok := FromContext(ctx)
+	if !ok {
+		return Principal{}, status.Error(codes.Unauthenticated, "authorization token is required")
+	}
+	return p, nil
+}
+
+// Authenticator проверяет токен и права вызывающего до обработчика
+type Authenticator struct {
+	log    *slog.Logger
+	secret string
+	users  UserProvider
+	rules  Rules
+}
+
+// New returns a new instance of the Authenticator. Методы без правила в rules отклоняются.
+func New(log *slog.Logger, secret string, users UserProvider, rules Rules) *Authenticator {
+	return &Authenticator{
+		log:    log,
+		secret: secret,
+		users:  users,
+		rules:  rules,
+	}
+}
+
+func (a *Authenticator) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := a.Authorize(ctx, info.FullMethod, req)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor проверяет потоковые вызовы; правила, которым
+// нужен запрос (Self, AdminIf), для потоков не применимы и отклоняют вызов
+func (a *Authenticator) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.Authorize(ss.Context(), info.FullMethod, nil)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// serverStream подменяет контекст потока контекстом с Principal
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// Authorize применяет правило метода и возвращает контекст с Principal
+// или gRPC-ошибку Unauthenticated/PermissionDenied
+func (a *Authenticator) Authorize(ctx context.Context, method string, req any) (context.Context, error) {
+	log := sl.FromContext(ctx, a.log).With(slog.String("method", method))
+
+	rule, ok := a.rules.match(method)
+	if !ok {
+		log.Error("no access rule for method, call rejected")
+		return nil, status.Error(codes.PermissionDenied, "method is not allowed")
+	}
+	if rule.Public {
+		return ctx, nil
+	}
+
+	p, err := a.principal(ctx, log)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rule.checkScope(p); err != nil {
+		log.Warn("access denied", slog.Int64("user_id", p.UserID), sl.Err(err))
+		return nil, err
+	}
+
+	if rule.Check != nil {
+		if err := rule.Check(p, req); err != nil {
+			log.Warn("access denied", slog.Int64("user_id", p.UserID), sl.Err(err))
+			return nil, err
+		}
+	}
+
+	return NewContext(ctx, p), nil
+}
+
+// principal проверяет bearer-токен и загружает пользователя: токены удалённых
+// и заблокированных пользователей не действуют, роль берётся из базы
+func (a *Authenticator) principal(ctx context.Context, log *slog.Logger) (Principal, error) {
+	token, err := BearerToken(ctx)
+	if err != nil {
+		return Principal{}, err
+	}
+
+	// машинные токены (client credentials) без user_id сюда не проходят
+	claims, err := jwt.ParseToken(token, a.secret)
+	if err != nil {
+		return Principal{}, status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	user, err := a.users.UserByID(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			log.Warn("token of unknown or deleted user", slog.Int64("user_id", claims.UserID))
+			return Principal{}, status.Error(codes.Unauthenticated, "invalid token")
+		}
+		log.Error("failed to get user", sl.Err(err))
+		return Principal{}, status.Error(codes.Internal, "internal error")
+	}
+	if user.Disabled() {
+		log.Warn("token of disabled user", slog.Int64("user_id", user.ID))
+		return Principal{}, status.Error(codes.PermissionDenied, "user is disabled")
+	}
+
+	return Principal{
+		UserID:   claims.UserID,
+		Email:    claims.Email,
+		AppID:    claims.AppID,
+		Scopes:   claims.Scopes,
+		IsAdmin:  user.IsAdmin,
+		APIKeyID: claims.APIKeyID,
+		Grant:    claims.Grant,
+	}, nil
+}
+
+// BearerToken достаёт токен из заголовка authorization входящих metadata
+func BearerToken(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", status.Error(codes.Unauthenticated, "authorization token is required")
+	}
+
+	values := md.Get(authorizationHeader)
+	if len(values) == 0 {
+		return "", status.Error(codes.Unauthenticated, "authorization token is required")
+	}
+
+	header := values[0]
+	if len(header) <= len(bearerPrefix) || !strings.EqualFold(header[:len(bearerPrefix)], bearerPrefix) {
+		return "", status.Error(codes.Unauthenticated, "authorization token must use the Bearer scheme")
+	}
+
+	return header[len(bearerPrefix):], nil
+}
@@ -0,0 +1,221 @@
+package grpcauth_test
+
+import (
+	"auth-service/gen/apikeys"
+	"auth-service/gen/profile"
+	"auth-service/gen/users"
+	"auth-service/internal/grpc/grpcauth"
+	"auth-service/internal/jwt"
+	"auth-service/internal/model"
+	"auth-service/internal/repository"
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/ILmira-116/protos/gen/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	secret     = "test-secret"
+	adminID    = 1
+	userID     = 2
+	disabledID = 3
+	deletedID  = 4
+)
+
+type userStore map[int64]model.User
+
+func (u userStore) UserByID(_ context.Context, userID int64) (model.User, error) {
+	user, ok := u[userID]
+	if !ok {
+		return model.User{}, repository.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func newAuthenticator() *grpcauth.Authenticator {
+	return grpcauth.New(slog.New(slog.NewTextHandler(io.Discard, nil)), secret, userStore{
+		adminID:    {ID: adminID, IsAdmin: true},
+		userID:     {ID: userID},
+		disabledID: {ID: disabledID, DisabledAt: time.Now()},
+	}, grpcauth.DefaultRules)
+}
+
+func withToken(t *testing.T, userID int64) context.Context {
+	t.Helper()
+
+	token, err := jwt.NewToken(model.User{ID: userID, Email: "user@example.com"}, model.App{ID: 3}, secret, time.Hour)
+	require.NoError(t, err)
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+}
+
+// withAPIKeyToken возвращает контекст с токеном, полученным обменом личного API ключа
+func withAPIKeyToken(t *testing.T, userID int64, scopes ...string) context.Context {
+	t.Helper()
+
+	token, err := jwt.NewAPIKeyToken(model.User{ID: userID, Email: "user@example.com"}, model.App{ID: 3}, 10, scopes, secret, time.Hour)
+	require.NoError(t, err)
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+}
+
+// call проходит перехватчик и возвращает Principal, который увидел обработчик
+func call(ctx context.Context, method string, req any) (grpcauth.Principal, error) {
+	var p grpcauth.Principal
+	_, err := newAuthenticator().UnaryServerInterceptor()(ctx, req, &grpc.UnaryServerInfo{FullMethod: method},
+		func(ctx context.Context, _ any) (any, error) {
+			p, _ = grpcauth.FromContext(ctx)
+			return nil, nil
+		})
+	return p, err
+}
+
+func TestUnary_PublicWithoutToken(t *testing.T) {
+	_, err := call(context.Background(), auth.Auth_Login_FullMethodName, &auth.LoginRequest{})
+	assert.NoError(t, err)
+}
+
+func TestUnary_TokenErrors(t *testing.T) {
+	clientToken, err := jwt.NewClientToken(model.App{ID: 3}, []string{"users:read"}, secret, time.Hour)
+	require.NoError(t, err)
+	foreign, err := jwt.NewToken(model.User{ID: adminID}, model.App{ID: 3}, "other-secret", time.Hour)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		md   metadata.MD
+	}{
+		{"no metadata", nil},
+		{"no header", metadata.MD{}},
+		{"basic scheme", metadata.Pairs("authorization", "Basic dXNlcjpwdw==")},
+		{"garbage", metadata.Pairs("authorization", "Bearer not-a-jwt")},
+		{"foreign secret", metadata.Pairs("authorization", "Bearer "+foreign)},
+		// машинный токен без user_id не представляет пользователя
+		{"client credentials token", metadata.Pairs("authorization", "Bearer "+clientToken)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, tt.md)
+			}
+
+			_, err := call(ctx, users.Users_ListUsers_FullMethodName, &users.ListUsersRequest{})
+			assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		})
+	}
+}
+
+func TestUnary_Admin(t *testing.T) {
+	_, err := call(withToken(t, userID), users.Users_ListUsers_FullMethodName, &users.ListUsersRequest{})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	p, err := call(withToken(t, adminID), users.Users_ListUsers_FullMethodName, &users.ListUsersRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, grpcauth.Principal{UserID: adminID, Email: "user@example.com", AppID: 3, Scopes: []string{}, IsAdmin: true}, p)
+}
+
+func TestUnary_SelfBindsToSubject(t *testing.T) {
+	p, err := call(withToken(t, userID), auth.Auth_IsAdmin_FullMethodName, &auth.IsAdminRequest{UserId: userID})
+	require.NoError(t, err)
+	assert.Equal(t, int64(userID), p.UserID)
+	assert.False(t, p.IsAdmin)
+
+	_, err = call(withToken(t, userID), auth.Auth_IsAdmin_FullMethodName, &auth.IsAdminRequest{UserId: adminID})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = call(context.Background(), auth.Auth_IsAdmin_FullMethodName, &auth.IsAdminRequest{UserId: userID})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// администратор видит роль любого пользователя
+	_, err = call(withToken(t, adminID), auth.Auth_IsAdmin_FullMethodName, &auth.IsAdminRequest{UserId: userID})
+	assert.NoError(t, err)
+}
+
+func TestUnary_AdminIfServiceAccount(t *testing.T) {
+	_, err := call(withToken(t, userID), apikeys.APIKeys_CreateAPIKey_FullMethodName, &apikeys.CreateAPIKeyRequest{})
+	assert.NoError(t, err)
+
+	_, err = call(withToken(t, userID), apikeys.APIKeys_CreateAPIKey_FullMethodName, &apikeys.CreateAPIKeyRequest{ServiceAccount: true})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = call(withToken(t, adminID), apikeys.APIKeys_CreateAPIKey_FullMethodName, &apikeys.CreateAPIKeyRequest{ServiceAccount: true})
+	assert.NoError(t, err)
+}
+
+func TestUnary_APIKeyTokenScopes(t *testing.T) {
+	ctx := withAPIKeyToken(t, userID, model.ScopeProfileRead)
+
+	p, err := call(ctx, profile.Profile_GetMe_FullMethodName, &profile.GetMeRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), p.APIKeyID)
+
+	_, err = call(ctx, profile.Profile_UpdateMe_FullMethodName, &profile.UpdateMeRequest{})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = call(ctx, apikeys.APIKeys_ListAPIKeys_FullMethodName, &apikeys.ListAPIKeysRequest{})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestUnary_APIKeyTokenCannotManageCredentials(t *testing.T) {
+	// даже со всеми scopes и ролью администратора
+	ctx := withAPIKeyToken(t, adminID, model.APIKeyScopes...)
+
+	methods := map[string]any{
+		apikeys.APIKeys_CreateAPIKey_FullMethodName:       &apikeys.CreateAPIKeyRequest{},
+		apikeys.APIKeys_RevokeAPIKey_FullMethodName:       &apikeys.RevokeAPIKeyRequest{},
+		profile.Profile_RequestEmailChange_FullMethodName: &profile.RequestEmailChangeRequest{},
+		users.Users_ListUsers_FullMethodName:              &users.ListUsersRequest{},
+	}
+	for method, req := range methods {
+		_, err := call(ctx, method, req)
+		assert.Equal(t, codes.PermissionDenied, status.Code(err), method)
+	}
+}
+
+func TestUnary_ScopedOAuthTokenIsNotASession(t *testing.T) {
+	token, err := jwt.NewScopedToken(model.User{ID: adminID}, model.App{ID: 3}, []string{"openid", "profile"}, secret, time.Hour)
+	require.NoError(t, err)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+
+	methods := map[string]any{
+		auth.Auth_IsAdmin_FullMethodName:            &auth.IsAdminRequest{UserId: adminID},
+		profile.Profile_GetMe_FullMethodName:        &profile.GetMeRequest{},
+		apikeys.APIKeys_CreateAPIKey_FullMethodName: &apikeys.CreateAPIKeyRequest{},
+		users.Users_ListUsers_FullMethodName:        &users.ListUsersRequest{},
+	}
+	for method, req := range methods {
+		_, err := call(ctx, method, req)
+		assert.Equal(t, codes.PermissionDenied, status.Code(err), method)
+	}
+}
+
+func TestUnary_DisabledAndDeletedUsers(t *testing.T) {
+	_, err := call(withToken(t, disabledID), profile.Profile_GetMe_FullMethodName, &profile.GetMeRequest{})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = call(withToken(t, deletedID), profile.Profile_GetMe_FullMethodName, &profile.GetMeRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// токен API ключа заблокированного пользователя тоже не действует
+	_, err = call(withAPIKeyToken(t, disabledID, model.ScopeProfileRead), profile.Profile_GetMe_FullMethodName, &profile.GetMeRequest{})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestUnary_UnknownMethodRejected(t *testing.T) {
+	_, err := call(withToken(t, adminID), "/unknown.Service/Method", nil)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestCaller_WithoutAuthenticator(t *testing.T) {
+	_, err := grpcauth.Caller(context.Background())
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
@@ -0,0 +1,142 @@
+package grpcauth
+
+import (
+	"auth-service/gen/apikeys"
+	"auth-service/gen/apps"
+	"auth-service/gen/oauth"
+	"auth-service/gen/profile"
+	"auth-service/gen/users"
+	"auth-service/internal/model"
+	"slices"
+	"strings"
+
+	"github.com/ILmira-116/protos/gen/auth"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// Rule — кто может вызвать метод
+type Rule struct {
+	// Public — метод доступен без токена
+	Public bool
+	// Check дополнительно проверяет пользователя с действительным токеном
+	Check func(p Principal, req any) error
+	// Scope нужен токену с ограниченными правами: выданному OAuth клиенту со scopes
+	// или обменом API ключа. Без Scope метод доступен только токенам входа.
+	Scope string
+}
+
+// WithScope возвращает правило, которое пускает токены со scope, а не только токены входа
+func (r Rule) WithScope(scope string) Rule {
+	r.Scope = scope
+	return r
+}
+
+var (
+	// Public — вход, регистрация и методы, защищённые своими секретами
+	Public = Rule{Public: true}
+	// Authenticated — любой пользователь с действительным токеном
+	Authenticated = Rule{}
+	// Admin — только администратор
+	Admin = Rule{Check: requireAdmin}
+	// Self — user_id запроса должен совпадать с пользователем токена;
+	// администратору доступен любой user_id
+	Self = Rule{Check: requireSelf}
+)
+
+// AdminIf требует роль администратора для запросов, на которых adminOnly
+// возвращает true; остальные доступны любому пользователю с токеном
+func AdminIf(adminOnly func(req any) bool) Rule {
+	return Rule{Check: func(p Principal, req any) error {
+		if req == nil || adminOnly(req) {
+			return requireAdmin(p, req)
+		}
+		return nil
+	}}
+}
+
+// Rules — правила по полному имени метода или "/{service}/*"
+type Rules map[string]Rule
+
+// DefaultRules — правила для всех сервисов, которые регистрирует grpcapp
+var DefaultRules = Rules{
+	auth.Auth_Register_FullMethodName: Public,
+	auth.Auth_Login_FullMethodName:    Public,
+	// роль пользователя видит он сам и администратор
+	auth.Auth_IsAdmin_FullMethodName: Self,
+
+	oauth.OAuth_ClientCredentials_FullMethodName: Public,
+
+	"/" + apps.Apps_ServiceDesc.ServiceName + "/*":   Admin,
+	"/" + users.Users_ServiceDesc.ServiceName + "/*": Admin,
+
+	profile.Profile_GetMe_FullMethodName:              Authenticated.WithScope(model.ScopeProfileRead),
+	profile.Profile_UpdateMe_FullMethodName:           Authenticated.WithScope(model.ScopeProfileWrite),
+	profile.Profile_RequestEmailChange_FullMethodName: Authenticated,
+	// подтверждение и отмена по токену из письма
+	profile.Profile_ConfirmEmailChange_FullMethodName: Public,
+	profile.Profile_CancelEmailChange_FullMethodName:  Public,
+
+	// ключ сервисного аккаунта выпускает и видит администратор, личный — сам пользователь.
+	// Токен API ключа не может выпускать и отзывать ключи.
+	apikeys.APIKeys_CreateAPIKey_FullMethodName: AdminIf(serviceAccount),
+	apikeys.APIKeys_ListAPIKeys_FullMethodName:  AdminIf(serviceAccount).WithScope(model.ScopeAPIKeysRead),
+	// чужой ключ отзывает администратор, это проверяет сервис
+	apikeys.APIKeys_RevokeAPIKey_FullMethodName:   Authenticated,
+	apikeys.APIKeys_ExchangeAPIKey_FullMethodName: Public,
+
+	"/" + healthpb.Health_ServiceDesc.ServiceName + "/*": Public,
+}
+
+// match ищет правило для метода, затем для всего сервиса
+func (r Rules) match(method string) (Rule, bool) {
+	if rule, ok := r[method]; ok {
+		return rule, true
+	}
+
+	i := strings.LastIndex(method, "/")
+	if i < 0 {
+		return Rule{}, false
+	}
+	rule, ok := r[method[:i+1]+"*"]
+	return rule, ok
+}
+
+// checkScope пускает токен с ограниченными правами, только если у него есть scope правила
+func (r Rule) checkScope(p Principal) error {
+	if p.Session() {
+		return nil
+	}
+	if r.Scope == "" {
+		return status.Error(codes.PermissionDenied, "method requires a session token")
+	}
+	if !slices.Contains(p.Scopes, r.Scope) {
+		return status.Errorf(codes.PermissionDenied, "scope %q required", r.Scope)
+	}
+	return nil
+}
+
+func requireAdmin(p Principal, _ any) error {
+	if !p.IsAdmin {
+		return status.Error(codes.PermissionDenied, "admin role required")
+	}
+	return nil
+}
+
+func requireSelf(p Principal, req any) error {
+	if p.IsAdmin {
+		return nil
+	}
+
+	r, ok := req.(interface{ GetUserId() int64 })
+	if !ok || r.GetUserId() != p.UserID {
+		return status.Error(codes.PermissionDenied, "access to another user is not allowed")
+	}
+	return nil
+}
+
+func serviceAccount(req any) bool {
+	r, ok := req.(interface{ GetServiceAccount() bool })
+	return ok && r.GetServiceAccount()
+}
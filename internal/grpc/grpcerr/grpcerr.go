@@ -0,0 +1,78 @@
+// Package grpcerr переводит ошибки обработчиков в gRPC-статусы в одном месте.
+package grpcerr
+
+import (
+	"auth-service/internal/apperr"
+	"auth-service/internal/logger/sl"
+	"context"
+	"errors"
+	"log/slog"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorDomain — domain в errdetails.ErrorInfo
+const ErrorDomain = "auth-service"
+
+var grpcCodes = map[apperr.Code]codes.Code{
+	apperr.Internal:           codes.Internal,
+	apperr.InvalidArgument:    codes.InvalidArgument,
+	apperr.NotFound:           codes.NotFound,
+	apperr.AlreadyExists:      codes.AlreadyExists,
+	apperr.Unauthenticated:    codes.Unauthenticated,
+	apperr.PermissionDenied:   codes.PermissionDenied,
+	apperr.FailedPrecondition: codes.FailedPrecondition,
+}
+
+// UnaryServerInterceptor заменяет ошибку обработчика gRPC-статусом:
+// ошибка предметной области даёт свой код и ErrorInfo с причиной,
+// готовый статус (например, из валидации) передаётся как есть,
+// остальное — Internal без подробностей для клиента
+func UnaryServerInterceptor(log *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return nil, ToStatus(sl.FromContext(ctx, log), info.FullMethod, err)
+		}
+		return resp, nil
+	}
+}
+
+// ToStatus переводит ошибку в gRPC-статус и логирует её
+func ToStatus(log *slog.Logger, method string, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	if e, ok := apperr.As(err); ok {
+		log.Warn("request failed",
+			slog.String("method", method),
+			slog.String("reason", e.Reason),
+			sl.Err(err),
+		)
+
+		code, ok := grpcCodes[e.Code]
+		if !ok {
+			code = codes.Internal
+		}
+		st, detailsErr := status.New(code, e.Message).WithDetails(&errdetails.ErrorInfo{
+			Reason: e.Reason,
+			Domain: ErrorDomain,
+		})
+		if detailsErr != nil {
+			return status.Error(code, e.Message)
+		}
+		return st.Err()
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		log.Warn("request aborted", slog.String("method", method), sl.Err(err))
+		return status.FromContextError(err).Err()
+	}
+
+	log.Error("request failed: internal error", slog.String("method", method), sl.Err(err))
+	return status.Error(codes.Internal, "internal error")
+}
@@ -0,0 +1,65 @@
+package grpcerr_test
+
+import (
+	"auth-service/internal/grpc/grpcerr"
+	"auth-service/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func call(t *testing.T, handlerErr error) *status.Status {
+	t.Helper()
+
+	interceptor := grpcerr.UnaryServerInterceptor(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/auth.Auth/Login"},
+		func(context.Context, any) (any, error) { return nil, handlerErr })
+	require.Error(t, err)
+
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	return st
+}
+
+func TestInterceptor_DomainError(t *testing.T) {
+	st := call(t, fmt.Errorf("auth.Login:%w", repository.ErrInvalidCredentials))
+
+	assert.Equal(t, codes.Unauthenticated, st.Code())
+	assert.Equal(t, "invalid credentials", st.Message())
+	require.Len(t, st.Details(), 1)
+	info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+	require.True(t, ok)
+	assert.Equal(t, "INVALID_CREDENTIALS", info.GetReason())
+	assert.Equal(t, grpcerr.ErrorDomain, info.GetDomain())
+}
+
+func TestInterceptor_InternalErrorIsHidden(t *testing.T) {
+	st := call(t, errors.New(`pq: duplicate key value violates unique constraint "users_email_key"`))
+
+	assert.Equal(t, codes.Internal, st.Code())
+	assert.Equal(t, "internal error", st.Message())
+	assert.Empty(t, st.Details())
+}
+
+func TestInterceptor_StatusPassesThrough(t *testing.T) {
+	st := call(t, status.Error(codes.InvalidArgument, "email is required"))
+
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	assert.Equal(t, "email is required", st.Message())
+}
+
+func TestInterceptor_ContextError(t *testing.T) {
+	st := call(t, fmt.Errorf("repository.GetUser: %w", context.DeadlineExceeded))
+
+	assert.Equal(t, codes.DeadlineExceeded, st.Code())
+}
@@ -0,0 +1,138 @@
+// Package grpclog — перехватчики, которые присваивают вызову request ID,
+// кладут логгер с ним в контекст, пишут одну строку access-лога на вызов
+// и превращают панику обработчика в codes.Internal.
+package grpclog
+
+import (
+	"auth-service/internal/logger/sl"
+	"auth-service/internal/random"
+	"auth-service/internal/tracing"
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// RequestIDHeader — ключ metadata с request ID во входящем запросе и в ответе
+const RequestIDHeader = "x-request-id"
+
+const (
+	requestIDLen    = 16
+	maxRequestIDLen = 128
+)
+
+// UnaryServerInterceptor должен стоять раньше остальных перехватчиков
+// (кроме метрик), чтобы логировать итоговый код и ловить панику в них
+func UnaryServerInterceptor(log *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		ctx, reqLog := newRequestContext(ctx, log)
+		start := time.Now()
+
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(reqLog, r)
+			}
+			accessLog(ctx, reqLog, info.FullMethod, start, err)
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+func StreamServerInterceptor(log *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		ctx, reqLog := newRequestContext(ss.Context(), log)
+		start := time.Now()
+
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(reqLog, r)
+			}
+			accessLog(ctx, reqLog, info.FullMethod, start, err)
+		}()
+
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// serverStream подменяет контекст потока контекстом с логгером запроса
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// newRequestContext берёт request ID из metadata или создаёт новый,
+// возвращает его клиенту в заголовке и кладёт логгер с ним в контекст.
+// Если вызов трассируется, логгер получает и trace_id серверного спана.
+func newRequestContext(ctx context.Context, log *slog.Logger) (context.Context, *slog.Logger) {
+	id := incomingRequestID(ctx)
+	if id == "" {
+		id, _ = random.Token(requestIDLen)
+	}
+
+	// заголовок нельзя отправить, например, вне gRPC-сервера; это не повод ронять вызов
+	_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDHeader, id))
+
+	reqLog := log.With(slog.String("request_id", id)).With(tracing.LogAttrs(ctx)...)
+	return sl.NewContext(ctx, reqLog), reqLog
+}
+
+// incomingRequestID возвращает request ID клиента, если он разумной длины
+// и из печатных ASCII-символов: значение попадает в логи как есть
+func incomingRequestID(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	values := md.Get(RequestIDHeader)
+	if len(values) == 0 || values[0] == "" || len(values[0]) > maxRequestIDLen {
+		return ""
+	}
+	for i := 0; i < len(values[0]); i++ {
+		if c := values[0][i]; c < 0x21 || c > 0x7e {
+			return ""
+		}
+	}
+	return values[0]
+}
+
+func recovered(log *slog.Logger, r any) error {
+	log.Error("handler panicked",
+		slog.String("panic", fmt.Sprint(r)),
+		slog.String("stack", string(debug.Stack())),
+	)
+	return status.Error(codes.Internal, "internal error")
+}
+
+// accessLog пишет одну строку на вызов; ошибки сервера — на уровне Error
+func accessLog(ctx context.Context, log *slog.Logger, method string, start time.Time, err error) {
+	code := status.Code(err)
+
+	level := slog.LevelInfo
+	switch code {
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable:
+		level = slog.LevelError
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("code", code.String()),
+		slog.Duration("latency", time.Since(start)),
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		attrs = append(attrs, slog.String("peer", p.Addr.String()))
+	}
+
+	log.LogAttrs(ctx, level, "grpc call", attrs...)
+}
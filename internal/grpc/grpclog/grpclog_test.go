@@ -0,0 +1,142 @@
+package grpclog_test
+
+import (
+	"auth-service/internal/grpc/grpclog"
+	"auth-service/internal/logger/sl"
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var info = &grpc.UnaryServerInfo{FullMethod: "/auth.Auth/Login"}
+
+// records разбирает JSON-строки лога
+func records(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var out []map[string]any
+	for line := range strings.Lines(buf.String()) {
+		var rec map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &rec))
+		out = append(out, rec)
+	}
+	return out
+}
+
+func TestUnary_RequestIDFromMetadata(t *testing.T) {
+	var buf bytes.Buffer
+	interceptor := grpclog.UnaryServerInterceptor(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(grpclog.RequestIDHeader, "req-42"))
+	_, err := interceptor(ctx, nil, info, func(ctx context.Context, _ any) (any, error) {
+		sl.FromContext(ctx, slog.Default()).Info("inside handler")
+		return "ok", nil
+	})
+	require.NoError(t, err)
+
+	recs := records(t, &buf)
+	require.Len(t, recs, 2)
+	for _, rec := range recs {
+		assert.Equal(t, "req-42", rec["request_id"])
+	}
+
+	access := recs[1]
+	assert.Equal(t, "grpc call", access["msg"])
+	assert.Equal(t, info.FullMethod, access["method"])
+	assert.Equal(t, codes.OK.String(), access["code"])
+	assert.Contains(t, access, "latency")
+}
+
+func TestUnary_GeneratesRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	interceptor := grpclog.UnaryServerInterceptor(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	// значение с пробелами и переводом строки в лог не попадает
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(grpclog.RequestIDHeader, "bad id\n"))
+	_, err := interceptor(ctx, nil, info, func(context.Context, any) (any, error) {
+		return nil, status.Error(codes.NotFound, "user not found")
+	})
+	require.Error(t, err)
+
+	recs := records(t, &buf)
+	require.Len(t, recs, 1)
+	assert.NotEmpty(t, recs[0]["request_id"])
+	assert.NotEqual(t, "bad id\n", recs[0]["request_id"])
+	assert.Equal(t, codes.NotFound.String(), recs[0]["code"])
+}
+
+func TestUnary_RecoversPanic(t *testing.T) {
+	var buf bytes.Buffer
+	interceptor := grpclog.UnaryServerInterceptor(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	_, err := interceptor(context.Background(), nil, info, func(context.Context, any) (any, error) {
+		panic("boom")
+	})
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	recs := records(t, &buf)
+	require.Len(t, recs, 2)
+	assert.Equal(t, "boom", recs[0]["panic"])
+	assert.Equal(t, "ERROR", recs[1]["level"])
+	assert.Equal(t, codes.Internal.String(), recs[1]["code"])
+}
+
+type stream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s stream) Context() context.Context { return s.ctx }
+
+func TestStream_RecoversPanicAndScopesLogger(t *testing.T) {
+	var buf bytes.Buffer
+	interceptor := grpclog.StreamServerInterceptor(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(grpclog.RequestIDHeader, "req-7"))
+	err := interceptor(nil, stream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: "/x.Y/Watch"},
+		func(_ any, ss grpc.ServerStream) error {
+			sl.FromContext(ss.Context(), slog.Default()).Info("inside stream")
+			panic("boom")
+		})
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	recs := records(t, &buf)
+	require.Len(t, recs, 3)
+	for _, rec := range recs {
+		assert.Equal(t, "req-7", rec["request_id"])
+	}
+}
+
+func TestUnary_TraceIDInLogger(t *testing.T) {
+	var buf bytes.Buffer
+	interceptor := grpclog.UnaryServerInterceptor(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	// traceparent из metadata разбирает otelgrpc до перехватчиков
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+
+	_, err := interceptor(ctx, nil, info, func(context.Context, any) (any, error) {
+		return "ok", nil
+	})
+	require.NoError(t, err)
+
+	recs := records(t, &buf)
+	require.Len(t, recs, 1)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", recs[0]["trace_id"])
+	assert.Equal(t, "00f067aa0ba902b7", recs[0]["span_id"])
+}
@@ -0,0 +1,36 @@
+// Package grpcmetrics — перехватчики, которые учитывают число, коды
+// и длительность gRPC вызовов в метриках Prometheus.
+package grpcmetrics
+
+import (
+	"auth-service/internal/metrics"
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor ставится перед grpclog, чтобы учитывать и вызовы,
+// паника в которых превращена в Internal
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+
+		resp, err := handler(ctx, req)
+		metrics.ObserveRPC(info.FullMethod, status.Code(err).String(), time.Since(start))
+
+		return resp, err
+	}
+}
+
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+
+		err := handler(srv, ss)
+		metrics.ObserveRPC(info.FullMethod, status.Code(err).String(), time.Since(start))
+
+		return err
+	}
+}
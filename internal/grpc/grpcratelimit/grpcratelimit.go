@@ -0,0 +1,178 @@
+// Package grpcratelimit ограничивает частоту вызовов gRPC методов
+// по правилам config.RateLimitPolicy: для каждого IP клиента, email
+// или app_id из запроса своя корзина токенов.
+package grpcratelimit
+
+import (
+	"auth-service/config"
+	"auth-service/internal/grpc/grpcerr"
+	"auth-service/internal/logger/sl"
+	"auth-service/internal/ratelimit"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ILmira-116/protos/gen/auth"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// ReasonRateLimited — причина в ErrorInfo отклонённого вызова
+const ReasonRateLimited = "RATE_LIMITED"
+
+// DefaultPolicies действуют, если RATE_LIMIT_POLICIES не задан:
+// перебор паролей ограничен и по IP, и по email, регистрация — по IP
+var DefaultPolicies = config.RateLimitPolicies{
+	{Method: auth.Auth_Login_FullMethodName, Key: config.RateLimitByIP, Rate: 5, Burst: 20},
+	{Method: auth.Auth_Login_FullMethodName, Key: config.RateLimitByEmail, Rate: 0.1, Burst: 5},
+	{Method: auth.Auth_Register_FullMethodName, Key: config.RateLimitByIP, Rate: 0.5, Burst: 30},
+}
+
+type Limiter struct {
+	log   *slog.Logger
+	store ratelimit.Store
+	// правила по методу или "/{service}/*"; меняются при перечитывании конфигурации
+	policies atomic.Pointer[map[string][]config.RateLimitPolicy]
+}
+
+// New returns a new instance of the Limiter. Без правил Limiter ничего не ограничивает.
+func New(log *slog.Logger, store ratelimit.Store, policies config.RateLimitPolicies) *Limiter {
+	l := &Limiter{
+		log:   log,
+		store: store,
+	}
+	l.SetPolicies(policies)
+
+	return l
+}
+
+// SetPolicies заменяет правила; корзины уже ограниченных ключей сохраняются
+func (l *Limiter) SetPolicies(policies config.RateLimitPolicies) {
+	byMethod := make(map[string][]config.RateLimitPolicy, len(policies))
+	for _, p := range policies {
+		byMethod[p.Method] = append(byMethod[p.Method], p)
+	}
+	l.policies.Store(&byMethod)
+}
+
+// UnaryServerInterceptor отклоняет вызов с ResourceExhausted до обработчика
+func (l *Limiter) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := l.Check(ctx, info.FullMethod, req); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// Check забирает по токену из корзин всех правил метода. Если хранилище
+// недоступно, вызов пропускается: отказ Redis не должен блокировать вход.
+func (l *Limiter) Check(ctx context.Context, method string, req any) error {
+	const op = "grpcratelimit.Check"
+
+	policies := l.match(method)
+	if len(policies) == 0 {
+		return nil
+	}
+
+	log := sl.FromContext(ctx, l.log).With(slog.String("op", op), slog.String("method", method))
+	now := time.Now()
+
+	for _, p := range policies {
+		value, ok := keyValue(ctx, p.Key, req)
+		if !ok {
+			continue
+		}
+
+		limit := ratelimit.Limit{Rate: p.Rate, Burst: p.Burst}
+		allowed, retryAfter, err := l.store.Take(ctx, p.Method+"|"+p.Key+"|"+value, limit, now)
+		if err != nil {
+			log.Error("rate limit store failed, allowing the call", sl.Err(err))
+			continue
+		}
+		if !allowed {
+			log.Warn("rate limit exceeded",
+				slog.String("key", p.Key),
+				slog.Duration("retry_after", retryAfter),
+			)
+			return exhausted(p.Key, retryAfter)
+		}
+	}
+
+	return nil
+}
+
+// match возвращает правила метода и правила всего его сервиса
+func (l *Limiter) match(method string) []config.RateLimitPolicy {
+	byMethod := *l.policies.Load()
+	policies := byMethod[method]
+	if i := strings.LastIndex(method, "/"); i >= 0 {
+		policies = append(policies[:len(policies):len(policies)], byMethod[method[:i+1]+"*"]...)
+	}
+	return policies
+}
+
+// keyValue достаёт значение ключа из вызова; ok == false, если его нет
+// (например, пустой email — такой запрос всё равно не пройдёт валидацию)
+func keyValue(ctx context.Context, key string, req any) (string, bool) {
+	switch key {
+	case config.RateLimitByIP:
+		p, ok := peer.FromContext(ctx)
+		if !ok || p.Addr == nil {
+			return "", false
+		}
+		host, _, err := net.SplitHostPort(p.Addr.String())
+		if err != nil {
+			return p.Addr.String(), true
+		}
+		return host, true
+
+	case config.RateLimitByEmail:
+		r, ok := req.(interface{ GetEmail() string })
+		if !ok {
+			return "", false
+		}
+		email := strings.ToLower(strings.TrimSpace(r.GetEmail()))
+		if email == "" {
+			return "", false
+		}
+		// в хранилище не попадают адреса пользователей
+		sum := sha256.Sum256([]byte(email))
+		return hex.EncodeToString(sum[:16]), true
+
+	case config.RateLimitByApp:
+		r, ok := req.(interface{ GetAppId() int32 })
+		if !ok || r.GetAppId() == 0 {
+			return "", false
+		}
+		return strconv.Itoa(int(r.GetAppId())), true
+	}
+
+	return "", false
+}
+
+// exhausted — ResourceExhausted с RetryInfo, чтобы клиент знал, когда повторить
+func exhausted(key string, retryAfter time.Duration) error {
+	st, err := status.New(codes.ResourceExhausted, "too many requests, retry later").WithDetails(
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)},
+		&errdetails.ErrorInfo{
+			Reason:   ReasonRateLimited,
+			Domain:   grpcerr.ErrorDomain,
+			Metadata: map[string]string{"key": key},
+		},
+	)
+	if err != nil {
+		return status.Error(codes.ResourceExhausted, "too many requests, retry later")
+	}
+	return st.Err()
+}
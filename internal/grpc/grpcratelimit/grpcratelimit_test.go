@@ -0,0 +1,141 @@
+package grpcratelimit_test
+
+import (
+	"auth-service/config"
+	"auth-service/internal/grpc/grpcratelimit"
+	"auth-service/internal/ratelimit"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ILmira-116/protos/gen/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+	login   = &grpc.UnaryServerInfo{FullMethod: auth.Auth_Login_FullMethodName}
+)
+
+func ok(context.Context, any) (any, error) { return "ok", nil }
+
+func fromIP(ip string) context.Context {
+	return peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 50000}})
+}
+
+func TestUnary_ExhaustedWithRetryInfo(t *testing.T) {
+	limiter := grpcratelimit.New(discard, ratelimit.NewMemoryStore(), config.RateLimitPolicies{
+		{Method: auth.Auth_Login_FullMethodName, Key: config.RateLimitByIP, Rate: 0.5, Burst: 2},
+	})
+	interceptor := limiter.UnaryServerInterceptor()
+	req := &auth.LoginRequest{Email: "a@example.com"}
+
+	for i := 0; i < 2; i++ {
+		_, err := interceptor(fromIP("10.0.0.1"), req, login, ok)
+		require.NoError(t, err)
+	}
+
+	_, err := interceptor(fromIP("10.0.0.1"), req, login, ok)
+	st := status.Convert(err)
+	require.Equal(t, codes.ResourceExhausted, st.Code())
+
+	var retry *errdetails.RetryInfo
+	var info *errdetails.ErrorInfo
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.RetryInfo:
+			retry = d
+		case *errdetails.ErrorInfo:
+			info = d
+		}
+	}
+	require.NotNil(t, retry)
+	assert.InDelta(t, 2*time.Second, retry.GetRetryDelay().AsDuration(), float64(100*time.Millisecond))
+	require.NotNil(t, info)
+	assert.Equal(t, grpcratelimit.ReasonRateLimited, info.GetReason())
+	assert.Equal(t, config.RateLimitByIP, info.GetMetadata()["key"])
+
+	// другой IP со своей корзиной
+	_, err = interceptor(fromIP("10.0.0.2"), req, login, ok)
+	assert.NoError(t, err)
+}
+
+func TestUnary_PerEmail(t *testing.T) {
+	limiter := grpcratelimit.New(discard, ratelimit.NewMemoryStore(), config.RateLimitPolicies{
+		{Method: auth.Auth_Login_FullMethodName, Key: config.RateLimitByEmail, Rate: 0.1, Burst: 1},
+	})
+	interceptor := limiter.UnaryServerInterceptor()
+
+	_, err := interceptor(fromIP("10.0.0.1"), &auth.LoginRequest{Email: "victim@example.com"}, login, ok)
+	require.NoError(t, err)
+
+	// смена IP и регистра email не сбрасывает лимит
+	_, err = interceptor(fromIP("10.0.0.2"), &auth.LoginRequest{Email: "Victim@Example.com"}, login, ok)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	_, err = interceptor(fromIP("10.0.0.1"), &auth.LoginRequest{Email: "other@example.com"}, login, ok)
+	assert.NoError(t, err)
+}
+
+func TestUnary_ServiceWildcardAndUnlimitedMethods(t *testing.T) {
+	limiter := grpcratelimit.New(discard, ratelimit.NewMemoryStore(), config.RateLimitPolicies{
+		{Method: "/auth.Auth/*", Key: config.RateLimitByIP, Rate: 1, Burst: 1},
+	})
+	interceptor := limiter.UnaryServerInterceptor()
+	other := &grpc.UnaryServerInfo{FullMethod: "/apps.Apps/CreateApp"}
+
+	_, err := interceptor(fromIP("10.0.0.1"), &auth.LoginRequest{}, login, ok)
+	require.NoError(t, err)
+	_, err = interceptor(fromIP("10.0.0.1"), &auth.RegisterRequest{}, &grpc.UnaryServerInfo{FullMethod: auth.Auth_Register_FullMethodName}, ok)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	for i := 0; i < 3; i++ {
+		_, err = interceptor(fromIP("10.0.0.1"), nil, other, ok)
+		assert.NoError(t, err)
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, ratelimit.Limit, time.Time) (bool, time.Duration, error) {
+	return false, 0, errors.New("connection refused")
+}
+
+func TestUnary_FailsOpen(t *testing.T) {
+	limiter := grpcratelimit.New(discard, failingStore{}, grpcratelimit.DefaultPolicies)
+
+	_, err := limiter.UnaryServerInterceptor()(fromIP("10.0.0.1"), &auth.LoginRequest{Email: "a@example.com"}, login, ok)
+	assert.NoError(t, err)
+}
+
+func TestSetPolicies(t *testing.T) {
+	limiter := grpcratelimit.New(discard, ratelimit.NewMemoryStore(), nil)
+	interceptor := limiter.UnaryServerInterceptor()
+	req := &auth.LoginRequest{Email: "a@example.com"}
+
+	_, err := interceptor(fromIP("10.0.0.1"), req, login, ok)
+	require.NoError(t, err)
+
+	limiter.SetPolicies(config.RateLimitPolicies{
+		{Method: auth.Auth_Login_FullMethodName, Key: config.RateLimitByIP, Rate: 0.1, Burst: 1},
+	})
+	_, err = interceptor(fromIP("10.0.0.1"), req, login, ok)
+	require.NoError(t, err)
+	_, err = interceptor(fromIP("10.0.0.1"), req, login, ok)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// без правил ограничения снова нет
+	limiter.SetPolicies(nil)
+	_, err = interceptor(fromIP("10.0.0.1"), req, login, ok)
+	assert.NoError(t, err)
+}
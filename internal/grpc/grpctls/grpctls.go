@@ -0,0 +1,187 @@
+// Package grpctls — TLS для gRPC сервера: сертификат и CA клиентов
+// перечитываются с диска без перезапуска, а методы можно ограничить
+// клиентскими сертификатами с определёнными SAN (mTLS).
+package grpctls
+
+import (
+	"auth-service/config"
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// Credentials выдаёт TLS конфигурацию для каждого нового соединения
+// и проверяет SAN клиентов перехватчиками
+type Credentials struct {
+	log        *slog.Logger
+	certFile   string
+	keyFile    string
+	caFile     string
+	clientAuth tls.ClientAuthType
+	interval   time.Duration
+	allowed    config.GRPCAllowedSANs
+
+	mu      sync.Mutex
+	checked time.Time
+	// содержимое файлов, из которых собран current
+	files   [][]byte
+	current *tls.Config
+}
+
+// New читает сертификаты из файлов cfg. Без CertFile возвращает nil:
+// сервер работает без TLS.
+func New(log *slog.Logger, cfg config.GRPCTLSConfig) (*Credentials, error) {
+	const op = "grpctls.New"
+
+	switch {
+	case cfg.CertFile == "" && cfg.KeyFile == "" && cfg.ClientCAFile == "" && len(cfg.AllowedSANs) == 0:
+		return nil, nil
+	case cfg.CertFile == "" || cfg.KeyFile == "":
+		return nil, fmt.Errorf("%s: both GRPC_TLS_CERT_FILE and GRPC_TLS_KEY_FILE are required", op)
+	case cfg.ClientCAFile == "" && (cfg.RequireClientCert || len(cfg.AllowedSANs) > 0):
+		return nil, fmt.Errorf("%s: client certificate checks require GRPC_TLS_CLIENT_CA_FILE", op)
+	}
+
+	c := &Credentials{
+		log:        log,
+		certFile:   cfg.CertFile,
+		keyFile:    cfg.KeyFile,
+		caFile:     cfg.ClientCAFile,
+		clientAuth: tls.NoClientCert,
+		interval:   cfg.ReloadInterval,
+		allowed:    cfg.AllowedSANs,
+	}
+	if cfg.ClientCAFile != "" {
+		c.clientAuth = tls.VerifyClientCertIfGiven
+		if cfg.RequireClientCert {
+			c.clientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	files, err := c.readFiles()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if c.current, err = c.build(files); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	c.files = files
+	c.checked = time.Now()
+
+	return c, nil
+}
+
+// ServerOption включает TLS на gRPC сервере
+func (c *Credentials) ServerOption() grpc.ServerOption {
+	return grpc.Creds(credentials.NewTLS(&tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		NextProtos:         []string{"h2"},
+		GetConfigForClient: c.configForClient,
+	}))
+}
+
+// configForClient возвращает актуальную конфигурацию; раз в interval
+// файлы перечитываются, и при изменениях новые соединения получают
+// новый сертификат и CA. Уже открытые соединения не разрываются.
+func (c *Credentials) configForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Since(c.checked) >= c.interval {
+		c.checked = time.Now()
+		c.reload()
+	}
+
+	return c.current, nil
+}
+
+// reload вызывается под mu; при ошибке остаётся прежний сертификат,
+// чтобы наполовину записанный файл не ронял новые соединения
+func (c *Credentials) reload() {
+	const op = "grpctls.reload"
+
+	log := c.log.With(slog.String("op", op))
+
+	files, err := c.readFiles()
+	if err != nil {
+		log.Error("failed to read tls files, keeping the current certificate", slog.String("err", err.Error()))
+		return
+	}
+	if equalFiles(files, c.files) {
+		return
+	}
+
+	cfg, err := c.build(files)
+	if err != nil {
+		log.Error("failed to load tls files, keeping the current certificate", slog.String("err", err.Error()))
+		return
+	}
+	c.current = cfg
+	c.files = files
+
+	log.Info("tls certificate reloaded", slog.String("cert_file", c.certFile))
+}
+
+func (c *Credentials) readFiles() ([][]byte, error) {
+	paths := []string{c.certFile, c.keyFile}
+	if c.caFile != "" {
+		paths = append(paths, c.caFile)
+	}
+
+	files := make([][]byte, 0, len(paths))
+	for _, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, b)
+	}
+
+	return files, nil
+}
+
+// build собирает конфигурацию из содержимого cert, key и (опционально) CA
+func (c *Credentials) build(files [][]byte) (*tls.Config, error) {
+	cert, err := tls.X509KeyPair(files[0], files[1])
+	if err != nil {
+		return nil, fmt.Errorf("load key pair %s: %w", c.certFile, err)
+	}
+
+	cfg := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"h2"},
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   c.clientAuth,
+	}
+
+	if c.caFile != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(files[2]) {
+			return nil, errors.New("no certificates in " + c.caFile)
+		}
+		cfg.ClientCAs = pool
+	}
+
+	return cfg, nil
+}
+
+func equalFiles(a, b [][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !bytes.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,272 @@
+package grpctls_test
+
+import (
+	"auth-service/config"
+	"auth-service/internal/grpc/grpctls"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+const checkMethod = "/grpc.health.v1.Health/Check"
+
+// ca — удостоверяющий центр, созданный в тесте
+type ca struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newCA(t *testing.T, name string) *ca {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &ca{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue выпускает сертификат и возвращает его и ключ в PEM
+func (c *ca) issue(t *testing.T, serial int64, usage x509.ExtKeyUsage, dnsNames []string, uris ...string) (certPEM, keyPEM []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		DNSNames:     dnsNames,
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	for _, u := range uris {
+		parsed, err := url.Parse(u)
+		require.NoError(t, err)
+		tmpl.URIs = append(tmpl.URIs, parsed)
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, c.cert, &key.PublicKey, c.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+}
+
+// serve запускает gRPC сервер с health на 127.0.0.1 и возвращает его адрес
+func serve(t *testing.T, creds *grpctls.Credentials) string {
+	t.Helper()
+
+	srv := grpc.NewServer(
+		creds.ServerOption(),
+		grpc.ChainUnaryInterceptor(creds.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(creds.StreamServerInterceptor()),
+	)
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = srv.Serve(l) }()
+	t.Cleanup(srv.Stop)
+
+	return l.Addr().String()
+}
+
+// check вызывает Health.Check по новому соединению и возвращает серийный номер сертификата сервера
+func check(t *testing.T, addr string, roots []byte, clientCert, clientKey []byte) (int64, error) {
+	t.Helper()
+
+	pool := x509.NewCertPool()
+	require.True(t, pool.AppendCertsFromPEM(roots))
+
+	var serial int64
+	cfg := &tls.Config{
+		RootCAs:    pool,
+		ServerName: "localhost",
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			serial = cs.PeerCertificates[0].SerialNumber.Int64()
+			return nil
+		},
+	}
+	if clientCert != nil {
+		pair, err := tls.X509KeyPair(clientCert, clientKey)
+		require.NoError(t, err)
+		cfg.Certificates = []tls.Certificate{pair}
+	}
+
+	cc, err := grpc.NewClient(addr, grpc.WithTransportCredentials(credentials.NewTLS(cfg)))
+	require.NoError(t, err)
+	defer func() { _ = cc.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err = healthpb.NewHealthClient(cc).Check(ctx, &healthpb.HealthCheckRequest{})
+	return serial, err
+}
+
+type fixture struct {
+	serverCA, clientCA *ca
+	cfg                config.GRPCTLSConfig
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+
+	dir := t.TempDir()
+	f := &fixture{
+		serverCA: newCA(t, "server ca"),
+		clientCA: newCA(t, "client ca"),
+		cfg: config.GRPCTLSConfig{
+			CertFile:     filepath.Join(dir, "server.crt"),
+			KeyFile:      filepath.Join(dir, "server.key"),
+			ClientCAFile: filepath.Join(dir, "client-ca.crt"),
+		},
+	}
+
+	cert, key := f.serverCA.issue(t, 100, x509.ExtKeyUsageServerAuth, []string{"localhost"})
+	writeFile(t, f.cfg.CertFile, cert)
+	writeFile(t, f.cfg.KeyFile, key)
+	writeFile(t, f.cfg.ClientCAFile, f.clientCA.pem)
+
+	return f
+}
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestNew_Plaintext(t *testing.T) {
+	creds, err := grpctls.New(discard, config.GRPCTLSConfig{ReloadInterval: time.Minute})
+	require.NoError(t, err)
+	assert.Nil(t, creds)
+
+	_, err = grpctls.New(discard, config.GRPCTLSConfig{CertFile: "server.crt"})
+	assert.Error(t, err)
+
+	_, err = grpctls.New(discard, config.GRPCTLSConfig{
+		CertFile:    "server.crt",
+		KeyFile:     "server.key",
+		AllowedSANs: config.GRPCAllowedSANs{checkMethod: {"billing.internal"}},
+	})
+	assert.Error(t, err, "SAN checks without a client CA")
+}
+
+func TestTLS_ServerOnly(t *testing.T) {
+	f := newFixture(t)
+	f.cfg.ClientCAFile = ""
+
+	creds, err := grpctls.New(discard, f.cfg)
+	require.NoError(t, err)
+	addr := serve(t, creds)
+
+	serial, err := check(t, addr, f.serverCA.pem, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), serial)
+}
+
+func TestMTLS_AllowedSANs(t *testing.T) {
+	f := newFixture(t)
+	f.cfg.AllowedSANs = config.GRPCAllowedSANs{
+		"/grpc.health.v1.Health/*": {"billing.internal", "spiffe://corp/ops"},
+	}
+
+	creds, err := grpctls.New(discard, f.cfg)
+	require.NoError(t, err)
+	addr := serve(t, creds)
+
+	billingCert, billingKey := f.clientCA.issue(t, 200, x509.ExtKeyUsageClientAuth, []string{"billing.internal"})
+	opsCert, opsKey := f.clientCA.issue(t, 201, x509.ExtKeyUsageClientAuth, nil, "spiffe://corp/ops")
+	otherCert, otherKey := f.clientCA.issue(t, 202, x509.ExtKeyUsageClientAuth, []string{"web.internal"})
+	// сертификат с нужным SAN, но от чужого CA клиент даже не предъявляет:
+	// сервер перечисляет в запросе только свои CA
+	foreignCert, foreignKey := newCA(t, "foreign").issue(t, 203, x509.ExtKeyUsageClientAuth, []string{"billing.internal"})
+
+	_, err = check(t, addr, f.serverCA.pem, billingCert, billingKey)
+	assert.NoError(t, err)
+
+	_, err = check(t, addr, f.serverCA.pem, opsCert, opsKey)
+	assert.NoError(t, err)
+
+	_, err = check(t, addr, f.serverCA.pem, otherCert, otherKey)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = check(t, addr, f.serverCA.pem, nil, nil)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = check(t, addr, f.serverCA.pem, foreignCert, foreignKey)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestTLS_HotReload(t *testing.T) {
+	f := newFixture(t)
+	f.cfg.ReloadInterval = 0
+
+	creds, err := grpctls.New(discard, f.cfg)
+	require.NoError(t, err)
+	addr := serve(t, creds)
+
+	serial, err := check(t, addr, f.serverCA.pem, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), serial)
+
+	// повреждённый файл не ломает новые соединения: остаётся прежний сертификат
+	writeFile(t, f.cfg.CertFile, []byte("not a certificate"))
+	serial, err = check(t, addr, f.serverCA.pem, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), serial)
+
+	// ротация сертификата подхватывается без перезапуска
+	cert, key := f.serverCA.issue(t, 101, x509.ExtKeyUsageServerAuth, []string{"localhost"})
+	writeFile(t, f.cfg.KeyFile, key)
+	writeFile(t, f.cfg.CertFile, cert)
+
+	serial, err = check(t, addr, f.serverCA.pem, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(101), serial)
+}
@@ -0,0 +1,101 @@
+package grpctls
+
+import (
+	"auth-service/internal/logger/sl"
+	"context"
+	"crypto/x509"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor пропускает к методам из AllowedSANs только клиентов,
+// чей проверенный сертификат содержит один из разрешённых SAN
+func (c *Credentials) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := c.authorize(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (c *Credentials) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := c.authorize(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func (c *Credentials) authorize(ctx context.Context, method string) error {
+	allowed, ok := c.allowedSANs(method)
+	if !ok {
+		return nil
+	}
+
+	log := sl.FromContext(ctx, c.log).With(slog.String("method", method))
+
+	leaf := clientCert(ctx)
+	if leaf == nil {
+		log.Warn("client certificate required")
+		return status.Error(codes.Unauthenticated, "client certificate is required")
+	}
+
+	sans := subjectAltNames(leaf)
+	for _, san := range sans {
+		if slices.Contains(allowed, san) {
+			return nil
+		}
+	}
+
+	log.Warn("client certificate is not allowed", slog.Any("sans", sans))
+	return status.Error(codes.PermissionDenied, "client certificate is not allowed to call this method")
+}
+
+// allowedSANs ищет правило для метода, затем для всего сервиса ("/pkg.Service/*")
+func (c *Credentials) allowedSANs(method string) ([]string, bool) {
+	if sans, ok := c.allowed[method]; ok {
+		return sans, true
+	}
+
+	i := strings.LastIndex(method, "/")
+	if i < 0 {
+		return nil, false
+	}
+	sans, ok := c.allowed[method[:i+1]+"*"]
+	return sans, ok
+}
+
+// clientCert возвращает проверенный по CA сертификат клиента или nil
+func clientCert(ctx context.Context) *x509.Certificate {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return info.State.VerifiedChains[0][0]
+}
+
+// subjectAltNames — DNS имена, URI (например, SPIFFE ID), email и IP адреса сертификата
+func subjectAltNames(cert *x509.Certificate) []string {
+	sans := slices.Clone(cert.DNSNames)
+	for _, u := range cert.URIs {
+		sans = append(sans, u.String())
+	}
+	sans = append(sans, cert.EmailAddresses...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	return sans
+}
@@ -0,0 +1,59 @@
+package oauthgrpc
+
+import (
+	"auth-service/gen/oauth"
+	"auth-service/internal/dynamic"
+	"auth-service/internal/logger/sl"
+	"auth-service/internal/service"
+	"auth-service/internal/validation"
+	"context"
+	"log/slog"
+
+	"google.golang.org/grpc"
+)
+
+const tokenTypeBearer = "Bearer"
+
+type OAuth interface {
+	ClientCredentials(
+		ctx context.Context,
+		appID int,
+		secret string,
+		scopes []string,
+	) (token string, granted []string, err error)
+}
+
+type serverAPI struct {
+	oauth.UnimplementedOAuthServer
+	oauth    OAuth
+	tokenTTL *dynamic.Duration
+	log      *slog.Logger
+}
+
+// регистрация обработчика
+func Register(gRPC *grpc.Server, authSvc *service.Auth, tokenTTL *dynamic.Duration, logger *slog.Logger) {
+	oauth.RegisterOAuthServer(gRPC, &serverAPI{
+		oauth:    authSvc,
+		tokenTTL: tokenTTL,
+		log:      logger,
+	})
+}
+
+func (s *serverAPI) ClientCredentials(ctx context.Context, req *oauth.ClientCredentialsRequest) (*oauth.TokenResponse, error) {
+	if err := validation.ValidateClientCredentialsRequest(req); err != nil {
+		sl.FromContext(ctx, s.log).Warn("client credentials request validation failed", "app_id", req.GetAppId(), "err", err)
+		return nil, err
+	}
+
+	token, granted, err := s.oauth.ClientCredentials(ctx, int(req.GetAppId()), req.GetClientSecret(), req.GetScopes())
+	if err != nil {
+		return nil, err
+	}
+
+	return &oauth.TokenResponse{
+		AccessToken: token,
+		TokenType:   tokenTypeBearer,
+		ExpiresIn:   int64(s.tokenTTL.Get().Seconds()),
+		Scopes:      granted,
+	}, nil
+}
@@ -0,0 +1,194 @@
+package profilegrpc
+
+import (
+	"auth-service/gen/profile"
+	"auth-service/internal/apperr"
+	"auth-service/internal/grpc/grpcauth"
+	"auth-service/internal/model"
+	"auth-service/internal/repository"
+	"auth-service/internal/service"
+	"auth-service/internal/validation"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+type Profile interface {
+	GetMe(ctx context.Context, userID int64, appID int) (model.Profile, error)
+	UpdateMe(ctx context.Context, userID int64, appID int, update service.ProfileUpdate) (model.Profile, error)
+}
+
+type EmailChange interface {
+	RequestEmailChange(ctx context.Context, userID int64, newEmail, password string) error
+	ConfirmEmailChange(ctx context.Context, token string) error
+	CancelEmailChange(ctx context.Context, token string) error
+}
+
+type serverAPI struct {
+	profile.UnimplementedProfileServer
+	profile     Profile
+	emailChange EmailChange
+	log         *slog.Logger
+}
+
+// регистрация обработчика
+func Register(
+	gRPC *grpc.Server,
+	profileSvc *service.Profile,
+	emailChangeSvc *service.EmailChange,
+	logger *slog.Logger,
+) {
+	profile.RegisterProfileServer(gRPC, &serverAPI{
+		profile:     profileSvc,
+		emailChange: emailChangeSvc,
+		log:         logger,
+	})
+}
+
+func (s *serverAPI) GetMe(ctx context.Context, _ *profile.GetMeRequest) (*profile.GetMeResponse, error) {
+	caller, err := grpcauth.Caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	me, err := s.profile.GetMe(ctx, caller.UserID, caller.AppID)
+	if err != nil {
+		return nil, domainErr(err)
+	}
+
+	resp, err := toProto(me)
+	if err != nil {
+		return nil, domainErr(err)
+	}
+
+	return &profile.GetMeResponse{Profile: resp}, nil
+}
+
+func (s *serverAPI) UpdateMe(ctx context.Context, req *profile.UpdateMeRequest) (*profile.UpdateMeResponse, error) {
+	caller, err := grpcauth.Caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validation.ValidateUpdateMeRequest(req); err != nil {
+		return nil, err
+	}
+
+	update := service.ProfileUpdate{
+		DisplayName: req.DisplayName,
+		Locale:      req.Locale,
+		Timezone:    req.Timezone,
+		AvatarURL:   req.AvatarUrl,
+	}
+	if req.GetAttributes() != nil {
+		update.Attributes = req.GetAttributes().AsMap()
+	}
+
+	me, err := s.profile.UpdateMe(ctx, caller.UserID, caller.AppID, update)
+	if err != nil {
+		return nil, domainErr(err)
+	}
+
+	resp, err := toProto(me)
+	if err != nil {
+		return nil, domainErr(err)
+	}
+
+	return &profile.UpdateMeResponse{Profile: resp}, nil
+}
+
+func (s *serverAPI) RequestEmailChange(
+	ctx context.Context,
+	req *profile.RequestEmailChangeRequest,
+) (*profile.RequestEmailChangeResponse, error) {
+	caller, err := grpcauth.Caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validation.ValidateRequestEmailChangeRequest(req); err != nil {
+		return nil, err
+	}
+
+	err = s.emailChange.RequestEmailChange(ctx, caller.UserID, req.GetNewEmail(), req.GetCurrentPassword())
+	if err != nil {
+		return nil, domainErr(err)
+	}
+
+	return &profile.RequestEmailChangeResponse{}, nil
+}
+
+func (s *serverAPI) ConfirmEmailChange(
+	ctx context.Context,
+	req *profile.ConfirmEmailChangeRequest,
+) (*profile.ConfirmEmailChangeResponse, error) {
+	if err := validation.ValidateConfirmEmailChangeRequest(req); err != nil {
+		return nil, err
+	}
+
+	if err := s.emailChange.ConfirmEmailChange(ctx, req.GetToken()); err != nil {
+		return nil, domainErr(err)
+	}
+
+	return &profile.ConfirmEmailChangeResponse{}, nil
+}
+
+func (s *serverAPI) CancelEmailChange(
+	ctx context.Context,
+	req *profile.CancelEmailChangeRequest,
+) (*profile.CancelEmailChangeResponse, error) {
+	if err := validation.ValidateCancelEmailChangeRequest(req); err != nil {
+		return nil, err
+	}
+
+	if err := s.emailChange.CancelEmailChange(ctx, req.GetToken()); err != nil {
+		return nil, domainErr(err)
+	}
+
+	return &profile.CancelEmailChangeResponse{}, nil
+}
+
+// ошибки, смысл которых в профиле отличается от общего
+var (
+	errInvalidCurrentPassword = apperr.New(apperr.PermissionDenied, "INVALID_CURRENT_PASSWORD", "invalid current password")
+	errEmailUnchanged         = apperr.New(apperr.InvalidArgument, "EMAIL_UNCHANGED", "new_email matches the current email")
+	errTokenAppNotFound       = apperr.New(apperr.FailedPrecondition, "TOKEN_APP_NOT_FOUND", "app of the token no longer exists")
+)
+
+// domainErr уточняет ошибку сервиса для методов профиля; статус выбирает grpcerr
+func domainErr(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrUserNotFound):
+		// токен удалённого пользователя
+		return fmt.Errorf("%w: %w", service.ErrInvalidToken, err)
+	case errors.Is(err, repository.ErrInvalidCredentials):
+		return fmt.Errorf("%w: %w", errInvalidCurrentPassword, err)
+	case errors.Is(err, service.ErrInvalidRequest):
+		return fmt.Errorf("%w: %w", errEmailUnchanged, err)
+	case errors.Is(err, repository.ErrAppNotFound):
+		return fmt.Errorf("%w: %w", errTokenAppNotFound, err)
+	}
+	return err
+}
+
+func toProto(me model.Profile) (*profile.UserProfile, error) {
+	attrs, err := structpb.NewStruct(me.Attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &profile.UserProfile{
+		UserId:      me.User.ID,
+		Email:       me.User.Email,
+		DisplayName: me.User.DisplayName,
+		Locale:      me.User.Locale,
+		Timezone:    me.User.Timezone,
+		AvatarUrl:   me.User.AvatarURL,
+		AppId:       int32(me.AppID),
+		Attributes:  attrs,
+	}, nil
+}
@@ -0,0 +1,178 @@
+package usersgrpc
+
+import (
+	"auth-service/gen/users"
+	"auth-service/internal/grpc/grpcauth"
+	"auth-service/internal/model"
+	"auth-service/internal/service"
+	"auth-service/internal/validation"
+	"context"
+	"log/slog"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 100
+)
+
+type Users interface {
+	GetUser(ctx context.Context, userID int64) (model.User, error)
+	ListUsers(ctx context.Context, emailPrefix string, afterID int64, limit int) ([]model.User, error)
+	UpdateUser(ctx context.Context, actorID, userID int64, update service.UserUpdate) (model.User, error)
+	DisableUser(ctx context.Context, actorID, userID int64) error
+	EnableUser(ctx context.Context, actorID, userID int64) error
+	DeleteUser(ctx context.Context, actorID, userID int64) error
+}
+
+type serverAPI struct {
+	users.UnimplementedUsersServer
+	users Users
+	log   *slog.Logger
+}
+
+// регистрация обработчика
+func Register(gRPC *grpc.Server, usersSvc *service.Users, logger *slog.Logger) {
+	users.RegisterUsersServer(gRPC, &serverAPI{
+		users: usersSvc,
+		log:   logger,
+	})
+}
+
+func (s *serverAPI) GetUser(ctx context.Context, req *users.GetUserRequest) (*users.GetUserResponse, error) {
+	if err := validation.ValidateGetUserRequest(req); err != nil {
+		return nil, err
+	}
+
+	user, err := s.users.GetUser(ctx, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &users.GetUserResponse{User: toProto(user)}, nil
+}
+
+func (s *serverAPI) ListUsers(ctx context.Context, req *users.ListUsersRequest) (*users.ListUsersResponse, error) {
+	if err := validation.ValidateListUsersRequest(req); err != nil {
+		return nil, err
+	}
+
+	// page_token — id последнего пользователя предыдущей страницы
+	var afterID int64
+	if req.GetPageToken() != "" {
+		id, err := strconv.ParseInt(req.GetPageToken(), 10, 64)
+		if err != nil || id < 0 {
+			return nil, status.Error(codes.InvalidArgument, "invalid page_token")
+		}
+		afterID = id
+	}
+
+	pageSize := int(req.GetPageSize())
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	pageSize = min(pageSize, maxPageSize)
+
+	list, err := s.users.ListUsers(ctx, req.GetEmailPrefix(), afterID, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &users.ListUsersResponse{Users: make([]*users.User, 0, len(list))}
+	for _, user := range list {
+		resp.Users = append(resp.Users, toProto(user))
+	}
+	if len(list) == pageSize {
+		resp.NextPageToken = strconv.FormatInt(list[len(list)-1].ID, 10)
+	}
+
+	return resp, nil
+}
+
+func (s *serverAPI) UpdateUser(ctx context.Context, req *users.UpdateUserRequest) (*users.UpdateUserResponse, error) {
+	actor, err := grpcauth.Caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validation.ValidateUpdateUserRequest(req); err != nil {
+		return nil, err
+	}
+
+	user, err := s.users.UpdateUser(ctx, actor.UserID, req.GetUserId(), service.UserUpdate{
+		Email:   req.Email,
+		IsAdmin: req.IsAdmin,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &users.UpdateUserResponse{User: toProto(user)}, nil
+}
+
+func (s *serverAPI) DisableUser(ctx context.Context, req *users.DisableUserRequest) (*users.DisableUserResponse, error) {
+	actor, err := grpcauth.Caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validation.ValidateDisableUserRequest(req); err != nil {
+		return nil, err
+	}
+
+	if err := s.users.DisableUser(ctx, actor.UserID, req.GetUserId()); err != nil {
+		return nil, err
+	}
+
+	return &users.DisableUserResponse{}, nil
+}
+
+func (s *serverAPI) EnableUser(ctx context.Context, req *users.EnableUserRequest) (*users.EnableUserResponse, error) {
+	actor, err := grpcauth.Caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validation.ValidateEnableUserRequest(req); err != nil {
+		return nil, err
+	}
+
+	if err := s.users.EnableUser(ctx, actor.UserID, req.GetUserId()); err != nil {
+		return nil, err
+	}
+
+	return &users.EnableUserResponse{}, nil
+}
+
+func (s *serverAPI) DeleteUser(ctx context.Context, req *users.DeleteUserRequest) (*users.DeleteUserResponse, error) {
+	actor, err := grpcauth.Caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validation.ValidateDeleteUserRequest(req); err != nil {
+		return nil, err
+	}
+
+	if err := s.users.DeleteUser(ctx, actor.UserID, req.GetUserId()); err != nil {
+		return nil, err
+	}
+
+	return &users.DeleteUserResponse{}, nil
+}
+
+func toProto(user model.User) *users.User {
+	return &users.User{
+		Id:        user.ID,
+		Email:     user.Email,
+		IsAdmin:   user.IsAdmin,
+		Disabled:  user.Disabled(),
+		CreatedAt: timestamppb.New(user.CreatedAt),
+		UpdatedAt: timestamppb.New(user.UpdatedAt),
+	}
+}
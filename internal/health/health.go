@@ -0,0 +1,145 @@
+// Package health ведёт статусы grpc.health.v1: живость процесса
+// и готовность принимать запросы, которая зависит от доступности базы.
+package health
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// имена сервисов в HealthCheckRequest
+const (
+	// LivenessService отвечает SERVING, пока процесс работает, в том числе
+	// во время остановки
+	LivenessService = "liveness"
+	// ReadinessService отвечает SERVING, пока база отвечает на ping;
+	// пустое имя (статус сервера целиком) ведёт себя так же
+	ReadinessService = "readiness"
+)
+
+// Pinger — проверка соединения с базой, например *pgxpool.Pool
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+type Checker struct {
+	log      *slog.Logger
+	db       Pinger
+	interval time.Duration
+	timeout  time.Duration
+	server   *health.Server
+
+	// mu упорядочивает смену готовности и Shutdown
+	mu      sync.Mutex
+	stopped bool
+	ready   atomic.Bool
+	stop    chan struct{}
+}
+
+// New returns a new instance of the Checker.
+// До первого успешного ping сервис не готов; Run проверяет базу раз в interval.
+func New(log *slog.Logger, db Pinger, interval, timeout time.Duration) *Checker {
+	c := &Checker{
+		log:      log,
+		db:       db,
+		interval: interval,
+		timeout:  timeout,
+		server:   health.NewServer(),
+		stop:     make(chan struct{}),
+	}
+	c.server.SetServingStatus(LivenessService, healthpb.HealthCheckResponse_SERVING)
+	c.setReady(false)
+
+	return c
+}
+
+// Server — реализация grpc.health.v1.Health для регистрации на gRPC сервере
+func (c *Checker) Server() healthpb.HealthServer {
+	return c.server
+}
+
+// Run пингует базу до вызова Shutdown
+func (c *Checker) Run() {
+	const op = "health.Run"
+
+	log := c.log.With(slog.String("op", op))
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		c.check(log)
+
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Checker) check(log *slog.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	err := c.db.Ping(ctx)
+	ready := err == nil
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// после Shutdown статус больше не меняется
+	if c.stopped || ready == c.ready.Load() {
+		return
+	}
+	if ready {
+		log.Info("database is reachable, service is ready")
+	} else {
+		log.Warn("database is unreachable, service is not ready", slog.String("err", err.Error()))
+	}
+	c.setReady(ready)
+}
+
+// setReady вызывается под mu или до запуска Run
+func (c *Checker) setReady(ready bool) {
+	c.ready.Store(ready)
+
+	status := healthpb.HealthCheckResponse_NOT_SERVING
+	if ready {
+		status = healthpb.HealthCheckResponse_SERVING
+	}
+	c.server.SetServingStatus("", status)
+	c.server.SetServingStatus(ReadinessService, status)
+}
+
+// Live сообщает, что процесс работает: раз Checker отвечает, он жив
+func (c *Checker) Live() bool {
+	return true
+}
+
+// Ready сообщает, что сервис готов принимать запросы
+func (c *Checker) Ready() bool {
+	return c.ready.Load()
+}
+
+// Shutdown переводит готовность в NOT_SERVING, чтобы балансировщик перестал
+// слать новые запросы, пока сервер дообрабатывает текущие. Живость остаётся
+// SERVING: процесс работает, и перезапускать его не нужно.
+func (c *Checker) Shutdown() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stopped {
+		return
+	}
+	c.stopped = true
+	close(c.stop)
+
+	c.setReady(false)
+}
@@ -0,0 +1,80 @@
+package health_test
+
+import (
+	"auth-service/internal/health"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type fakeDB struct {
+	down atomic.Bool
+}
+
+func (f *fakeDB) Ping(context.Context) error {
+	if f.down.Load() {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func status(t *testing.T, c *health.Checker, service string) healthpb.HealthCheckResponse_ServingStatus {
+	t.Helper()
+
+	resp, err := c.Server().Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+	require.NoError(t, err)
+	return resp.GetStatus()
+}
+
+func TestChecker_ReadinessFollowsDatabase(t *testing.T) {
+	db := &fakeDB{}
+	c := health.New(slog.New(slog.NewTextHandler(io.Discard, nil)), db, 5*time.Millisecond, time.Second)
+
+	// до первого ping сервис жив, но не готов
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, status(t, c, health.LivenessService))
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status(t, c, health.ReadinessService))
+	assert.False(t, c.Ready())
+
+	go c.Run()
+	t.Cleanup(c.Shutdown)
+
+	require.Eventually(t, c.Ready, time.Second, time.Millisecond)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, status(t, c, health.ReadinessService))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, status(t, c, ""))
+
+	db.down.Store(true)
+	require.Eventually(t, func() bool { return !c.Ready() }, time.Second, time.Millisecond)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status(t, c, ""))
+	// недоступная база не делает процесс мёртвым
+	assert.True(t, c.Live())
+}
+
+func TestChecker_ShutdownIsFinal(t *testing.T) {
+	db := &fakeDB{}
+	c := health.New(slog.New(slog.NewTextHandler(io.Discard, nil)), db, 5*time.Millisecond, time.Second)
+
+	go c.Run()
+	require.Eventually(t, c.Ready, time.Second, time.Millisecond)
+
+	c.Shutdown()
+	c.Shutdown()
+
+	// база доступна, но готовность после остановки не возвращается в SERVING
+	time.Sleep(20 * time.Millisecond)
+	assert.False(t, c.Ready())
+	for _, service := range []string{"", health.ReadinessService} {
+		assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status(t, c, service), service)
+	}
+
+	// процесс дообрабатывает вызовы и остаётся живым
+	assert.True(t, c.Live())
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, status(t, c, health.LivenessService))
+}
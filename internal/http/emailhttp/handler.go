@@ -0,0 +1,115 @@
+package emailhttp
+
+import (
+	"auth-service/internal/repository"
+	"auth-service/internal/service"
+	"context"
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+)
+
+// ограничение на размер тела запроса формы
+const maxFormSize = 64 << 10
+
+type EmailChange interface {
+	ConfirmEmailChange(ctx context.Context, token string) error
+	CancelEmailChange(ctx context.Context, token string) error
+}
+
+type handler struct {
+	emailChange EmailChange
+	log         *slog.Logger
+}
+
+// регистрация обработчиков ссылок из писем о смене email.
+// GET только показывает форму: почтовые сканеры открывают ссылки сами,
+// и смена не должна выполняться без действия пользователя.
+func Register(mux *http.ServeMux, emailChangeSvc *service.EmailChange, logger *slog.Logger) {
+	h := &handler{
+		emailChange: emailChangeSvc,
+		log:         logger,
+	}
+
+	mux.HandleFunc("GET /email-change/confirm", h.form(confirmPage))
+	mux.HandleFunc("POST /email-change/confirm", h.submit(h.emailChange.ConfirmEmailChange, "Email изменён."))
+	mux.HandleFunc("GET /email-change/cancel", h.form(cancelPage))
+	mux.HandleFunc("POST /email-change/cancel", h.submit(h.emailChange.CancelEmailChange, "Смена email отменена."))
+}
+
+func (h *handler) form(page *template.Template) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("token")
+		if token == "" {
+			h.render(w, http.StatusBadRequest, resultPage, "Ссылка повреждена.")
+			return
+		}
+		h.render(w, http.StatusOK, page, token)
+	}
+}
+
+func (h *handler) submit(action func(ctx context.Context, token string) error, done string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxFormSize)
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("token") == "" {
+			h.render(w, http.StatusBadRequest, resultPage, "Ссылка повреждена.")
+			return
+		}
+
+		err := action(r.Context(), r.PostForm.Get("token"))
+		switch {
+		case err == nil:
+			h.render(w, http.StatusOK, resultPage, done)
+		case errors.Is(err, repository.ErrEmailChangeNotFound):
+			h.render(w, http.StatusNotFound, resultPage, "Ссылка недействительна или устарела.")
+		case errors.Is(err, repository.ErrUserExists):
+			h.render(w, http.StatusConflict, resultPage, "Этот email уже занят другой учётной записью.")
+		default:
+			h.log.Error("email change failed: internal error", "path", r.URL.Path, "err", err)
+			h.render(w, http.StatusInternalServerError, resultPage, "Внутренняя ошибка, попробуйте позже.")
+		}
+	}
+}
+
+func (h *handler) render(w http.ResponseWriter, status int, page *template.Template, data string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Frame-Options", "DENY")
+	// токен из адреса не должен уходить сторонним сайтам в Referer
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.WriteHeader(status)
+
+	if err := page.Execute(w, data); err != nil {
+		h.log.Error("failed to render page", "page", page.Name(), "err", err)
+	}
+}
+
+const layoutStart = `<!DOCTYPE html>
+<html lang="ru">
+<head><meta charset="utf-8"><title>Смена email</title></head>
+<body>`
+
+const layoutEnd = `</body>
+</html>`
+
+var confirmPage = template.Must(template.New("confirm").Parse(layoutStart + `
+<h1>Подтверждение нового email</h1>
+<form method="post" action="/email-change/confirm">
+  <input type="hidden" name="token" value="{{.}}">
+  <button type="submit">Подтвердить</button>
+</form>
+` + layoutEnd))
+
+var cancelPage = template.Must(template.New("cancel").Parse(layoutStart + `
+<h1>Отмена смены email</h1>
+<p>Если вы не запрашивали смену email, отмените её и смените пароль.</p>
+<form method="post" action="/email-change/cancel">
+  <input type="hidden" name="token" value="{{.}}">
+  <button type="submit">Отменить смену</button>
+</form>
+` + layoutEnd))
+
+var resultPage = template.Must(template.New("result").Parse(layoutStart + `
+<p>{{.}}</p>
+` + layoutEnd))
@@ -0,0 +1,159 @@
+package federationhttp
+
+import (
+	"auth-service/internal/dynamic"
+	"auth-service/internal/repository"
+	"auth-service/internal/service"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+const (
+	// cookie со state живёт только на путях входа через провайдеров
+	stateCookie     = "federation_state"
+	stateCookiePath = "/federation/"
+
+	tokenTypeBearer = "Bearer"
+)
+
+type Federation interface {
+	Start(ctx context.Context, provider string, appID int) (service.FederationStart, error)
+	Finish(ctx context.Context, provider, signedState, state, code string) (string, error)
+}
+
+type handler struct {
+	federation   Federation
+	tokenTTL     *dynamic.Duration
+	secureCookie bool
+	log          *slog.Logger
+}
+
+// регистрация обработчиков входа через внешних провайдеров.
+// secureCookie включает флаг Secure, если сервис доступен по https.
+func Register(mux *http.ServeMux, federationSvc *service.Federation, tokenTTL *dynamic.Duration, secureCookie bool, logger *slog.Logger) {
+	h := &handler{
+		federation:   federationSvc,
+		tokenTTL:     tokenTTL,
+		secureCookie: secureCookie,
+		log:          logger,
+	}
+
+	mux.HandleFunc("GET /federation/{provider}/login", h.login)
+	mux.HandleFunc("GET /federation/{provider}/callback", h.callback)
+}
+
+// login перенаправляет пользователя к провайдеру: /federation/{provider}/login?app_id=1
+func (h *handler) login(w http.ResponseWriter, r *http.Request) {
+	provider := r.PathValue("provider")
+
+	appID, err := strconv.Atoi(r.URL.Query().Get("app_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "app_id is required")
+		return
+	}
+
+	start, err := h.federation.Start(r.Context(), provider, appID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrProviderNotFound):
+			writeError(w, http.StatusNotFound, "unknown identity provider")
+		case errors.Is(err, repository.ErrUserDisabled):
+			writeError(w, http.StatusForbidden, "user is disabled")
+		case errors.Is(err, service.ErrInvalidClient):
+			writeError(w, http.StatusBadRequest, "unknown app_id")
+		default:
+			h.log.Error("federated login failed: internal error", "provider", provider, "err", err)
+			writeError(w, http.StatusBadGateway, "identity provider is unavailable")
+		}
+		return
+	}
+
+	h.setStateCookie(w, start.State, 0)
+	http.Redirect(w, r, start.RedirectURL, http.StatusFound)
+}
+
+// callback принимает код от провайдера и возвращает токен приложения
+func (h *handler) callback(w http.ResponseWriter, r *http.Request) {
+	provider := r.PathValue("provider")
+	query := r.URL.Query()
+
+	cookie, err := r.Cookie(stateCookie)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "login session expired, start again")
+		return
+	}
+	// state одноразовый
+	h.setStateCookie(w, "", -1)
+
+	// отказ пользователя или ошибка на стороне провайдера
+	if e := query.Get("error"); e != "" {
+		writeError(w, http.StatusUnauthorized, "identity provider returned "+e)
+		return
+	}
+
+	token, err := h.federation.Finish(r.Context(), provider, cookie.Value, query.Get("state"), query.Get("code"))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrProviderNotFound):
+			writeError(w, http.StatusNotFound, "unknown identity provider")
+		case errors.Is(err, service.ErrInvalidRequest):
+			writeError(w, http.StatusBadRequest, "login session expired, start again")
+		case errors.Is(err, service.ErrInvalidGrant):
+			writeError(w, http.StatusUnauthorized, "identity provider rejected the login")
+		case errors.Is(err, service.ErrEmailNotVerified):
+			writeError(w, http.StatusForbidden, "email is not verified by identity provider")
+		case errors.Is(err, repository.ErrUserDisabled):
+			writeError(w, http.StatusForbidden, "user is disabled")
+		case errors.Is(err, service.ErrInvalidClient):
+			writeError(w, http.StatusBadRequest, "unknown app_id")
+		default:
+			h.log.Error("federated login failed: internal error", "provider", provider, "err", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tokenResponse{
+		AccessToken: token,
+		TokenType:   tokenTypeBearer,
+		ExpiresIn:   int64(h.tokenTTL.Get().Seconds()),
+	})
+}
+
+func (h *handler) setStateCookie(w http.ResponseWriter, value string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     stateCookie,
+		Value:    value,
+		Path:     stateCookiePath,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   h.secureCookie,
+		// Lax: cookie отправляется при возврате от провайдера обычным редиректом
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
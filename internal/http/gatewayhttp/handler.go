@@ -0,0 +1,160 @@
+// Package gatewayhttp — REST/JSON шлюз к gRPC API для клиентов без gRPC.
+// Запросы и ответы — JSON-представление тех же protobuf сообщений (protojson),
+// обработчики вызываются напрямую, поэтому валидация и ошибки совпадают с gRPC.
+package gatewayhttp
+
+import (
+	"auth-service/internal/grpc/grpcerr"
+	"auth-service/internal/logger/sl"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+
+	"github.com/ILmira-116/protos/gen/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// ограничение на размер тела запроса
+const maxBodySize = 64 << 10
+
+var (
+	unmarshal = protojson.UnmarshalOptions{}
+	// поля в snake_case, как в .proto; нулевые значения (например, is_admin: false) не опускаются
+	marshal = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+)
+
+type handler struct {
+	// те же перехватчики, что и у gRPC сервера (лимиты, проверка токена)
+	interceptors []grpc.UnaryServerInterceptor
+	log          *slog.Logger
+}
+
+// регистрация маршрутов шлюза Auth:
+//
+//	POST /v1/register                   RegisterRequest → RegisterResponse
+//	POST /v1/login                      LoginRequest → LoginResponse
+//	GET  /v1/users/{user_id}/is_admin   IsAdminResponse
+func Register(
+	mux *http.ServeMux,
+	authSrv auth.AuthServer,
+	interceptors []grpc.UnaryServerInterceptor,
+	logger *slog.Logger,
+) {
+	h := &handler{
+		interceptors: interceptors,
+		log:          logger,
+	}
+
+	mux.Handle("POST /v1/register", unary(h, auth.Auth_Register_FullMethodName, authSrv.Register, nil))
+	mux.Handle("POST /v1/login", unary(h, auth.Auth_Login_FullMethodName, authSrv.Login, nil))
+	mux.Handle("GET /v1/users/{user_id}/is_admin", unary(h, auth.Auth_IsAdmin_FullMethodName, authSrv.IsAdmin,
+		func(r *http.Request, req *auth.IsAdminRequest) error {
+			userID, err := strconv.ParseInt(r.PathValue("user_id"), 10, 64)
+			if err != nil {
+				return status.Error(codes.InvalidArgument, "user_id must be an integer")
+			}
+			req.UserId = userID
+			return nil
+		}))
+}
+
+// unary превращает унарный метод gRPC в HTTP обработчик: тело запроса
+// (если есть) разбирается в Req, bind дополняет его параметрами пути,
+// ответ пишется как JSON, ошибка — как problem+json
+func unary[Req any, Resp proto.Message, PReq interface {
+	*Req
+	proto.Message
+}](
+	h *handler,
+	method string,
+	call func(context.Context, PReq) (Resp, error),
+	bind func(*http.Request, PReq) error,
+) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := h.log.With(slog.String("method", method))
+		ctx := sl.NewContext(r.Context(), log)
+
+		req := PReq(new(Req))
+		if err := decode(w, r, req); err != nil {
+			writeProblem(w, grpcerr.ToStatus(log, method, err))
+			return
+		}
+		if bind != nil {
+			if err := bind(r, req); err != nil {
+				writeProblem(w, grpcerr.ToStatus(log, method, err))
+				return
+			}
+		}
+
+		// перехватчики видят IP клиента и заголовок Authorization, как в gRPC
+		if addr, err := netip.ParseAddrPort(r.RemoteAddr); err == nil {
+			ctx = peer.NewContext(ctx, &peer.Peer{Addr: net.TCPAddrFromAddrPort(addr)})
+		}
+		if authorization := r.Header.Get("Authorization"); authorization != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", authorization))
+		}
+
+		resp, err := h.chain(method, func(ctx context.Context, req any) (any, error) {
+			return call(ctx, req.(PReq))
+		})(ctx, req)
+		if err != nil {
+			writeProblem(w, grpcerr.ToStatus(log, method, err))
+			return
+		}
+
+		body, err := marshal.Marshal(resp.(proto.Message))
+		if err != nil {
+			writeProblem(w, grpcerr.ToStatus(log, method, err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_, _ = w.Write(body)
+	})
+}
+
+// chain оборачивает вызов перехватчиками в том же порядке, что и grpc.ChainUnaryInterceptor
+func (h *handler) chain(method string, handler grpc.UnaryHandler) grpc.UnaryHandler {
+	info := &grpc.UnaryServerInfo{FullMethod: method}
+	for i := len(h.interceptors) - 1; i >= 0; i-- {
+		interceptor, next := h.interceptors[i], handler
+		handler = func(ctx context.Context, req any) (any, error) {
+			return interceptor(ctx, req, info, next)
+		}
+	}
+	return handler
+}
+
+// decode разбирает JSON тело запроса; пустое тело оставляет сообщение пустым
+func decode(w http.ResponseWriter, r *http.Request, req proto.Message) error {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return status.Error(codes.InvalidArgument, "request body is too large")
+		}
+		return fmt.Errorf("read request body: %w", err)
+	}
+	if len(body) == 0 {
+		return nil
+	}
+
+	if err := unmarshal.Unmarshal(body, req); err != nil {
+		return status.Error(codes.InvalidArgument, "request body is not a valid JSON message: "+err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,238 @@
+package gatewayhttp_test
+
+import (
+	"auth-service/config"
+	"auth-service/internal/grpc/authgrpc"
+	"auth-service/internal/grpc/grpcauth"
+	"auth-service/internal/grpc/grpcratelimit"
+	"auth-service/internal/http/gatewayhttp"
+	"auth-service/internal/jwt"
+	"auth-service/internal/model"
+	"auth-service/internal/ratelimit"
+	"auth-service/internal/repository"
+	"auth-service/internal/validation"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ILmira-116/protos/gen/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+type fakeAuth struct {
+	registerErr error
+}
+
+func (f *fakeAuth) Login(context.Context, string, string, int) (string, error) {
+	return "token-for-app", nil
+}
+
+func (f *fakeAuth) Register(context.Context, string, string) (int64, error) {
+	if f.registerErr != nil {
+		return 0, f.registerErr
+	}
+	return 42, nil
+}
+
+func (f *fakeAuth) IsAdmin(_ context.Context, userID int64) (bool, error) {
+	if userID == 404 {
+		return false, repository.ErrUserNotFound
+	}
+	return userID == 1, nil
+}
+
+func (f *fakeAuth) UserByID(ctx context.Context, userID int64) (model.User, error) {
+	isAdmin, err := f.IsAdmin(ctx, userID)
+	if err != nil {
+		return model.User{}, err
+	}
+	return model.User{ID: userID, IsAdmin: isAdmin}, nil
+}
+
+type apps map[int]model.App
+
+func (a apps) App(_ context.Context, appID int) (model.App, error) {
+	app, ok := a[appID]
+	if !ok {
+		return model.App{}, repository.ErrAppNotFound
+	}
+	return app, nil
+}
+
+type problem struct {
+	Title         string `json:"title"`
+	Status        int    `json:"status"`
+	Detail        string `json:"detail"`
+	Code          string `json:"code"`
+	Reason        string `json:"reason"`
+	InvalidParams []struct {
+		Name   string `json:"name"`
+		Reason string `json:"reason"`
+	} `json:"invalid_params"`
+}
+
+func newGateway(auth *fakeAuth, interceptors ...grpc.UnaryServerInterceptor) http.Handler {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	mux := http.NewServeMux()
+	gatewayhttp.Register(mux, authgrpc.NewServer(auth, validation.NewAppChecker(apps{1: {ID: 1}}), log), interceptors, log)
+	return mux
+}
+
+func do(t *testing.T, h http.Handler, method, path, body string, header ...string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func decodeProblem(t *testing.T, rec *httptest.ResponseRecorder) problem {
+	t.Helper()
+
+	assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+	var p problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+	assert.Equal(t, rec.Code, p.Status)
+	return p
+}
+
+func TestRegister_HappyPath(t *testing.T) {
+	rec := do(t, newGateway(&fakeAuth{}), http.MethodPost, "/v1/register",
+		`{"email":"user@example.com","password":"correct-horse"}`)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	// int64 в protojson — строка
+	assert.JSONEq(t, `{"user_id":"42"}`, rec.Body.String())
+}
+
+func TestRegister_ValidationProblem(t *testing.T) {
+	rec := do(t, newGateway(&fakeAuth{}), http.MethodPost, "/v1/register", `{"email":"not-an-email","password":"short"}`)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	p := decodeProblem(t, rec)
+	assert.Equal(t, "INVALID_ARGUMENT", p.Code)
+
+	fields := map[string]bool{}
+	for _, v := range p.InvalidParams {
+		fields[v.Name] = true
+		assert.NotEmpty(t, v.Reason)
+	}
+	assert.Equal(t, map[string]bool{"email": true, "password": true}, fields)
+}
+
+func TestRegister_DomainErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		reason string
+		detail string
+	}{
+		{"exists", repository.ErrUserExists, http.StatusConflict, "USER_EXISTS", "user already exists"},
+		{"internal", errors.New("pq: connection reset"), http.StatusInternalServerError, "", "internal error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := do(t, newGateway(&fakeAuth{registerErr: tt.err}), http.MethodPost, "/v1/register",
+				`{"email":"user@example.com","password":"correct-horse"}`)
+
+			require.Equal(t, tt.status, rec.Code)
+			p := decodeProblem(t, rec)
+			assert.Equal(t, tt.reason, p.Reason)
+			assert.Equal(t, tt.detail, p.Detail)
+		})
+	}
+}
+
+func TestRegister_MalformedBody(t *testing.T) {
+	rec := do(t, newGateway(&fakeAuth{}), http.MethodPost, "/v1/register", `{"email":`)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "INVALID_ARGUMENT", decodeProblem(t, rec).Code)
+}
+
+func TestLogin(t *testing.T) {
+	h := newGateway(&fakeAuth{})
+
+	rec := do(t, h, http.MethodPost, "/v1/login", `{"email":"user@example.com","password":"pw","app_id":1}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"token":"token-for-app"}`, rec.Body.String())
+
+	// несуществующее приложение отклоняет та же валидация, что и в gRPC
+	rec = do(t, h, http.MethodPost, "/v1/login", `{"email":"user@example.com","password":"pw","app_id":7}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	p := decodeProblem(t, rec)
+	require.Len(t, p.InvalidParams, 1)
+	assert.Equal(t, "app_id", p.InvalidParams[0].Name)
+}
+
+func TestIsAdmin(t *testing.T) {
+	h := newGateway(&fakeAuth{})
+
+	rec := do(t, h, http.MethodGet, "/v1/users/2/is_admin", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"is_admin":false}`, rec.Body.String())
+
+	rec = do(t, h, http.MethodGet, "/v1/users/404/is_admin", "")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "USER_NOT_FOUND", decodeProblem(t, rec).Reason)
+
+	rec = do(t, h, http.MethodGet, "/v1/users/abc/is_admin", "")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestLogin_RateLimited(t *testing.T) {
+	limiter := grpcratelimit.New(slog.New(slog.NewTextHandler(io.Discard, nil)), ratelimit.NewMemoryStore(), config.RateLimitPolicies{
+		{Method: auth.Auth_Login_FullMethodName, Key: config.RateLimitByIP, Rate: 0.25, Burst: 1},
+	})
+	h := newGateway(&fakeAuth{}, limiter.UnaryServerInterceptor())
+	body := `{"email":"user@example.com","password":"pw","app_id":1}`
+
+	rec := do(t, h, http.MethodPost, "/v1/login", body)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	// httptest.NewRequest приходит с одного адреса 192.0.2.1
+	rec = do(t, h, http.MethodPost, "/v1/login", body)
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "4", rec.Header().Get("Retry-After"))
+	p := decodeProblem(t, rec)
+	assert.Equal(t, "RESOURCE_EXHAUSTED", p.Code)
+	assert.Equal(t, grpcratelimit.ReasonRateLimited, p.Reason)
+}
+
+func TestIsAdmin_RequiresToken(t *testing.T) {
+	const secret = "test-secret"
+	authenticator := grpcauth.New(slog.New(slog.NewTextHandler(io.Discard, nil)), secret, &fakeAuth{}, grpcauth.DefaultRules)
+	h := newGateway(&fakeAuth{}, authenticator.UnaryServerInterceptor())
+
+	rec := do(t, h, http.MethodGet, "/v1/users/2/is_admin", "")
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "UNAUTHENTICATED", decodeProblem(t, rec).Code)
+
+	token, err := jwt.NewToken(model.User{ID: 2}, model.App{ID: 1}, secret, time.Hour)
+	require.NoError(t, err)
+
+	rec = do(t, h, http.MethodGet, "/v1/users/2/is_admin", "", "Authorization", "Bearer "+token)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"is_admin":false}`, rec.Body.String())
+
+	// роль другого пользователя видит только администратор
+	rec = do(t, h, http.MethodGet, "/v1/users/1/is_admin", "", "Authorization", "Bearer "+token)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+}
@@ -0,0 +1,31 @@
+package healthhttp
+
+import (
+	"net/http"
+)
+
+type Checker interface {
+	Live() bool
+	Ready() bool
+}
+
+// регистрация HTTP зеркал статусов grpc.health.v1 для проб, которые не умеют gRPC:
+// 200 — SERVING, 503 — NOT_SERVING
+func Register(mux *http.ServeMux, checker Checker) {
+	mux.HandleFunc("GET /healthz", probe(checker.Live))
+	mux.HandleFunc("GET /readyz", probe(checker.Ready))
+}
+
+func probe(ok func() bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+
+		if !ok() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("NOT_SERVING\n"))
+			return
+		}
+		_, _ = w.Write([]byte("SERVING\n"))
+	}
+}
@@ -0,0 +1,353 @@
+package oauthhttp
+
+import (
+	"auth-service/internal/dynamic"
+	"auth-service/internal/model"
+	"auth-service/internal/repository"
+	"auth-service/internal/service"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ILmira-116/protos/gen/auth"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	grantTypeAuthorizationCode = "authorization_code"
+	grantTypeClientCredentials = "client_credentials"
+	responseTypeCode           = "code"
+	tokenTypeBearer            = "Bearer"
+
+	// ограничение на размер тела запроса формы
+	maxFormSize = 64 << 10
+)
+
+// коды ошибок OAuth 2.0 (RFC 6749, разделы 4.1.2.1 и 5.2)
+const (
+	errInvalidRequest          = "invalid_request"
+	errInvalidClient           = "invalid_client"
+	errInvalidGrant            = "invalid_grant"
+	errInvalidScope            = "invalid_scope"
+	errUnsupportedGrantType    = "unsupported_grant_type"
+	errUnsupportedResponseType = "unsupported_response_type"
+	errAccessDenied            = "access_denied"
+	errServerError             = "server_error"
+)
+
+type OAuth interface {
+	ValidateAuthorization(ctx context.Context, req model.AuthorizationRequest) (model.App, error)
+	Authorize(ctx context.Context, req model.AuthorizationRequest, email, password string) (service.AuthorizeResult, error)
+	Consent(ctx context.Context, ticket string, approved bool) (model.AuthorizationRequest, string, error)
+	Exchange(ctx context.Context, req service.TokenRequest) (service.TokenResult, error)
+}
+
+type ClientCredentials interface {
+	ClientCredentials(ctx context.Context, appID int, secret string, scopes []string) (token string, granted []string, err error)
+}
+
+// Limiter — ограничитель частоты вызовов gRPC методов (grpcratelimit.Limiter)
+type Limiter interface {
+	Check(ctx context.Context, method string, req any) error
+}
+
+type handler struct {
+	oauth    OAuth
+	clients  ClientCredentials
+	limiter  Limiter
+	tokenTTL *dynamic.Duration
+	log      *slog.Logger
+}
+
+// регистрация обработчиков /authorize и /token
+func Register(mux *http.ServeMux, oauthSvc *service.OAuth, authSvc *service.Auth, limiter Limiter, tokenTTL *dynamic.Duration, logger *slog.Logger) {
+	h := &handler{
+		oauth:    oauthSvc,
+		clients:  authSvc,
+		limiter:  limiter,
+		tokenTTL: tokenTTL,
+		log:      logger,
+	}
+
+	mux.HandleFunc("GET /authorize", h.authorizeForm)
+	mux.HandleFunc("POST /authorize", h.authorize)
+	mux.HandleFunc("POST /authorize/consent", h.consent)
+	mux.HandleFunc("POST /token", h.token)
+}
+
+// authorizeForm проверяет запрос и показывает форму входа
+func (h *handler) authorizeForm(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.authorizationRequest(w, r, r.URL.Query())
+	if !ok {
+		return
+	}
+
+	h.render(w, http.StatusOK, loginPage, pageData{Request: req})
+}
+
+// authorize принимает форму входа
+func (h *handler) authorize(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxFormSize)
+	if err := r.ParseForm(); err != nil {
+		h.renderError(w, http.StatusBadRequest, "invalid form")
+		return
+	}
+
+	req, ok := h.authorizationRequest(w, r, r.PostForm)
+	if !ok {
+		return
+	}
+
+	email := r.PostForm.Get("email")
+
+	// форма проверяет пароль, поэтому на неё действуют лимиты Login по IP и email
+	ctx := r.Context()
+	if addr, err := netip.ParseAddrPort(r.RemoteAddr); err == nil {
+		ctx = peer.NewContext(ctx, &peer.Peer{Addr: net.TCPAddrFromAddrPort(addr)})
+	}
+	if err := h.limiter.Check(ctx, auth.Auth_Login_FullMethodName, &auth.LoginRequest{Email: email}); err != nil {
+		setRetryAfter(w, err)
+		h.render(w, http.StatusTooManyRequests, loginPage, pageData{Request: req, Error: "Слишком много попыток входа, повторите позже"})
+		return
+	}
+
+	res, err := h.oauth.Authorize(r.Context(), req, email, r.PostForm.Get("password"))
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidCredentials) {
+			h.render(w, http.StatusUnauthorized, loginPage, pageData{Request: req, Error: "Неверный email или пароль"})
+			return
+		}
+		if errors.Is(err, repository.ErrUserDisabled) {
+			h.render(w, http.StatusForbidden, loginPage, pageData{Request: req, Error: "Учётная запись заблокирована"})
+			return
+		}
+
+		h.log.Error("authorize failed: internal error", "app_id", req.AppID, "err", err)
+		redirectError(w, r, req, errServerError)
+		return
+	}
+
+	if res.Code != "" {
+		redirectCode(w, r, req, res.Code)
+		return
+	}
+
+	h.render(w, http.StatusOK, consentPage, pageData{Request: req, Ticket: res.ConsentTicket})
+}
+
+// consent принимает решение пользователя на странице согласия
+func (h *handler) consent(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxFormSize)
+	if err := r.ParseForm(); err != nil {
+		h.renderError(w, http.StatusBadRequest, "invalid form")
+		return
+	}
+
+	approved := r.PostForm.Get("decision") == "approve"
+
+	req, code, err := h.oauth.Consent(r.Context(), r.PostForm.Get("ticket"), approved)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrAccessDenied):
+			redirectError(w, r, req, errAccessDenied)
+		// scopes приложения сократили, пока пользователь был на странице согласия
+		case errors.Is(err, service.ErrInvalidScope):
+			redirectError(w, r, req, errInvalidScope)
+		case errors.Is(err, service.ErrInvalidRequest),
+			errors.Is(err, service.ErrInvalidClient),
+			errors.Is(err, service.ErrInvalidRedirectURI):
+			h.renderError(w, http.StatusBadRequest, "Сессия авторизации истекла, начните вход заново")
+		default:
+			h.log.Error("consent failed: internal error", "err", err)
+			redirectError(w, r, req, errServerError)
+		}
+		return
+	}
+
+	redirectCode(w, r, req, code)
+}
+
+// token — эндпоинт обмена гранта на токен доступа
+func (h *handler) token(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxFormSize)
+	if err := r.ParseForm(); err != nil {
+		writeTokenError(w, http.StatusBadRequest, errInvalidRequest, "invalid form")
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+
+	appID, err := strconv.Atoi(clientID)
+	if err != nil {
+		writeTokenError(w, http.StatusUnauthorized, errInvalidClient, "client_id is required")
+		return
+	}
+
+	var result service.TokenResult
+
+	switch r.PostForm.Get("grant_type") {
+	case grantTypeAuthorizationCode:
+		result, err = h.oauth.Exchange(r.Context(), service.TokenRequest{
+			Code:         r.PostForm.Get("code"),
+			RedirectURI:  r.PostForm.Get("redirect_uri"),
+			AppID:        appID,
+			ClientSecret: clientSecret,
+			CodeVerifier: r.PostForm.Get("code_verifier"),
+		})
+	case grantTypeClientCredentials:
+		result.AccessToken, result.Scopes, err = h.clients.ClientCredentials(
+			r.Context(), appID, clientSecret, strings.Fields(r.PostForm.Get("scope")),
+		)
+	default:
+		writeTokenError(w, http.StatusBadRequest, errUnsupportedGrantType, "")
+		return
+	}
+
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidClient):
+			writeTokenError(w, http.StatusUnauthorized, errInvalidClient, "")
+		case errors.Is(err, service.ErrInvalidGrant):
+			writeTokenError(w, http.StatusBadRequest, errInvalidGrant, "")
+		case errors.Is(err, service.ErrInvalidScope):
+			writeTokenError(w, http.StatusBadRequest, errInvalidScope, "")
+		default:
+			h.log.Error("token request failed: internal error", "app_id", appID, "err", err)
+			writeTokenError(w, http.StatusInternalServerError, errServerError, "")
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tokenResponse{
+		AccessToken: result.AccessToken,
+		TokenType:   tokenTypeBearer,
+		ExpiresIn:   int64(h.tokenTTL.Get().Seconds()),
+		Scope:       strings.Join(result.Scopes, " "),
+		IDToken:     result.IDToken,
+	})
+}
+
+// authorizationRequest разбирает и проверяет параметры /authorize.
+// Если вернуть ошибку клиенту нельзя или уже вернули, ok = false.
+func (h *handler) authorizationRequest(w http.ResponseWriter, r *http.Request, params url.Values) (model.AuthorizationRequest, bool) {
+	appID, err := strconv.Atoi(params.Get("client_id"))
+	if err != nil {
+		h.renderError(w, http.StatusBadRequest, "client_id is required")
+		return model.AuthorizationRequest{}, false
+	}
+
+	req := model.AuthorizationRequest{
+		AppID:               appID,
+		RedirectURI:         params.Get("redirect_uri"),
+		Scopes:              strings.Fields(params.Get("scope")),
+		State:               params.Get("state"),
+		CodeChallenge:       params.Get("code_challenge"),
+		CodeChallengeMethod: params.Get("code_challenge_method"),
+		Nonce:               params.Get("nonce"),
+	}
+
+	if _, err := h.oauth.ValidateAuthorization(r.Context(), req); err != nil {
+		switch {
+		// при неверном клиенте или redirect_uri перенаправлять нельзя (RFC 6749, раздел 4.1.2.1)
+		case errors.Is(err, service.ErrInvalidClient):
+			h.renderError(w, http.StatusBadRequest, "unknown client_id")
+		case errors.Is(err, service.ErrInvalidRedirectURI):
+			h.renderError(w, http.StatusBadRequest, "redirect_uri is not registered for this client")
+		case errors.Is(err, service.ErrInvalidRequest):
+			redirectError(w, r, req, errInvalidRequest)
+		case errors.Is(err, service.ErrInvalidScope):
+			redirectError(w, r, req, errInvalidScope)
+		default:
+			h.log.Error("authorize validation failed: internal error", "app_id", appID, "err", err)
+			h.renderError(w, http.StatusInternalServerError, "internal error")
+		}
+		return model.AuthorizationRequest{}, false
+	}
+
+	if params.Get("response_type") != responseTypeCode {
+		redirectError(w, r, req, errUnsupportedResponseType)
+		return model.AuthorizationRequest{}, false
+	}
+
+	return req, true
+}
+
+func redirectCode(w http.ResponseWriter, r *http.Request, req model.AuthorizationRequest, code string) {
+	redirect(w, r, req, url.Values{"code": {code}})
+}
+
+func redirectError(w http.ResponseWriter, r *http.Request, req model.AuthorizationRequest, code string) {
+	redirect(w, r, req, url.Values{"error": {code}})
+}
+
+// redirect возвращает пользователя на redirect_uri клиента с параметрами и state
+func redirect(w http.ResponseWriter, r *http.Request, req model.AuthorizationRequest, params url.Values) {
+	u, err := url.Parse(req.RedirectURI)
+	if err != nil || req.RedirectURI == "" {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	query := u.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	if req.State != "" {
+		query.Set("state", req.State)
+	}
+	u.RawQuery = query.Encode()
+
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope,omitempty"`
+	IDToken     string `json:"id_token,omitempty"`
+}
+
+type tokenErrorResponse struct {
+	Error       string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+// setRetryAfter переносит RetryInfo отказа лимита в заголовок Retry-After
+func setRetryAfter(w http.ResponseWriter, err error) {
+	for _, d := range status.Convert(err).Details() {
+		if info, ok := d.(*errdetails.RetryInfo); ok {
+			// в целых секундах, округление вверх
+			retry := info.GetRetryDelay().AsDuration()
+			w.Header().Set("Retry-After", strconv.Itoa(int((retry+time.Second-1)/time.Second)))
+		}
+	}
+}
+
+func writeTokenError(w http.ResponseWriter, status int, code, description string) {
+	if status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Basic realm="token"`)
+	}
+	writeJSON(w, status, tokenErrorResponse{Error: code, Description: description})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	// ответы с токенами не должны кешироваться (RFC 6749, раздел 5.1)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
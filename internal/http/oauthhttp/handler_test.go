@@ -0,0 +1,88 @@
+package oauthhttp
+
+import (
+	"auth-service/config"
+	"auth-service/internal/grpc/grpcratelimit"
+	"auth-service/internal/model"
+	"auth-service/internal/ratelimit"
+	"auth-service/internal/repository"
+	"auth-service/internal/service"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ILmira-116/protos/gen/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOAuth принимает любой запрос и отвергает любой пароль
+type fakeOAuth struct {
+	OAuth
+	attempts int
+}
+
+func (f *fakeOAuth) ValidateAuthorization(context.Context, model.AuthorizationRequest) (model.App, error) {
+	return model.App{}, nil
+}
+
+func (f *fakeOAuth) Authorize(context.Context, model.AuthorizationRequest, string, string) (service.AuthorizeResult, error) {
+	f.attempts++
+	return service.AuthorizeResult{}, repository.ErrInvalidCredentials
+}
+
+func postLogin(h http.Handler, email, remoteAddr string) *httptest.ResponseRecorder {
+	form := url.Values{
+		"response_type": {responseTypeCode},
+		"client_id":     {"1"},
+		"redirect_uri":  {"https://client.example/callback"},
+		"scope":         {"profile"},
+		"email":         {email},
+		"password":      {"guess"},
+	}
+	req := httptest.NewRequest(http.MethodPost, "/authorize", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.RemoteAddr = remoteAddr
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAuthorize_LoginRateLimits(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	oauth := &fakeOAuth{}
+	h := &handler{
+		oauth: oauth,
+		limiter: grpcratelimit.New(log, ratelimit.NewMemoryStore(), config.RateLimitPolicies{
+			{Method: auth.Auth_Login_FullMethodName, Key: config.RateLimitByIP, Rate: 0.25, Burst: 2},
+			{Method: auth.Auth_Login_FullMethodName, Key: config.RateLimitByEmail, Rate: 0.25, Burst: 1},
+		}),
+		log: log,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /authorize", h.authorize)
+
+	rec := postLogin(mux, "dana@example.com", "192.0.2.1:1000")
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// тот же email с другого адреса
+	rec = postLogin(mux, "Dana@example.com", "192.0.2.2:1000")
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "4", rec.Header().Get("Retry-After"))
+
+	// другой email с первого адреса: лимит по IP ещё не исчерпан
+	rec = postLogin(mux, "kim@example.com", "192.0.2.1:1000")
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = postLogin(mux, "lee@example.com", "192.0.2.1:1000")
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+
+	// отклонённые лимитом попытки не доходят до проверки пароля
+	assert.Equal(t, 2, oauth.attempts)
+}
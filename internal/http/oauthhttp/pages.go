@@ -0,0 +1,84 @@
+package oauthhttp
+
+import (
+	"auth-service/internal/model"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type pageData struct {
+	Request model.AuthorizationRequest
+	Ticket  string
+	Error   string
+}
+
+var funcs = template.FuncMap{
+	"itoa": strconv.Itoa,
+	"join": strings.Join,
+}
+
+const layoutStart = `<!DOCTYPE html>
+<html lang="ru">
+<head><meta charset="utf-8"><title>Вход</title></head>
+<body>`
+
+const layoutEnd = `</body>
+</html>`
+
+var loginPage = template.Must(template.New("login").Funcs(funcs).Parse(layoutStart + `
+<h1>Вход</h1>
+{{if .Error}}<p role="alert">{{.Error}}</p>{{end}}
+<form method="post" action="/authorize">
+  <input type="hidden" name="response_type" value="code">
+  <input type="hidden" name="client_id" value="{{itoa .Request.AppID}}">
+  <input type="hidden" name="redirect_uri" value="{{.Request.RedirectURI}}">
+  <input type="hidden" name="scope" value="{{join .Request.Scopes " "}}">
+  <input type="hidden" name="state" value="{{.Request.State}}">
+  <input type="hidden" name="code_challenge" value="{{.Request.CodeChallenge}}">
+  <input type="hidden" name="code_challenge_method" value="{{.Request.CodeChallengeMethod}}">
+  <input type="hidden" name="nonce" value="{{.Request.Nonce}}">
+  <label>Email <input type="email" name="email" required autofocus></label>
+  <label>Пароль <input type="password" name="password" required></label>
+  <button type="submit">Войти</button>
+</form>
+` + layoutEnd))
+
+var consentPage = template.Must(template.New("consent").Funcs(funcs).Parse(layoutStart + `
+<h1>Подтверждение доступа</h1>
+<p>Приложение запрашивает доступ:</p>
+<ul>{{range .Request.Scopes}}<li>{{.}}</li>{{end}}</ul>
+<form method="post" action="/authorize/consent">
+  <input type="hidden" name="ticket" value="{{.Ticket}}">
+  <button type="submit" name="decision" value="approve">Разрешить</button>
+  <button type="submit" name="decision" value="deny">Отклонить</button>
+</form>
+` + layoutEnd))
+
+var errorPage = template.Must(template.New("error").Parse(layoutStart + `
+<h1>Ошибка авторизации</h1>
+<p>{{.}}</p>
+` + layoutEnd))
+
+func (h *handler) render(w http.ResponseWriter, status int, page *template.Template, data pageData) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	// страницы входа нельзя встраивать во фреймы (защита от clickjacking)
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.WriteHeader(status)
+
+	if err := page.Execute(w, data); err != nil {
+		h.log.Error("failed to render page", "page", page.Name(), "err", err)
+	}
+}
+
+func (h *handler) renderError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+
+	if err := errorPage.Execute(w, message); err != nil {
+		h.log.Error("failed to render error page", "err", err)
+	}
+}
@@ -0,0 +1,122 @@
+package oidchttp
+
+import (
+	"auth-service/internal/jwt"
+	"auth-service/internal/service"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+type UserInfo interface {
+	UserInfo(ctx context.Context, accessToken string) (map[string]any, error)
+}
+
+type handler struct {
+	userInfo  UserInfo
+	signer    *jwt.IDTokenSigner
+	discovery discoveryDocument
+	log       *slog.Logger
+}
+
+// discoveryDocument — метаданные провайдера (OpenID Connect Discovery 1.0, раздел 3)
+type discoveryDocument struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserinfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+// регистрация обработчиков discovery, JWKS и /userinfo
+func Register(mux *http.ServeMux, oauthSvc *service.OAuth, signer *jwt.IDTokenSigner, issuer string, logger *slog.Logger) {
+	h := &handler{
+		userInfo: oauthSvc,
+		signer:   signer,
+		discovery: discoveryDocument{
+			Issuer:                            issuer,
+			AuthorizationEndpoint:             issuer + "/authorize",
+			TokenEndpoint:                     issuer + "/token",
+			UserinfoEndpoint:                  issuer + "/userinfo",
+			JWKSURI:                           issuer + "/.well-known/jwks.json",
+			ScopesSupported:                   []string{service.ScopeOpenID, service.ScopeEmail, service.ScopeProfile},
+			ResponseTypesSupported:            []string{"code"},
+			GrantTypesSupported:               []string{"authorization_code", "client_credentials"},
+			SubjectTypesSupported:             []string{"public"},
+			IDTokenSigningAlgValuesSupported:  []string{"RS256", "HS256"},
+			TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+			CodeChallengeMethodsSupported:     []string{service.CodeChallengeS256},
+			ClaimsSupported: []string{
+				"iss", "sub", "aud", "exp", "iat", "auth_time", "nonce", "at_hash",
+				"email", "updated_at", "name", "locale", "zoneinfo", "picture",
+			},
+		},
+		log: logger,
+	}
+
+	mux.HandleFunc("GET /.well-known/openid-configuration", h.openIDConfiguration)
+	mux.HandleFunc("GET /.well-known/jwks.json", h.jwks)
+	mux.HandleFunc("GET /userinfo", h.userinfo)
+	mux.HandleFunc("POST /userinfo", h.userinfo)
+}
+
+func (h *handler) openIDConfiguration(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, h.discovery)
+}
+
+func (h *handler) jwks(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"keys": h.signer.JWKS()})
+}
+
+// userinfo возвращает claims пользователя по bearer-токену (OpenID Connect Core, раздел 5.3)
+func (h *handler) userinfo(w http.ResponseWriter, r *http.Request) {
+	header := r.Header.Get("Authorization")
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok || token == "" {
+		writeBearerError(w, http.StatusUnauthorized, "")
+		return
+	}
+
+	claims, err := h.userInfo.UserInfo(r.Context(), token)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidToken):
+			writeBearerError(w, http.StatusUnauthorized, "invalid_token")
+		case errors.Is(err, service.ErrInsufficientScope):
+			writeBearerError(w, http.StatusForbidden, "insufficient_scope")
+		default:
+			h.log.Error("userinfo failed: internal error", "err", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, claims)
+}
+
+// writeBearerError отвечает ошибкой в формате RFC 6750, раздел 3
+func writeBearerError(w http.ResponseWriter, status int, code string) {
+	value := "Bearer"
+	if code != "" {
+		value += ` error="` + code + `"`
+	}
+	w.Header().Set("WWW-Authenticate", value)
+	w.WriteHeader(status)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,176 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const rsaKeyBits = 2048
+
+// IDTokenSigner подписывает id_token по RS256, чтобы клиенты могли
+// проверять их публичным ключом из JWKS
+type IDTokenSigner struct {
+	key *rsa.PrivateKey
+	kid string
+}
+
+// IDTokenClaims — claims id_token (OpenID Connect Core, раздел 2)
+type IDTokenClaims struct {
+	Issuer   string
+	Subject  string
+	Audience string
+	AuthTime time.Time
+	Nonce    string
+	// AccessToken нужен для at_hash
+	AccessToken string
+	// Extra — дополнительные claims пользователя в зависимости от scopes
+	Extra map[string]any
+}
+
+// LoadIDTokenSigner читает RSA-ключ из PEM-файла (PKCS#1 или PKCS#8)
+func LoadIDTokenSigner(path string) (*IDTokenSigner, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var key *rsa.PrivateKey
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		var parsed any
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err == nil {
+			var ok bool
+			if key, ok = parsed.(*rsa.PrivateKey); !ok {
+				return nil, errors.New("signing key is not an RSA key")
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return NewIDTokenSigner(key), nil
+}
+
+// GenerateIDTokenSigner создаёт подписанта со случайным ключом.
+// Токены, выданные им, перестают проверяться после перезапуска.
+func GenerateIDTokenSigner() (*IDTokenSigner, error) {
+	key, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	if err != nil {
+		return nil, err
+	}
+	return NewIDTokenSigner(key), nil
+}
+
+func NewIDTokenSigner(key *rsa.PrivateKey) *IDTokenSigner {
+	// kid — отпечаток публичного ключа, меняется вместе с ключом
+	der, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	sum := sha256.Sum256(der)
+
+	return &IDTokenSigner{
+		key: key,
+		kid: base64.RawURLEncoding.EncodeToString(sum[:8]),
+	}
+}
+
+// Sign создаёт id_token
+func (s *IDTokenSigner) Sign(c IDTokenClaims, ttl time.Duration) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, idTokenClaims(c, ttl))
+	token.Header["kid"] = s.kid
+
+	idToken, err := token.SignedString(s.key)
+	if err != nil {
+		return "", err
+	}
+
+	return idToken, nil
+}
+
+// SignIDTokenHS256 создаёт id_token, подписанный секретом клиента
+// (OpenID Connect Core, раздел 10.1): клиент проверяет его без JWKS
+func SignIDTokenHS256(c IDTokenClaims, clientSecret string, ttl time.Duration) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, idTokenClaims(c, ttl))
+
+	idToken, err := token.SignedString([]byte(clientSecret))
+	if err != nil {
+		return "", err
+	}
+
+	return idToken, nil
+}
+
+func idTokenClaims(c IDTokenClaims, ttl time.Duration) jwt.MapClaims {
+	now := time.Now()
+
+	claims := jwt.MapClaims{}
+	for k, v := range c.Extra {
+		claims[k] = v
+	}
+	claims["iss"] = c.Issuer
+	claims["sub"] = c.Subject
+	claims["aud"] = c.Audience
+	claims["exp"] = now.Add(ttl).Unix()
+	claims["iat"] = now.Unix()
+	if !c.AuthTime.IsZero() {
+		claims["auth_time"] = c.AuthTime.Unix()
+	}
+	if c.Nonce != "" {
+		claims["nonce"] = c.Nonce
+	}
+	if c.AccessToken != "" {
+		claims["at_hash"] = AccessTokenHash(c.AccessToken)
+	}
+
+	return claims
+}
+
+// AccessTokenHash — левая половина SHA-256 токена доступа в base64url (at_hash для RS256 и HS256)
+func AccessTokenHash(accessToken string) string {
+	sum := sha256.Sum256([]byte(accessToken))
+	return base64.RawURLEncoding.EncodeToString(sum[:len(sum)/2])
+}
+
+// JWK — публичный ключ в формате RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// JWKS возвращает набор публичных ключей для /.well-known/jwks.json
+func (s *IDTokenSigner) JWKS() []JWK {
+	pub := s.key.PublicKey
+
+	return []JWK{{
+		Kty: "RSA",
+		Use: "sig",
+		Alg: jwt.SigningMethodRS256.Alg(),
+		Kid: s.kid,
+		N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}}
+}
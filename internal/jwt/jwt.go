@@ -2,13 +2,42 @@ package jwt
 
 import (
 	"auth-service/internal/model"
+	"errors"
+	"maps"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// GrantAuthorizationCode — значение claim grant у токенов, выданных OAuth клиенту
+const GrantAuthorizationCode = "authorization_code"
+
+// NewToken создаёт токен входа пользователя (Login, SSO)
 func NewToken(user model.User, app model.App, secret string, ttl time.Duration) (string, error) {
+	return sign(userClaims(user, app, nil, ttl), secret)
+}
+
+// NewScopedToken создаёт токен пользователя, выданный OAuth клиенту: claim scope
+// с выданными scopes и claim grant, который отличает его от токена входа даже без scopes
+func NewScopedToken(user model.User, app model.App, scopes []string, secret string, ttl time.Duration) (string, error) {
+	claims := userClaims(user, app, scopes, ttl)
+	claims["grant"] = GrantAuthorizationCode
+
+	return sign(claims, secret)
+}
+
+// NewAPIKeyToken создаёт токен пользователя, полученный обменом личного API ключа keyID.
+// Claim key_id отличает его от токена входа даже без scopes.
+func NewAPIKeyToken(user model.User, app model.App, keyID int64, scopes []string, secret string, ttl time.Duration) (string, error) {
+	claims := userClaims(user, app, scopes, ttl)
+	claims["key_id"] = keyID
 
+	return sign(claims, secret)
+}
+
+func userClaims(user model.User, app model.App, scopes []string, ttl time.Duration) jwt.MapClaims {
 	claims := jwt.MapClaims{
 		"user_id": user.ID,
 		"email":   user.Email,
@@ -16,7 +45,15 @@ func NewToken(user model.User, app model.App, secret string, ttl time.Duration)
 		"exp":     time.Now().Add(ttl).Unix(),
 		"iat":     time.Now().Unix(),
 	}
+	if len(scopes) > 0 {
+		claims["scope"] = strings.Join(scopes, " ")
+	}
+	maps.Copy(claims, ProfileClaims(user))
+
+	return claims
+}
 
+func sign(claims jwt.MapClaims, secret string) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
 	tokenString, err := token.SignedString([]byte(secret))
@@ -26,3 +63,219 @@ func NewToken(user model.User, app model.App, secret string, ttl time.Duration)
 
 	return tokenString, nil
 }
+
+// ProfileClaims возвращает заполненные поля профиля под именами claims
+// OpenID Connect (Core, раздел 5.1); незаданные поля в токен не попадают
+func ProfileClaims(user model.User) map[string]any {
+	claims := map[string]any{}
+
+	if user.DisplayName != "" {
+		claims["name"] = user.DisplayName
+	}
+	if user.Locale != "" {
+		claims["locale"] = user.Locale
+	}
+	if user.Timezone != "" {
+		claims["zoneinfo"] = user.Timezone
+	}
+	if user.AvatarURL != "" {
+		claims["picture"] = user.AvatarURL
+	}
+
+	return claims
+}
+
+// NewClientToken создаёт машинный токен приложения (client credentials).
+// sub и client_id содержат id приложения, scope — выданные права через пробел.
+func NewClientToken(app model.App, scopes []string, secret string, ttl time.Duration) (string, error) {
+	clientID := strconv.Itoa(app.ID)
+
+	claims := jwt.MapClaims{
+		"sub":       clientID,
+		"client_id": clientID,
+		"app_id":    app.ID,
+		"scope":     strings.Join(scopes, " "),
+		"exp":       time.Now().Add(ttl).Unix(),
+		"iat":       time.Now().Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+
+	tokenString, err := token.SignedString([]byte(secret))
+	if err != nil {
+		return "", err
+	}
+
+	return tokenString, nil
+}
+
+// Claims — данные, извлечённые из токена, выданного NewToken
+type Claims struct {
+	UserID int64
+	Email  string
+	AppID  int
+	Scopes []string
+	// APIKeyID — id личного API ключа, если токен выдан NewAPIKeyToken
+	APIKeyID int64
+	// Grant — грант OAuth, по которому выдан токен; пустой у токенов входа
+	Grant string
+}
+
+// ParseToken проверяет подпись и срок действия токена и возвращает его claims
+func ParseToken(tokenString string, secret string) (Claims, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return Claims{}, err
+	}
+
+	mapClaims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return Claims{}, errors.New("unexpected claims type")
+	}
+
+	// числа в JSON декодируются как float64
+	userID, ok := mapClaims["user_id"].(float64)
+	if !ok {
+		return Claims{}, errors.New("user_id claim is missing")
+	}
+	email, _ := mapClaims["email"].(string)
+	appID, _ := mapClaims["app_id"].(float64)
+	scope, _ := mapClaims["scope"].(string)
+	keyID, _ := mapClaims["key_id"].(float64)
+	grant, _ := mapClaims["grant"].(string)
+
+	return Claims{
+		UserID:   int64(userID),
+		Email:    email,
+		AppID:    int(appID),
+		Scopes:   strings.Fields(scope),
+		APIKeyID: int64(keyID),
+		Grant:    grant,
+	}, nil
+}
+
+// тип тикета согласия, чтобы его нельзя было спутать с токеном доступа
+const consentTicketType = "consent_ticket"
+
+// ConsentTicket — пользователь аутентифицировался, но ещё не подтвердил согласие на scopes
+type ConsentTicket struct {
+	UserID   int64
+	AuthTime time.Time
+	Request  model.AuthorizationRequest
+}
+
+type consentTicketClaims struct {
+	jwt.RegisteredClaims
+	Type     string                     `json:"typ"`
+	AuthTime int64                      `json:"auth_time"`
+	Request  model.AuthorizationRequest `json:"req"`
+}
+
+// NewConsentTicket подписывает тикет согласия
+func NewConsentTicket(t ConsentTicket, secret string, ttl time.Duration) (string, error) {
+	claims := consentTicketClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatInt(t.UserID, 10),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+		Type:     consentTicketType,
+		AuthTime: t.AuthTime.Unix(),
+		Request:  t.Request,
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+}
+
+// ParseConsentTicket проверяет подпись и срок действия тикета согласия
+func ParseConsentTicket(ticket string, secret string) (ConsentTicket, error) {
+	var claims consentTicketClaims
+
+	_, err := jwt.ParseWithClaims(ticket, &claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return ConsentTicket{}, err
+	}
+
+	if claims.Type != consentTicketType {
+		return ConsentTicket{}, errors.New("not a consent ticket")
+	}
+
+	userID, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil {
+		return ConsentTicket{}, errors.New("invalid subject")
+	}
+
+	return ConsentTicket{
+		UserID:   userID,
+		AuthTime: time.Unix(claims.AuthTime, 0),
+		Request:  claims.Request,
+	}, nil
+}
+
+// тип state входа через внешнего провайдера
+const federationStateType = "federation_state"
+
+// FederationState хранится в cookie между редиректом к внешнему провайдеру и возвратом
+type FederationState struct {
+	Provider string
+	AppID    int
+	State    string
+	Nonce    string
+	Verifier string
+}
+
+type federationStateClaims struct {
+	jwt.RegisteredClaims
+	Type     string `json:"typ"`
+	Provider string `json:"provider"`
+	AppID    int    `json:"app_id"`
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+}
+
+// NewFederationState подписывает state входа через внешнего провайдера
+func NewFederationState(s FederationState, secret string, ttl time.Duration) (string, error) {
+	claims := federationStateClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+		Type:     federationStateType,
+		Provider: s.Provider,
+		AppID:    s.AppID,
+		State:    s.State,
+		Nonce:    s.Nonce,
+		Verifier: s.Verifier,
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+}
+
+// ParseFederationState проверяет подпись и срок действия state
+func ParseFederationState(state string, secret string) (FederationState, error) {
+	var claims federationStateClaims
+
+	_, err := jwt.ParseWithClaims(state, &claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return FederationState{}, err
+	}
+
+	if claims.Type != federationStateType {
+		return FederationState{}, errors.New("not a federation state")
+	}
+
+	return FederationState{
+		Provider: claims.Provider,
+		AppID:    claims.AppID,
+		State:    claims.State,
+		Nonce:    claims.Nonce,
+		Verifier: claims.Verifier,
+	}, nil
+}
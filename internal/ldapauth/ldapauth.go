@@ -0,0 +1,127 @@
+package ldapauth
+
+import (
+	"auth-service/config"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// ErrInvalidCredentials — пользователь не найден, найден не один или пароль неверен
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// Entry — пользователь каталога после успешного bind
+type Entry struct {
+	DN    string
+	Email string
+	// Roles — роли из LDAP_GROUP_ROLES для групп пользователя
+	Roles []string
+}
+
+// Directory проверяет пароли bind'ом от имени пользователя: сначала ищет DN
+// по email (служебной учётной записью или анонимно), затем bind'ится с паролем
+type Directory struct {
+	cfg config.LDAPConfig
+}
+
+func New(cfg config.LDAPConfig) *Directory {
+	return &Directory{cfg: cfg}
+}
+
+// Authenticate проверяет email и пароль в каталоге и возвращает пользователя с ролями
+func (d *Directory) Authenticate(ctx context.Context, email, password string) (Entry, error) {
+	// пустой пароль даёт unauthenticated bind, который сервер считает успешным (RFC 4513, 5.1.2)
+	if email == "" || password == "" {
+		return Entry{}, ErrInvalidCredentials
+	}
+
+	conn, err := d.dial(ctx)
+	if err != nil {
+		return Entry{}, err
+	}
+	defer conn.Close()
+
+	if d.cfg.BindDN != "" {
+		if err := conn.Bind(d.cfg.BindDN, d.cfg.BindPassword.Reveal()); err != nil {
+			return Entry{}, fmt.Errorf("service bind: %w", err)
+		}
+	}
+
+	res, err := conn.Search(ldap.NewSearchRequest(
+		d.cfg.BaseDN,
+		ldap.ScopeWholeSubtree,
+		ldap.NeverDerefAliases,
+		2, // больше одной записи — неоднозначный email
+		int(d.cfg.Timeout.Seconds()),
+		false,
+		strings.ReplaceAll(d.cfg.UserFilter, "%s", ldap.EscapeFilter(email)),
+		[]string{d.cfg.EmailAttribute, d.cfg.GroupAttribute},
+		nil,
+	))
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		return Entry{}, fmt.Errorf("search user: %w", err)
+	}
+	if res == nil || len(res.Entries) != 1 {
+		return Entry{}, ErrInvalidCredentials
+	}
+	user := res.Entries[0]
+
+	if err := conn.Bind(user.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return Entry{}, ErrInvalidCredentials
+		}
+		return Entry{}, fmt.Errorf("user bind: %w", err)
+	}
+
+	entry := Entry{
+		DN:    user.DN,
+		Email: user.GetAttributeValue(d.cfg.EmailAttribute),
+	}
+	if entry.Email == "" {
+		entry.Email = email
+	}
+
+	for _, group := range user.GetAttributeValues(d.cfg.GroupAttribute) {
+		for groupDN, role := range d.cfg.GroupRoles {
+			// DN сравниваются без учёта регистра
+			if strings.EqualFold(group, groupDN) {
+				entry.Roles = append(entry.Roles, role)
+			}
+		}
+	}
+
+	return entry, nil
+}
+
+func (d *Directory) dial(ctx context.Context) (*ldap.Conn, error) {
+	dialer := &net.Dialer{Timeout: d.cfg.Timeout}
+	if deadline, ok := ctx.Deadline(); ok {
+		dialer.Deadline = deadline
+	}
+
+	conn, err := ldap.DialURL(d.cfg.URL, ldap.DialWithDialer(dialer))
+	if err != nil {
+		return nil, fmt.Errorf("dial %s: %w", d.cfg.URL, err)
+	}
+	conn.SetTimeout(d.cfg.Timeout)
+
+	if d.cfg.StartTLS {
+		u, err := url.Parse(d.cfg.URL)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("parse url: %w", err)
+		}
+		if err := conn.StartTLS(&tls.Config{ServerName: u.Hostname()}); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("start tls: %w", err)
+		}
+	}
+
+	return conn, nil
+}
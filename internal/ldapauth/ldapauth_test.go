@@ -0,0 +1,107 @@
+package ldapauth_test
+
+import (
+	"auth-service/config"
+	"auth-service/internal/ldapauth"
+	"auth-service/internal/ldapauth/ldaptest"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	baseDN    = "dc=example,dc=com"
+	adminsDN  = "cn=admins,ou=groups,dc=example,dc=com"
+	serviceDN = "cn=auth,ou=services,dc=example,dc=com"
+)
+
+func newDirectory(t *testing.T) *ldapauth.Directory {
+	t.Helper()
+
+	srv := ldaptest.NewServer(t,
+		ldaptest.Entry{DN: serviceDN, Password: "service-pass"},
+		ldaptest.Entry{
+			DN:       "uid=alice,ou=people,dc=example,dc=com",
+			Password: "alice-pass",
+			Attributes: map[string][]string{
+				"objectClass": {"person"},
+				"mail":        {"alice@example.com"},
+				"memberOf":    {"CN=Admins,OU=Groups,DC=example,DC=com", "cn=dev,ou=groups,dc=example,dc=com"},
+			},
+		},
+		ldaptest.Entry{
+			DN:         "uid=bob,ou=people,dc=example,dc=com",
+			Password:   "bob-pass",
+			Attributes: map[string][]string{"objectClass": {"person"}, "mail": {"bob@example.com"}},
+		},
+	)
+
+	return ldapauth.New(config.LDAPConfig{
+		URL:            srv.URL,
+		BindDN:         serviceDN,
+		BindPassword:   "service-pass",
+		BaseDN:         baseDN,
+		UserFilter:     "(&(objectClass=person)(mail=%s))",
+		EmailAttribute: "mail",
+		GroupAttribute: "memberOf",
+		GroupRoles:     config.LDAPGroupRoles{adminsDN: "admin"},
+		Timeout:        time.Second,
+	})
+}
+
+func TestDirectory_Authenticate(t *testing.T) {
+	dir := newDirectory(t)
+
+	entry, err := dir.Authenticate(context.Background(), "alice@example.com", "alice-pass")
+	require.NoError(t, err)
+	assert.Equal(t, "uid=alice,ou=people,dc=example,dc=com", entry.DN)
+	assert.Equal(t, "alice@example.com", entry.Email)
+	// DN групп сравниваются без учёта регистра
+	assert.Equal(t, []string{"admin"}, entry.Roles)
+
+	entry, err = dir.Authenticate(context.Background(), "bob@example.com", "bob-pass")
+	require.NoError(t, err)
+	assert.Empty(t, entry.Roles)
+}
+
+func TestDirectory_Authenticate_InvalidCredentials(t *testing.T) {
+	dir := newDirectory(t)
+
+	tests := []struct {
+		name     string
+		email    string
+		password string
+	}{
+		{name: "wrong password", email: "alice@example.com", password: "bob-pass"},
+		{name: "empty password", email: "alice@example.com", password: ""},
+		{name: "unknown user", email: "carol@example.com", password: "alice-pass"},
+		{name: "filter injection", email: "*", password: "alice-pass"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := dir.Authenticate(context.Background(), tt.email, tt.password)
+			require.ErrorIs(t, err, ldapauth.ErrInvalidCredentials)
+		})
+	}
+}
+
+func TestDirectory_Authenticate_ServiceBindFails(t *testing.T) {
+	srv := ldaptest.NewServer(t)
+
+	dir := ldapauth.New(config.LDAPConfig{
+		URL:          srv.URL,
+		BindDN:       serviceDN,
+		BindPassword: "wrong",
+		BaseDN:       baseDN,
+		UserFilter:   "(mail=%s)",
+		Timeout:      time.Second,
+	})
+
+	_, err := dir.Authenticate(context.Background(), "alice@example.com", "alice-pass")
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ldapauth.ErrInvalidCredentials, "misconfiguration must not look like a wrong password")
+}
@@ -0,0 +1,244 @@
+// Package ldaptest — LDAP сервер в памяти процесса для тестов: simple bind,
+// поиск с фильтрами равенства, присутствия и AND, unbind.
+package ldaptest
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+// протокольные операции LDAPv3 (RFC 4511, раздел 4.2)
+const (
+	appBindRequest   = 0
+	appBindResponse  = 1
+	appUnbindRequest = 2
+	appSearchRequest = 3
+	appSearchEntry   = 4
+	appSearchDone    = 5
+)
+
+// типы фильтров поиска (RFC 4511, раздел 4.5.1)
+const (
+	filterAnd           = 0
+	filterEqualityMatch = 3
+	filterPresent       = 7
+)
+
+// коды результата (RFC 4511, приложение A)
+const (
+	resultSuccess            = 0
+	resultOperationsError    = 1
+	resultProtocolError      = 2
+	resultUnwillingToPerform = 53
+	resultInvalidCredentials = 49
+)
+
+// Entry — запись каталога
+type Entry struct {
+	DN         string
+	Password   string
+	Attributes map[string][]string
+}
+
+// Server обслуживает LDAP на 127.0.0.1 до конца теста
+type Server struct {
+	URL string
+
+	listener net.Listener
+	entries  []Entry
+}
+
+// NewServer запускает сервер с записями entries; он останавливается в t.Cleanup
+func NewServer(t testing.TB, entries ...Entry) *Server {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ldaptest: listen: %v", err)
+	}
+
+	s := &Server{
+		URL:      "ldap://" + listener.Addr().String(),
+		listener: listener,
+		entries:  entries,
+	}
+	go s.serve()
+	t.Cleanup(func() { _ = listener.Close() })
+
+	return s
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil {
+			return
+		}
+		if len(packet.Children) < 2 {
+			return
+		}
+
+		messageID, ok := packet.Children[0].Value.(int64)
+		if !ok {
+			return
+		}
+		op := packet.Children[1]
+
+		var responses []*ber.Packet
+		switch op.Tag {
+		case appBindRequest:
+			responses = []*ber.Packet{s.bind(op)}
+		case appSearchRequest:
+			responses = s.search(op)
+		case appUnbindRequest:
+			return
+		default:
+			responses = []*ber.Packet{result(appSearchDone, resultUnwillingToPerform, "operation is not supported")}
+		}
+
+		for _, response := range responses {
+			envelope := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+			envelope.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageID, "MessageID"))
+			envelope.AppendChild(response)
+			if _, err := conn.Write(envelope.Bytes()); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *Server) bind(op *ber.Packet) *ber.Packet {
+	if len(op.Children) < 3 {
+		return result(appBindResponse, resultProtocolError, "malformed bind request")
+	}
+
+	dn, _ := op.Children[1].Value.(string)
+	password := op.Children[2].Data.String()
+
+	// анонимный bind
+	if dn == "" && password == "" {
+		return result(appBindResponse, resultSuccess, "")
+	}
+
+	for _, entry := range s.entries {
+		if strings.EqualFold(entry.DN, dn) && entry.Password != "" && entry.Password == password {
+			return result(appBindResponse, resultSuccess, "")
+		}
+	}
+
+	return result(appBindResponse, resultInvalidCredentials, "invalid credentials")
+}
+
+func (s *Server) search(op *ber.Packet) []*ber.Packet {
+	if len(op.Children) < 8 {
+		return []*ber.Packet{result(appSearchDone, resultProtocolError, "malformed search request")}
+	}
+
+	baseDN, _ := op.Children[0].Value.(string)
+	filter := op.Children[6]
+
+	var responses []*ber.Packet
+	for _, entry := range s.entries {
+		if !hasSuffixFold(entry.DN, baseDN) {
+			continue
+		}
+
+		ok, err := matches(entry, filter)
+		if err != nil {
+			return []*ber.Packet{result(appSearchDone, resultOperationsError, err.Error())}
+		}
+		if ok {
+			responses = append(responses, searchEntry(entry))
+		}
+	}
+
+	return append(responses, result(appSearchDone, resultSuccess, ""))
+}
+
+func matches(entry Entry, filter *ber.Packet) (bool, error) {
+	switch filter.Tag {
+	case filterAnd:
+		for _, child := range filter.Children {
+			ok, err := matches(entry, child)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+	case filterEqualityMatch:
+		if len(filter.Children) != 2 {
+			return false, errors.New("malformed equality filter")
+		}
+		attr := filter.Children[0].Data.String()
+		value := filter.Children[1].Data.String()
+		for _, v := range attribute(entry, attr) {
+			if strings.EqualFold(v, value) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case filterPresent:
+		return len(attribute(entry, filter.Data.String())) > 0, nil
+	default:
+		return false, errors.New("filter is not supported")
+	}
+}
+
+// attribute возвращает значения атрибута; имена атрибутов регистронезависимы
+func attribute(entry Entry, name string) []string {
+	for attr, values := range entry.Attributes {
+		if strings.EqualFold(attr, name) {
+			return values
+		}
+	}
+	return nil
+}
+
+func searchEntry(entry Entry) *ber.Packet {
+	packet := ber.Encode(ber.ClassApplication, ber.TypeConstructed, appSearchEntry, nil, "Search Result Entry")
+	packet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.DN, "Object Name"))
+
+	attrs := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attributes")
+	for name, values := range entry.Attributes {
+		attr := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attribute")
+		attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "Type"))
+
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+		for _, value := range values {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "Value"))
+		}
+		attr.AppendChild(set)
+		attrs.AppendChild(attr)
+	}
+	packet.AppendChild(attrs)
+
+	return packet
+}
+
+func result(tag ber.Tag, code int64, message string) *ber.Packet {
+	packet := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Result")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, "Result Code"))
+	packet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Matched DN"))
+	packet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, message, "Diagnostic Message"))
+	return packet
+}
+
+func hasSuffixFold(dn, suffix string) bool {
+	return len(dn) >= len(suffix) && strings.EqualFold(dn[len(dn)-len(suffix):], suffix)
+}
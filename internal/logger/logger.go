@@ -12,31 +12,38 @@ const (
 	EnvProd  = "prod"
 )
 
+// level — общий уровень всех логгеров из New; меняется через SetLevel
+var level slog.LevelVar
+
 // New создаёт slog.Logger на основе конфигурации
 func New(cfg *config.Config) *slog.Logger {
 	// Определяем уровень логирования из cfg.LogLevel
-	level := slog.LevelInfo
-	switch cfg.LogLevel {
-	case "debug":
-		level = slog.LevelDebug
-	case "info":
-		level = slog.LevelInfo
-	case "warn":
-		level = slog.LevelWarn
-	case "error":
-		level = slog.LevelError
-	}
+	SetLevel(cfg.LogLevel)
 
 	// Выбираем формат логов по cfg.Env
 	var handler slog.Handler
 	switch cfg.Env {
 	case EnvLocal:
-		handler = slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: level})
+		handler = slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: &level})
 	case EnvDev, EnvProd:
-		handler = slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level})
+		handler = slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: &level})
 	default:
-		handler = slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: level})
+		handler = slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: &level})
 	}
 
 	return slog.New(handler)
 }
+
+// SetLevel меняет уровень уже созданных логгеров; неизвестный уровень — info
+func SetLevel(name string) {
+	switch name {
+	case "debug":
+		level.Set(slog.LevelDebug)
+	case "warn":
+		level.Set(slog.LevelWarn)
+	case "error":
+		level.Set(slog.LevelError)
+	default:
+		level.Set(slog.LevelInfo)
+	}
+}
@@ -1,6 +1,7 @@
 package sl
 
 import (
+	"context"
 	"log/slog"
 )
 
@@ -10,3 +11,18 @@ func Err(err error) slog.Attr {
 		Value: slog.StringValue(err.Error()),
 	}
 }
+
+type ctxKey struct{}
+
+// NewContext возвращает контекст с логгером запроса (например, с request_id)
+func NewContext(ctx context.Context, log *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, log)
+}
+
+// FromContext возвращает логгер запроса или fallback, если его нет в контексте
+func FromContext(ctx context.Context, fallback *slog.Logger) *slog.Logger {
+	if log, ok := ctx.Value(ctxKey{}).(*slog.Logger); ok {
+		return log
+	}
+	return fallback
+}
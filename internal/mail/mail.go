@@ -0,0 +1,148 @@
+// Package mail отправляет служебные письма: через SMTP или, если он не настроен, в лог.
+package mail
+
+import (
+	"auth-service/config"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Message — текстовое письмо одному получателю
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New возвращает SMTP отправителя или, если MAIL_SMTP_ADDR пуст, LogSender
+func New(cfg config.MailConfig, log *slog.Logger) Sender {
+	if cfg.SMTPAddr == "" {
+		log.Warn("MAIL_SMTP_ADDR is not set, emails are written to the log")
+		return NewLogSender(log)
+	}
+	return &SMTPSender{cfg: cfg}
+}
+
+// SMTPSender отправляет письма через SMTP, используя STARTTLS, если сервер его поддерживает
+type SMTPSender struct {
+	cfg config.MailConfig
+}
+
+func (s *SMTPSender) Send(ctx context.Context, msg Message) error {
+	const op = "mail.Send"
+
+	data, err := s.build(msg)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.cfg.SMTPAddr)
+	if err != nil {
+		return fmt.Errorf("%s: dial: %w", op, err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	host, _, err := net.SplitHostPort(s.cfg.SMTPAddr)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return fmt.Errorf("%s: starttls: %w", op, err)
+		}
+	}
+	if s.cfg.SMTPUsername != "" {
+		// PlainAuth сам отказывается передавать пароль без TLS на удалённый хост
+		auth := smtp.PlainAuth("", s.cfg.SMTPUsername, s.cfg.SMTPPassword.Reveal(), host)
+		if err := c.Auth(auth); err != nil {
+			return fmt.Errorf("%s: auth: %w", op, err)
+		}
+	}
+
+	if err := c.Mail(s.cfg.From); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := c.Rcpt(msg.To); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return c.Quit()
+}
+
+// build собирает письмо RFC 5322 с телом text/plain в UTF-8
+func (s *SMTPSender) build(msg Message) ([]byte, error) {
+	for _, v := range []string{s.cfg.From, msg.To, msg.Subject} {
+		// перевод строки в заголовке позволил бы подставить свои заголовки
+		if strings.ContainsAny(v, "\r\n") {
+			return nil, errors.New("header contains line break")
+		}
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", s.cfg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+
+	return b.Bytes(), nil
+}
+
+// LogSender пишет письма в лог вместо отправки, для локального запуска
+type LogSender struct {
+	log *slog.Logger
+}
+
+func NewLogSender(log *slog.Logger) *LogSender {
+	return NewLogSender(log)
+}
+
+func (s *LogSender) Send(_ context.Context, msg Message) error {
+	s.log.Info("email is not sent, SMTP is not configured",
+		slog.String("to", msg.To),
+		slog.String("subject", msg.Subject),
+		slog.String("body", msg.Body),
+	)
+	return nil
+}
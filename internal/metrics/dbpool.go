@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// dbPoolCollector отдаёт статистику pgxpool.Pool при каждом сборе метрик
+type dbPoolCollector struct {
+	pool *pgxpool.Pool
+
+	maxConns          *prometheus.Desc
+	totalConns        *prometheus.Desc
+	acquiredConns     *prometheus.Desc
+	idleConns         *prometheus.Desc
+	constructingConns *prometheus.Desc
+	acquires          *prometheus.Desc
+	acquireWait       *prometheus.Desc
+	emptyAcquires     *prometheus.Desc
+	canceledAcquires  *prometheus.Desc
+	newConns          *prometheus.Desc
+	lifetimeDestroys  *prometheus.Desc
+	idleDestroys      *prometheus.Desc
+}
+
+func newDBPoolCollector(pool *pgxpool.Pool, name string) *dbPoolCollector {
+	desc := func(metric, help string) *prometheus.Desc {
+		return prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "db_pool", metric),
+			help,
+			nil,
+			prometheus.Labels{"db_name": name},
+		)
+	}
+
+	return &dbPoolCollector{
+		pool:              pool,
+		maxConns:          desc("max_conns", "Maximum size of the connection pool."),
+		totalConns:        desc("conns", "Open connections: acquired, idle and being established."),
+		acquiredConns:     desc("acquired_conns", "Connections in use."),
+		idleConns:         desc("idle_conns", "Idle connections."),
+		constructingConns: desc("constructing_conns", "Connections being established."),
+		acquires:          desc("acquires_total", "Successful connection acquisitions."),
+		acquireWait:       desc("acquire_duration_seconds_total", "Total time spent acquiring connections."),
+		emptyAcquires:     desc("empty_acquires_total", "Acquisitions that waited for a connection because the pool was empty."),
+		canceledAcquires:  desc("canceled_acquires_total", "Acquisitions canceled by context."),
+		newConns:          desc("new_conns_total", "Connections opened by the pool."),
+		lifetimeDestroys:  desc("max_lifetime_destroys_total", "Connections closed after DB_MAX_CONN_LIFETIME."),
+		idleDestroys:      desc("max_idle_destroys_total", "Connections closed after DB_MAX_CONN_IDLE_TIME."),
+	}
+}
+
+func (c *dbPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *dbPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.pool.Stat()
+
+	gauge := func(desc *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v)
+	}
+	counter := func(desc *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, v)
+	}
+
+	gauge(c.maxConns, float64(s.MaxConns()))
+	gauge(c.totalConns, float64(s.TotalConns()))
+	gauge(c.acquiredConns, float64(s.AcquiredConns()))
+	gauge(c.idleConns, float64(s.IdleConns()))
+	gauge(c.constructingConns, float64(s.ConstructingConns()))
+	counter(c.acquires, float64(s.AcquireCount()))
+	counter(c.acquireWait, s.AcquireDuration().Seconds())
+	counter(c.emptyAcquires, float64(s.EmptyAcquireCount()))
+	counter(c.canceledAcquires, float64(s.CanceledAcquireCount()))
+	counter(c.newConns, float64(s.NewConnsCount()))
+	counter(c.lifetimeDestroys, float64(s.MaxLifetimeDestroyCount()))
+	counter(c.idleDestroys, float64(s.MaxIdleDestroyCount()))
+}
@@ -0,0 +1,27 @@
+package metrics_test
+
+import (
+	"auth-service/internal/metrics"
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterDB(t *testing.T) {
+	// пул не подключается к базе, пока соединение не понадобится
+	pool, err := pgxpool.New(context.Background(), "postgres://auth@127.0.0.1:1/metrics_db?pool_max_conns=7")
+	require.NoError(t, err)
+	defer pool.Close()
+
+	require.NoError(t, metrics.RegisterDB(pool, "metrics_db"))
+	// повторная регистрация того же пула не ошибка
+	require.NoError(t, metrics.RegisterDB(pool, "metrics_db"))
+
+	lines := scrape(t, "auth_db_pool_")
+	assert.Contains(t, lines, `auth_db_pool_max_conns{db_name="metrics_db"} 7`)
+	assert.Contains(t, lines, `auth_db_pool_acquired_conns{db_name="metrics_db"} 0`)
+	assert.Contains(t, lines, `auth_db_pool_new_conns_total{db_name="metrics_db"} 0`)
+}
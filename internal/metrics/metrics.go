@@ -0,0 +1,142 @@
+// Package metrics собирает метрики сервиса в формате Prometheus
+// и отдаёт их HTTP обработчиком /metrics.
+package metrics
+
+import (
+	"auth-service/internal/apperr"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "auth"
+
+// Registry — реестр метрик сервиса; стандартные метрики Go и процесса
+// регистрируются в нём сразу, пул соединений — через RegisterDB
+var Registry = prometheus.NewRegistry()
+
+var (
+	rpcRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "grpc_requests_total",
+		Help:      "gRPC calls by method and status code.",
+	}, []string{"method", "code"})
+
+	rpcDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "grpc_request_duration_seconds",
+		Help:      "gRPC call latency by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Successful logins by app.",
+	}, []string{"app_id"})
+
+	loginFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "login_failures_total",
+		Help:      "Failed logins by app and error reason.",
+	}, []string{"app_id", "reason"})
+
+	bcryptDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "bcrypt_duration_seconds",
+		Help:      "Time spent hashing and comparing passwords with bcrypt.",
+		// bcrypt с cost 10 занимает десятки миллисекунд
+		Buckets: []float64{.01, .025, .05, .1, .25, .5, 1},
+	}, []string{"op"})
+
+	tokensIssued = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tokens_issued_total",
+		Help:      "Signed tokens by kind.",
+	}, []string{"kind"})
+)
+
+// операции bcrypt для ObserveBcrypt
+const (
+	BcryptHash    = "hash"
+	BcryptCompare = "compare"
+)
+
+// виды токенов для TokenIssued
+const (
+	TokenAccess = "access"
+	TokenClient = "client"
+	TokenID     = "id_token"
+)
+
+// reasonInternal — причина неудачного входа для ошибок без apperr
+const reasonInternal = "INTERNAL"
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		rpcRequests,
+		rpcDuration,
+		logins,
+		loginFailures,
+		bcryptDuration,
+		tokensIssued,
+	)
+}
+
+// Handler отдаёт метрики реестра в текстовом формате Prometheus
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// RegisterDB добавляет метрики пула соединений pool (pgxpool.Stat)
+func RegisterDB(pool *pgxpool.Pool, name string) error {
+	err := Registry.Register(newDBPoolCollector(pool, name))
+
+	var already prometheus.AlreadyRegisteredError
+	if errors.As(err, &already) {
+		return nil
+	}
+
+	return err
+}
+
+// ObserveRPC учитывает завершённый gRPC вызов
+func ObserveRPC(method, code string, d time.Duration) {
+	rpcRequests.WithLabelValues(method, code).Inc()
+	rpcDuration.WithLabelValues(method).Observe(d.Seconds())
+}
+
+// ObserveLogin учитывает попытку входа в приложение appID.
+// Причина неудачи берётся из apperr, остальные ошибки считаются как INTERNAL.
+func ObserveLogin(appID int, err error) {
+	app := strconv.Itoa(appID)
+
+	if err == nil {
+		logins.WithLabelValues(app).Inc()
+		return
+	}
+
+	reason := reasonInternal
+	if e, ok := apperr.As(err); ok {
+		reason = e.Reason
+	}
+	loginFailures.WithLabelValues(app, reason).Inc()
+}
+
+// ObserveBcrypt учитывает время операции bcrypt, начатой в start
+func ObserveBcrypt(op string, start time.Time) {
+	bcryptDuration.WithLabelValues(op).Observe(time.Since(start).Seconds())
+}
+
+// TokenIssued учитывает подписанный токен вида kind
+func TokenIssued(kind string) {
+	tokensIssued.WithLabelValues(kind).Inc()
+}
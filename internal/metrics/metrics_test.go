@@ -0,0 +1,67 @@
+package metrics_test
+
+import (
+	"auth-service/internal/metrics"
+	"auth-service/internal/repository"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scrape возвращает строки ответа /metrics, начинающиеся с prefix
+func scrape(t *testing.T, prefix string) []string {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+
+	var lines []string
+	for line := range strings.Lines(string(body)) {
+		if strings.HasPrefix(line, prefix) {
+			lines = append(lines, strings.TrimSpace(line))
+		}
+	}
+	return lines
+}
+
+func TestObserveLogin(t *testing.T) {
+	metrics.ObserveLogin(901, nil)
+	metrics.ObserveLogin(901, fmt.Errorf("auth.Login:%w", repository.ErrInvalidCredentials))
+	metrics.ObserveLogin(901, fmt.Errorf("auth.Login:%w", repository.ErrInvalidCredentials))
+	metrics.ObserveLogin(901, errors.New("connection refused"))
+
+	assert.Contains(t, scrape(t, "auth_logins_total"), `auth_logins_total{app_id="901"} 1`)
+	failures := scrape(t, "auth_login_failures_total")
+	assert.Contains(t, failures, `auth_login_failures_total{app_id="901",reason="INVALID_CREDENTIALS"} 2`)
+	assert.Contains(t, failures, `auth_login_failures_total{app_id="901",reason="INTERNAL"} 1`)
+}
+
+func TestObserveRPC(t *testing.T) {
+	metrics.ObserveRPC("/auth.Auth/Register", "AlreadyExists", 30*time.Millisecond)
+
+	assert.Contains(t, scrape(t, "auth_grpc_requests_total"),
+		`auth_grpc_requests_total{code="AlreadyExists",method="/auth.Auth/Register"} 1`)
+	assert.Contains(t, scrape(t, "auth_grpc_request_duration_seconds_count"),
+		`auth_grpc_request_duration_seconds_count{method="/auth.Auth/Register"} 1`)
+}
+
+func TestObserveBcryptAndTokens(t *testing.T) {
+	metrics.ObserveBcrypt(metrics.BcryptCompare, time.Now().Add(-50*time.Millisecond))
+	metrics.TokenIssued(metrics.TokenID)
+
+	assert.Contains(t, scrape(t, "auth_bcrypt_duration_seconds_count"),
+		`auth_bcrypt_duration_seconds_count{op="compare"} 1`)
+	assert.Contains(t, scrape(t, "auth_tokens_issued_total"), `auth_tokens_issued_total{kind="id_token"} 1`)
+}
@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// scopes личных API ключей — к каким методам gRPC пускают токен, полученный
+// обменом ключа. Остальные методы, в том числе управление ключами и паролем,
+// такому токену недоступны.
+const (
+	ScopeProfileRead  = "profile:read"
+	ScopeProfileWrite = "profile:write"
+	ScopeAPIKeysRead  = "apikeys:read"
+)
+
+// APIKeyScopes — все scopes, которые можно выдать личному ключу
+var APIKeyScopes = []string{ScopeProfileRead, ScopeProfileWrite, ScopeAPIKeysRead}
+
+// APIKey — долгоживущий ключ, который обменивается на короткий JWT.
+// Личный ключ принадлежит пользователю UserID, ключ сервисного аккаунта
+// (UserID == 0) — приложению AppID.
+type APIKey struct {
+	ID     int64
+	Prefix string
+	// SecretHash — SHA-256 полного ключа, сам ключ не хранится
+	SecretHash []byte
+	Name       string
+	UserID     int64
+	AppID      int
+	Scopes     []string
+	ExpiresAt  time.Time
+	// LastUsedAt и RevokedAt нулевые, если ключ не использовался или не отозван
+	LastUsedAt time.Time
+	RevokedAt  time.Time
+	CreatedAt  time.Time
+}
+
+// IsServiceAccount — ключ сервисного аккаунта приложения, а не пользователя
+func (k APIKey) IsServiceAccount() bool {
+	return k.UserID == 0
+}
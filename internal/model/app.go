@@ -1,7 +1,35 @@
 package model
 
+// источники учётных данных пользователей, которые проверяются при входе в приложение
+const (
+	AuthenticatorLocal = "local"
+	AuthenticatorLDAP  = "ldap"
+)
+
+// режимы хранения секрета приложения
+const (
+	// AppSecretAuthOnly — секрет только проверяется, хранится лишь его хеш
+	AppSecretAuthOnly = "auth_only"
+	// AppSecretSigning — секретом ещё и подписывают, нужна зашифрованная копия
+	AppSecretSigning = "signing"
+)
+
 type App struct {
-	ID     int
-	Name   string
-	Secret string
+	ID   int
+	Name string
+	// SecretHash — SHA-256 секрета, используется для аутентификации клиента
+	SecretHash []byte
+	// SecretEnc — секрет, зашифрованный appsecret.Envelope; только у приложений AppSecretSigning
+	SecretEnc []byte
+	// SecretMode — AppSecretAuthOnly или AppSecretSigning, задаётся при создании
+	SecretMode string
+	// Scopes — права, которые приложение получает по client credentials
+	Scopes []string
+	// RedirectURIs — зарегистрированные redirect_uri для authorization code
+	RedirectURIs []string
+	// Authenticators — источники учётных данных в порядке проверки при входе
+	Authenticators []string
+	// Public — публичный клиент (SPA, мобильное приложение): не хранит секрет и
+	// обменивает код авторизации только с PKCE
+	Public bool
 }
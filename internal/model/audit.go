@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// Действия, записываемые в журнал аудита
+const (
+	AuditAppCreate       = "app.create"
+	AuditAppUpdate       = "app.update"
+	AuditAppSecretRotate = "app.secret_rotate"
+	AuditAppDelete       = "app.delete"
+	AuditAPIKeyCreate    = "api_key.create"
+	AuditAPIKeyRevoke    = "api_key.revoke"
+	AuditUserUpdate      = "user.update"
+	AuditUserDisable     = "user.disable"
+	AuditUserEnable      = "user.enable"
+	AuditUserDelete      = "user.delete"
+)
+
+type AuditEntry struct {
+	ID        int64
+	ActorID   int64
+	Action    string
+	Entity    string
+	EntityID  string
+	Details   map[string]any
+	CreatedAt time.Time
+}
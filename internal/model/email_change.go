@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// EmailChange — запрос пользователя на смену email
+type EmailChange struct {
+	ID          int64
+	UserID      int64
+	OldEmail    string
+	NewEmail    string
+	ConfirmHash []byte
+	CancelHash  []byte
+	// ExpiresAt — срок ссылки подтверждения, CancelExpiresAt — ссылки отмены
+	ExpiresAt       time.Time
+	CancelExpiresAt time.Time
+	ConfirmedAt     time.Time
+	CanceledAt      time.Time
+	CreatedAt       time.Time
+}
+
+func (c EmailChange) Confirmed() bool {
+	return !c.ConfirmedAt.IsZero()
+}
+
+func (c EmailChange) Canceled() bool {
+	return !c.CanceledAt.IsZero()
+}
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// LinkedIdentity связывает пользователя внешнего OIDC провайдера (issuer + sub) с users.id
+type LinkedIdentity struct {
+	UserID    int64     `db:"user_id"`
+	Provider  string    `db:"provider"`
+	Subject   string    `db:"subject"`
+	Email     string    `db:"email"`
+	CreatedAt time.Time `db:"created_at"`
+}
@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// AuthorizationRequest — параметры запроса /authorize, прошедшие проверку
+type AuthorizationRequest struct {
+	AppID               int      `json:"app_id"`
+	RedirectURI         string   `json:"redirect_uri"`
+	Scopes              []string `json:"scopes"`
+	State               string   `json:"state"`
+	CodeChallenge       string   `json:"code_challenge"`
+	CodeChallengeMethod string   `json:"code_challenge_method"`
+	// Nonce из OpenID Connect, возвращается в id_token без изменений
+	Nonce string `json:"nonce,omitempty"`
+}
+
+// AuthorizationCode — выданный код авторизации; сам код не хранится, только его хеш
+type AuthorizationCode struct {
+	CodeHash      []byte
+	AppID         int
+	UserID        int64
+	RedirectURI   string
+	Scopes        []string
+	CodeChallenge string
+	Nonce         string
+	// AuthTime — момент, когда пользователь ввёл пароль
+	AuthTime  time.Time
+	ExpiresAt time.Time
+}
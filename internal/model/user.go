@@ -2,6 +2,12 @@ package model
 
 import "time"
 
+// источники пользователей: кто создал запись
+const (
+	UserSourceLocal = "local"
+	UserSourceLDAP  = "ldap"
+)
+
 type User struct {
 	ID        int64     `db:"id"`
 	Email     string    `db:"email"`
@@ -9,4 +15,26 @@ type User struct {
 	IsAdmin   bool      `db:"is_admin"`
 	CreatedAt time.Time `db:"created_at"`
 	UpdatedAt time.Time `db:"updated_at"`
+	// DisabledAt — момент блокировки администратором, нулевой у активных пользователей
+	DisabledAt time.Time `db:"disabled_at"`
+	// Source — UserSourceLocal или UserSourceLDAP; каталог управляет только своими записями
+	Source string `db:"source"`
+
+	// профиль, который пользователь редактирует сам; пустые поля не заданы
+	DisplayName string `db:"display_name"`
+	Locale      string `db:"locale"`
+	Timezone    string `db:"timezone"`
+	AvatarURL   string `db:"avatar_url"`
+}
+
+// Disabled сообщает, что пользователю запрещены вход и выдача токенов
+func (u User) Disabled() bool {
+	return !u.DisabledAt.IsZero()
+}
+
+// Profile — пользователь вместе с его атрибутами в приложении AppID
+type Profile struct {
+	User       User
+	AppID      int
+	Attributes map[string]any
 }
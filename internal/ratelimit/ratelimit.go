@@ -0,0 +1,127 @@
+// Package ratelimit — token bucket: у каждого ключа корзина на Burst токенов,
+// которая пополняется со скоростью Rate токенов в секунду; вызов забирает токен.
+package ratelimit
+
+import (
+	"auth-service/config"
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Limit — параметры корзины
+type Limit struct {
+	// Rate — токенов в секунду
+	Rate float64
+	// Burst — ёмкость корзины, сколько вызовов можно сделать подряд
+	Burst int
+}
+
+// Store хранит состояние корзин. Take забирает токен из корзины key
+// и, если токенов нет, сообщает, через сколько появится следующий.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (allowed bool, retryAfter time.Duration, err error)
+}
+
+// refill возвращает число токенов в корзине спустя elapsed
+func refill(tokens float64, elapsed time.Duration, limit Limit) float64 {
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	return math.Min(float64(limit.Burst), tokens+elapsed.Seconds()*limit.Rate)
+}
+
+// wait — через сколько в корзине с tokens появится целый токен
+func wait(tokens float64, limit Limit) time.Duration {
+	return time.Duration(math.Ceil((1 - tokens) / limit.Rate * float64(time.Second)))
+}
+
+// хранилища для RATE_LIMIT_STORE
+const (
+	StoreMemory = "memory"
+	StoreRedis  = "redis"
+)
+
+// NewStore создаёт хранилище, выбранное в cfg.Store
+func NewStore(cfg config.RateLimitConfig) (Store, error) {
+	const op = "ratelimit.NewStore"
+
+	switch cfg.Store {
+	case StoreMemory:
+		return NewMemoryStore(), nil
+	case StoreRedis:
+		opts, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return NewRedisStore(redis.NewClient(opts)), nil
+	}
+
+	return nil, fmt.Errorf("%s: unknown store %q", op, cfg.Store)
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	// full — за сколько пустая корзина пополняется целиком
+	full time.Duration
+}
+
+// MemoryStore хранит корзины в памяти процесса: у каждой реплики свои лимиты
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// sweepInterval — как часто удалять полные корзины, чтобы память не росла
+// с числом разных IP и email
+const sweepInterval = time.Minute
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]*bucket{}}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit, now time.Time) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.sweep(now)
+		s.lastSweep = now
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{
+			tokens:  float64(limit.Burst),
+			updated: now,
+			full:    time.Duration(float64(limit.Burst) / limit.Rate * float64(time.Second)),
+		}
+		s.buckets[key] = b
+	}
+
+	b.tokens = refill(b.tokens, now.Sub(b.updated), limit)
+	b.updated = now
+
+	if b.tokens < 1 {
+		return false, wait(b.tokens, limit), nil
+	}
+	b.tokens--
+
+	return true, 0, nil
+}
+
+// sweep удаляет корзины, которые не трогали дольше, чем нужно для полного
+// пополнения: новая корзина с полным запасом ничем от них не отличается
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if now.Sub(b.updated) > b.full {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package ratelimit_test
+
+import (
+	"auth-service/internal/ratelimit"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// одинаковые сценарии для обоих хранилищ: время задаётся явно
+func stores(t *testing.T) map[string]ratelimit.Store {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	return map[string]ratelimit.Store{
+		"memory": ratelimit.NewMemoryStore(),
+		"redis":  ratelimit.NewRedisStore(client),
+	}
+}
+
+func TestTake_BurstThenRefill(t *testing.T) {
+	limit := ratelimit.Limit{Rate: 2, Burst: 3}
+	start := time.UnixMilli(1_700_000_000_000)
+
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			for i := 0; i < 3; i++ {
+				allowed, _, err := store.Take(ctx, "k", limit, start)
+				require.NoError(t, err)
+				assert.True(t, allowed, "call %d", i)
+			}
+
+			allowed, retry, err := store.Take(ctx, "k", limit, start)
+			require.NoError(t, err)
+			assert.False(t, allowed)
+			assert.Equal(t, 500*time.Millisecond, retry)
+
+			// через 500ms появляется ровно один токен
+			allowed, _, err = store.Take(ctx, "k", limit, start.Add(500*time.Millisecond))
+			require.NoError(t, err)
+			assert.True(t, allowed)
+
+			allowed, _, err = store.Take(ctx, "k", limit, start.Add(500*time.Millisecond))
+			require.NoError(t, err)
+			assert.False(t, allowed)
+
+			// корзина не переполняется сверх Burst
+			later := start.Add(time.Hour)
+			for i := 0; i < 3; i++ {
+				allowed, _, err := store.Take(ctx, "k", limit, later)
+				require.NoError(t, err)
+				assert.True(t, allowed)
+			}
+			allowed, _, err = store.Take(ctx, "k", limit, later)
+			require.NoError(t, err)
+			assert.False(t, allowed)
+		})
+	}
+}
+
+func TestTake_KeysAreIndependent(t *testing.T) {
+	limit := ratelimit.Limit{Rate: 1, Burst: 1}
+	now := time.UnixMilli(1_700_000_000_000)
+
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			allowed, _, err := store.Take(ctx, "a", limit, now)
+			require.NoError(t, err)
+			assert.True(t, allowed)
+
+			allowed, _, err = store.Take(ctx, "a", limit, now)
+			require.NoError(t, err)
+			assert.False(t, allowed)
+
+			allowed, _, err = store.Take(ctx, "b", limit, now)
+			require.NoError(t, err)
+			assert.True(t, allowed)
+		})
+	}
+}
+
+func TestRedisStore_Unavailable(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { _ = client.Close() })
+	mr.Close()
+
+	_, _, err := ratelimit.NewRedisStore(client).Take(context.Background(), "k", ratelimit.Limit{Rate: 1, Burst: 1}, time.Now())
+	assert.Error(t, err)
+}
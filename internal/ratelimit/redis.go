@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// keyPrefix отделяет корзины от других данных в той же базе Redis
+const keyPrefix = "ratelimit:"
+
+// takeScript атомарно пополняет корзину и забирает токен. Состояние —
+// хеш {tokens, ts}, время в миллисекундах передаёт клиент; ключ истекает,
+// когда корзина пополнилась бы целиком.
+var takeScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000)
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) * 1000 / rate)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst * 1000 / rate))
+
+return {allowed, retry}
+`)
+
+// RedisStore хранит корзины в Redis (или совместимом сервере),
+// поэтому лимиты общие для всех реплик сервиса
+type RedisStore struct {
+	client redis.Scripter
+}
+
+func NewRedisStore(client redis.Scripter) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func (s *RedisStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (bool, time.Duration, error) {
+	const op = "ratelimit.RedisStore.Take"
+
+	res, err := takeScript.Run(ctx, s.client,
+		[]string{keyPrefix + key},
+		limit.Rate, limit.Burst, now.UnixMilli(),
+	).Int64Slice()
+	if err != nil {
+		return false, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(res) != 2 {
+		return false, 0, fmt.Errorf("%s: unexpected script result %v", op, res)
+	}
+
+	if res[0] == 1 {
+		return true, 0, nil
+	}
+
+	return false, time.Duration(max(res[1], 1)) * time.Millisecond, nil
+}
@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"auth-service/internal/model"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const apiKeyColumns = `id, prefix, secret_hash, name, user_id, app_id, scopes,
+	          expires_at, last_used_at, revoked_at, created_at`
+
+type APIKeyRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAPIKeyRepository(db *pgxpool.Pool) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+// CreateAPIKey сохраняет ключ и записывает audit с его id в журнал аудита
+func (r *APIKeyRepository) CreateAPIKey(ctx context.Context, key model.APIKey, audit model.AuditEntry) (model.APIKey, error) {
+	const op = "repository.CreateAPIKey"
+
+	query := `INSERT INTO api_keys (prefix, secret_hash, name, user_id, app_id, scopes, expires_at)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7)
+	          RETURNING id, created_at`
+
+	err := withAudit(ctx, r.db, op, &audit, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, query,
+			key.Prefix,
+			key.SecretHash,
+			key.Name,
+			pgtype.Int8{Int64: key.UserID, Valid: key.UserID != 0},
+			key.AppID,
+			key.Scopes,
+			key.ExpiresAt,
+		).Scan(&key.ID, &key.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		audit.EntityID = strconv.FormatInt(key.ID, 10)
+		return nil
+	})
+	if err != nil {
+		return model.APIKey{}, err
+	}
+
+	return key, nil
+}
+
+// APIKeyByPrefix возвращает ключ по открытой части, в том числе отозванный
+func (r *APIKeyRepository) APIKeyByPrefix(ctx context.Context, prefix string) (model.APIKey, error) {
+	const op = "repository.APIKeyByPrefix"
+
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE prefix = $1`
+
+	key, err := scanAPIKey(r.db.QueryRow(ctx, query, prefix))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.APIKey{}, fmt.Errorf("%s: %w", op, ErrAPIKeyNotFound)
+		}
+		return model.APIKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return key, nil
+}
+
+func (r *APIKeyRepository) APIKey(ctx context.Context, id int64) (model.APIKey, error) {
+	const op = "repository.APIKey"
+
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE id = $1`
+
+	key, err := scanAPIKey(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.APIKey{}, fmt.Errorf("%s: %w", op, ErrAPIKeyNotFound)
+		}
+		return model.APIKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return key, nil
+}
+
+// ListUserAPIKeys возвращает до limit личных ключей пользователя с id больше afterID
+func (r *APIKeyRepository) ListUserAPIKeys(ctx context.Context, userID, afterID int64, limit int) ([]model.APIKey, error) {
+	const op = "repository.ListUserAPIKeys"
+
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys
+	          WHERE user_id = $1 AND id > $2 ORDER BY id LIMIT $3`
+
+	return r.listAPIKeys(ctx, op, query, userID, afterID, limit)
+}
+
+// ListServiceAPIKeys возвращает до limit ключей сервисного аккаунта приложения с id больше afterID
+func (r *APIKeyRepository) ListServiceAPIKeys(ctx context.Context, appID int, afterID int64, limit int) ([]model.APIKey, error) {
+	const op = "repository.ListServiceAPIKeys"
+
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys
+	          WHERE app_id = $1 AND user_id IS NULL AND id > $2 ORDER BY id LIMIT $3`
+
+	return r.listAPIKeys(ctx, op, query, appID, afterID, limit)
+}
+
+// RevokeAPIKey отзывает ключ; повторный отзыв не меняет время первого
+func (r *APIKeyRepository) RevokeAPIKey(ctx context.Context, id int64, audit model.AuditEntry) error {
+	const op = "repository.RevokeAPIKey"
+
+	query := `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, $2) WHERE id = $1`
+
+	return withAudit(ctx, r.db, op, &audit, func(tx pgx.Tx) error {
+		res, err := tx.Exec(ctx, query, id, time.Now())
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		return checkAffected(op, res, ErrAPIKeyNotFound)
+	})
+}
+
+// TouchAPIKey запоминает время последнего обмена ключа
+func (r *APIKeyRepository) TouchAPIKey(ctx context.Context, id int64, usedAt time.Time) error {
+	const op = "repository.TouchAPIKey"
+
+	query := `UPDATE api_keys SET last_used_at = $2 WHERE id = $1`
+
+	if _, err := r.db.Exec(ctx, query, id, usedAt); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *APIKeyRepository) listAPIKeys(ctx context.Context, op, query string, args ...any) ([]model.APIKey, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var keys []model.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan: %w", op, err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return keys, nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAPIKey(row scanner) (model.APIKey, error) {
+	var (
+		key       model.APIKey
+		userID    pgtype.Int8
+		lastUsed  pgtype.Timestamptz
+		revokedAt pgtype.Timestamptz
+	)
+
+	err := row.Scan(
+		&key.ID,
+		&key.Prefix,
+		&key.SecretHash,
+		&key.Name,
+		&userID,
+		&key.AppID,
+		&key.Scopes,
+		&key.ExpiresAt,
+		&lastUsed,
+		&revokedAt,
+		&key.CreatedAt,
+	)
+	if err != nil {
+		return model.APIKey{}, err
+	}
+
+	key.UserID = userID.Int64
+	key.LastUsedAt = lastUsed.Time
+	key.RevokedAt = revokedAt.Time
+
+	return key, nil
+}
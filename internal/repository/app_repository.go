@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"auth-service/internal/model"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AppRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAppRepository(db *pgxpool.Pool) *AppRepository {
+	return &AppRepository{db: db}
+}
+
+// CreateApp создаёт приложение и записывает audit с его id в журнал аудита
+func (r *AppRepository) CreateApp(ctx context.Context, app model.App, audit model.AuditEntry) (model.App, error) {
+	const op = "repository.CreateApp"
+
+	query := `INSERT INTO apps (name, scopes, redirect_uris, authenticators, secret_hash, secret_enc, secret_mode, public)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`
+
+	err := withAudit(ctx, r.db, op, &audit, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, query,
+			app.Name,
+			app.Scopes,
+			app.RedirectURIs,
+			app.Authenticators,
+			app.SecretHash,
+			app.SecretEnc,
+			app.SecretMode,
+			app.Public,
+		).Scan(&app.ID)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		audit.EntityID = strconv.Itoa(app.ID)
+		return nil
+	})
+	if err != nil {
+		return model.App{}, err
+	}
+
+	return app, nil
+}
+
+// UpdateApp заменяет имя, scopes, redirect_uri, источники учётных данных и тип клиента
+func (r *AppRepository) UpdateApp(ctx context.Context, app model.App, audit model.AuditEntry) (model.App, error) {
+	const op = "repository.UpdateApp"
+
+	query := `UPDATE apps SET name = $2, scopes = $3, redirect_uris = $4, authenticators = $5, public = $6
+	          WHERE id = $1
+	          RETURNING secret_hash, secret_enc, secret_mode`
+
+	err := withAudit(ctx, r.db, op, &audit, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, query,
+			app.ID,
+			app.Name,
+			app.Scopes,
+			app.RedirectURIs,
+			app.Authenticators,
+			app.Public,
+		).Scan(&app.SecretHash, &app.SecretEnc, &app.SecretMode)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("%s: %w", op, ErrAppNotFound)
+			}
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return model.App{}, err
+	}
+
+	return app, nil
+}
+
+// ListApps возвращает до limit приложений с id больше afterID (курсорная пагинация)
+func (r *AppRepository) ListApps(ctx context.Context, afterID, limit int) ([]model.App, error) {
+	const op = "repository.ListApps"
+
+	query := `SELECT id, name, scopes, redirect_uris, authenticators, secret_mode, public
+	          FROM apps WHERE id > $1 ORDER BY id LIMIT $2`
+
+	rows, err := r.db.Query(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var apps []model.App
+	for rows.Next() {
+		var app model.App
+		err := rows.Scan(&app.ID, &app.Name, &app.Scopes, &app.RedirectURIs, &app.Authenticators, &app.SecretMode, &app.Public)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan: %w", op, err)
+		}
+		apps = append(apps, app)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return apps, nil
+}
+
+// SecretMode возвращает режим хранения секрета приложения
+func (r *AppRepository) SecretMode(ctx context.Context, appID int) (string, error) {
+	const op = "repository.SecretMode"
+
+	var mode string
+	err := r.db.QueryRow(ctx, `SELECT secret_mode FROM apps WHERE id = $1`, appID).Scan(&mode)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", fmt.Errorf("%s: %w", op, ErrAppNotFound)
+		}
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return mode, nil
+}
+
+func (r *AppRepository) UpdateAppSecret(ctx context.Context, appID int, secretHash, secretEnc []byte, audit model.AuditEntry) error {
+	const op = "repository.UpdateAppSecret"
+
+	query := `UPDATE apps SET secret_hash = $2, secret_enc = $3 WHERE id = $1`
+
+	return withAudit(ctx, r.db, op, &audit, func(tx pgx.Tx) error {
+		res, err := tx.Exec(ctx, query, appID, secretHash, secretEnc)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		return checkAffected(op, res, ErrAppNotFound)
+	})
+}
+
+func (r *AppRepository) DeleteApp(ctx context.Context, appID int, audit model.AuditEntry) error {
+	const op = "repository.DeleteApp"
+
+	return withAudit(ctx, r.db, op, &audit, func(tx pgx.Tx) error {
+		res, err := tx.Exec(ctx, `DELETE FROM apps WHERE id = $1`, appID)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		return checkAffected(op, res, ErrAppNotFound)
+	})
+}
+
+// checkAffected возвращает notFound, если запрос не затронул ни одной строки
+func checkAffected(op string, res pgconn.CommandTag, notFound error) error {
+	if res.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, notFound)
+	}
+	return nil
+}
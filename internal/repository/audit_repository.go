@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"auth-service/internal/model"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// withAudit выполняет изменение fn и сохраняет запись audit в журнал аудита
+// в одной транзакции: изменение без записи в журнале не применяется
+func withAudit(ctx context.Context, db *pgxpool.Pool, op string, audit *model.AuditEntry, fn func(tx pgx.Tx) error) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := recordAudit(ctx, tx, *audit); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// recordAudit сохраняет запись в журнал аудита
+func recordAudit(ctx context.Context, tx pgx.Tx, entry model.AuditEntry) error {
+	details := entry.Details
+	if details == nil {
+		details = map[string]any{}
+	}
+
+	detailsJSON, err := json.Marshal(details)
+	if err != nil {
+		return fmt.Errorf("marshal audit details: %w", err)
+	}
+
+	query := `INSERT INTO audit_log (actor_id, action, entity, entity_id, details)
+	          VALUES ($1, $2, $3, $4, $5)`
+
+	_, err = tx.Exec(ctx, query, entry.ActorID, entry.Action, entry.Entity, entry.EntityID, detailsJSON)
+	if err != nil {
+		return fmt.Errorf("record audit entry: %w", err)
+	}
+
+	return nil
+}
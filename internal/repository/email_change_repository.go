@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"auth-service/internal/model"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const emailChangeColumns = `id, user_id, old_email, new_email, confirm_hash, cancel_hash,
+	          expires_at, cancel_expires_at, confirmed_at, canceled_at, created_at`
+
+type EmailChangeRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewEmailChangeRepository(db *pgxpool.Pool) *EmailChangeRepository {
+	return &EmailChangeRepository{db: db}
+}
+
+// CreateEmailChange сохраняет запрос и отменяет неподтверждённые запросы пользователя:
+// действует только последняя ссылка подтверждения
+func (r *EmailChangeRepository) CreateEmailChange(ctx context.Context, change model.EmailChange) (model.EmailChange, error) {
+	const op = "repository.CreateEmailChange"
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return model.EmailChange{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	_, err = tx.Exec(ctx,
+		`UPDATE email_changes SET canceled_at = $2
+		 WHERE user_id = $1 AND confirmed_at IS NULL AND canceled_at IS NULL`,
+		change.UserID, time.Now().UTC(),
+	)
+	if err != nil {
+		return model.EmailChange{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := `INSERT INTO email_changes
+	              (user_id, old_email, new_email, confirm_hash, cancel_hash, expires_at, cancel_expires_at)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7)
+	          RETURNING id, created_at`
+
+	err = tx.QueryRow(ctx, query,
+		change.UserID,
+		change.OldEmail,
+		change.NewEmail,
+		change.ConfirmHash,
+		change.CancelHash,
+		change.ExpiresAt.UTC(),
+		change.CancelExpiresAt.UTC(),
+	).Scan(&change.ID, &change.CreatedAt)
+	if err != nil {
+		return model.EmailChange{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return model.EmailChange{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return change, nil
+}
+
+func (r *EmailChangeRepository) EmailChangeByConfirmHash(ctx context.Context, hash []byte) (model.EmailChange, error) {
+	const op = "repository.EmailChangeByConfirmHash"
+
+	return r.emailChange(ctx, op, `SELECT `+emailChangeColumns+` FROM email_changes WHERE confirm_hash = $1`, hash)
+}
+
+func (r *EmailChangeRepository) EmailChangeByCancelHash(ctx context.Context, hash []byte) (model.EmailChange, error) {
+	const op = "repository.EmailChangeByCancelHash"
+
+	return r.emailChange(ctx, op, `SELECT `+emailChangeColumns+` FROM email_changes WHERE cancel_hash = $1`, hash)
+}
+
+// ApplyEmailChange подтверждает запрос и меняет email пользователя. Если запрос уже
+// подтверждён или отменён либо email успели изменить иначе, возвращает ErrEmailChangeNotFound,
+// если новый адрес заняли — ErrUserExists.
+func (r *EmailChangeRepository) ApplyEmailChange(ctx context.Context, change model.EmailChange) error {
+	const op = "repository.ApplyEmailChange"
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	res, err := tx.Exec(ctx,
+		`UPDATE email_changes SET confirmed_at = $2
+		 WHERE id = $1 AND confirmed_at IS NULL AND canceled_at IS NULL`,
+		change.ID, time.Now().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := checkAffected(op, res, ErrEmailChangeNotFound); err != nil {
+		return err
+	}
+
+	if err := setEmail(ctx, tx, op, change.UserID, change.OldEmail, change.NewEmail); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RevertEmailChange отменяет запрос; если он уже подтверждён, возвращает старый email
+func (r *EmailChangeRepository) RevertEmailChange(ctx context.Context, change model.EmailChange) error {
+	const op = "repository.RevertEmailChange"
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var confirmedAt pgtype.Timestamp
+	err = tx.QueryRow(ctx,
+		`UPDATE email_changes SET canceled_at = $2
+		 WHERE id = $1 AND canceled_at IS NULL
+		 RETURNING confirmed_at`,
+		change.ID, time.Now().UTC(),
+	).Scan(&confirmedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("%s: %w", op, ErrEmailChangeNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if confirmedAt.Valid {
+		if err := setEmail(ctx, tx, op, change.UserID, change.NewEmail, change.OldEmail); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// setEmail меняет email пользователя с from на to, проверяя уникальность так же, как SaveUser
+func setEmail(ctx context.Context, tx pgx.Tx, op string, userID int64, from, to string) error {
+	res, err := tx.Exec(ctx,
+		`UPDATE users SET email = $3, updated_at = NOW()
+		 WHERE id = $1 AND email = $2 AND deleted_at IS NULL`,
+		userID, from, to,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%s: %w", op, ErrUserExists)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return checkAffected(op, res, ErrEmailChangeNotFound)
+}
+
+func (r *EmailChangeRepository) emailChange(ctx context.Context, op, query string, args ...any) (model.EmailChange, error) {
+	var (
+		change      model.EmailChange
+		confirmedAt pgtype.Timestamp
+		canceledAt  pgtype.Timestamp
+	)
+
+	err := r.db.QueryRow(ctx, query, args...).Scan(
+		&change.ID,
+		&change.UserID,
+		&change.OldEmail,
+		&change.NewEmail,
+		&change.ConfirmHash,
+		&change.CancelHash,
+		&change.ExpiresAt,
+		&change.CancelExpiresAt,
+		&confirmedAt,
+		&canceledAt,
+		&change.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.EmailChange{}, fmt.Errorf("%s: %w", op, ErrEmailChangeNotFound)
+		}
+		return model.EmailChange{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	change.ConfirmedAt = confirmedAt.Time
+	change.CanceledAt = canceledAt.Time
+
+	return change, nil
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"auth-service/internal/model"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type IdentityRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewIdentityRepository(db *pgxpool.Pool) *IdentityRepository {
+	return &IdentityRepository{db: db}
+}
+
+// LinkedUser возвращает id пользователя, связанного с учётной записью провайдера
+func (r *IdentityRepository) LinkedUser(ctx context.Context, provider, subject string) (int64, error) {
+	const op = "repository.LinkedUser"
+
+	query := `SELECT user_id FROM linked_identities WHERE provider = $1 AND subject = $2`
+
+	var userID int64
+	err := r.db.QueryRow(ctx, query, provider, subject).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, fmt.Errorf("%s: %w", op, ErrIdentityNotFound)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userID, nil
+}
+
+// LinkIdentity сохраняет связь. Если учётная запись уже связана
+// (параллельный первый вход), существующая связь не меняется.
+func (r *IdentityRepository) LinkIdentity(ctx context.Context, identity model.LinkedIdentity) error {
+	const op = "repository.LinkIdentity"
+
+	query := `INSERT INTO linked_identities (provider, subject, user_id, email)
+	          VALUES ($1, $2, $3, $4)
+	          ON CONFLICT (provider, subject) DO NOTHING`
+
+	_, err := r.db.Exec(ctx, query, identity.Provider, identity.Subject, identity.UserID, identity.Email)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
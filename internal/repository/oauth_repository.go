@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"auth-service/internal/model"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type OAuthRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewOAuthRepository(db *pgxpool.Pool) *OAuthRepository {
+	return &OAuthRepository{db: db}
+}
+
+func (r *OAuthRepository) SaveCode(ctx context.Context, code model.AuthorizationCode) error {
+	const op = "repository.SaveCode"
+
+	query := `INSERT INTO oauth_codes
+	              (code_hash, app_id, user_id, redirect_uri, scopes, code_challenge, nonce, auth_time, expires_at)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	_, err := r.db.Exec(ctx, query,
+		code.CodeHash,
+		code.AppID,
+		code.UserID,
+		code.RedirectURI,
+		code.Scopes,
+		code.CodeChallenge,
+		code.Nonce,
+		code.AuthTime,
+		code.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ConsumeCode удаляет код и возвращает его, поэтому код можно обменять только один раз
+func (r *OAuthRepository) ConsumeCode(ctx context.Context, codeHash []byte) (model.AuthorizationCode, error) {
+	const op = "repository.ConsumeCode"
+
+	code := model.AuthorizationCode{CodeHash: codeHash}
+	query := `DELETE FROM oauth_codes WHERE code_hash = $1
+	          RETURNING app_id, user_id, redirect_uri, scopes, code_challenge, nonce, auth_time, expires_at`
+
+	err := r.db.QueryRow(ctx, query, codeHash).Scan(
+		&code.AppID,
+		&code.UserID,
+		&code.RedirectURI,
+		&code.Scopes,
+		&code.CodeChallenge,
+		&code.Nonce,
+		&code.AuthTime,
+		&code.ExpiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.AuthorizationCode{}, fmt.Errorf("%s: %w", op, ErrCodeNotFound)
+		}
+		return model.AuthorizationCode{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return code, nil
+}
+
+// Consent возвращает scopes, на которые пользователь уже дал согласие приложению
+func (r *OAuthRepository) Consent(ctx context.Context, userID int64, appID int) ([]string, error) {
+	const op = "repository.Consent"
+
+	var scopes []string
+	query := `SELECT scopes FROM oauth_consents WHERE user_id = $1 AND app_id = $2`
+
+	err := r.db.QueryRow(ctx, query, userID, appID).Scan(&scopes)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return scopes, nil
+}
+
+// SaveConsent добавляет scopes к уже выданному согласию
+func (r *OAuthRepository) SaveConsent(ctx context.Context, userID int64, appID int, scopes []string) error {
+	const op = "repository.SaveConsent"
+
+	query := `INSERT INTO oauth_consents (user_id, app_id, scopes) VALUES ($1, $2, $3)
+	          ON CONFLICT (user_id, app_id) DO UPDATE
+	          SET scopes = ARRAY(SELECT DISTINCT unnest(oauth_consents.scopes || EXCLUDED.scopes)),
+	              updated_at = NOW()`
+
+	if _, err := r.db.Exec(ctx, query, userID, appID, scopes); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"auth-service/internal/model"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// UpdateProfile сохраняет поля профиля, которые пользователь редактирует сам
+func (r *UserRepository) UpdateProfile(ctx context.Context, user model.User) (model.User, error) {
+	const op = "repository.UpdateProfile"
+
+	ctx, span := startSpan(ctx, op)
+	defer span.End()
+
+	query := `UPDATE users
+	          SET display_name = $2, locale = $3, timezone = $4, avatar_url = $5, updated_at = NOW()
+	          WHERE id = $1 AND deleted_at IS NULL
+	          RETURNING ` + userColumns
+
+	user, err := scanUser(r.db.QueryRow(ctx, query,
+		user.ID,
+		user.DisplayName,
+		user.Locale,
+		user.Timezone,
+		user.AvatarURL,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.User{}, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+		return model.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+// AppAttributes возвращает атрибуты пользователя в приложении, пустые, если их не задавали
+func (r *UserRepository) AppAttributes(ctx context.Context, userID int64, appID int) (map[string]any, error) {
+	const op = "repository.AppAttributes"
+
+	ctx, span := startSpan(ctx, op)
+	defer span.End()
+
+	query := `SELECT attributes FROM user_app_attributes WHERE user_id = $1 AND app_id = $2`
+
+	var raw []byte
+	err := r.db.QueryRow(ctx, query, userID, appID).Scan(&raw)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return map[string]any{}, nil
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	attrs := map[string]any{}
+	if err := json.Unmarshal(raw, &attrs); err != nil {
+		return nil, fmt.Errorf("%s: unmarshal: %w", op, err)
+	}
+
+	return attrs, nil
+}
+
+// SetAppAttributes заменяет атрибуты пользователя в приложении
+func (r *UserRepository) SetAppAttributes(ctx context.Context, userID int64, appID int, attrs map[string]any) error {
+	const op = "repository.SetAppAttributes"
+
+	ctx, span := startSpan(ctx, op)
+	defer span.End()
+
+	if attrs == nil {
+		attrs = map[string]any{}
+	}
+
+	raw, err := json.Marshal(attrs)
+	if err != nil {
+		return fmt.Errorf("%s: marshal: %w", op, err)
+	}
+
+	query := `INSERT INTO user_app_attributes (user_id, app_id, attributes)
+	          VALUES ($1, $2, $3)
+	          ON CONFLICT (user_id, app_id) DO UPDATE SET attributes = EXCLUDED.attributes, updated_at = NOW()`
+
+	if _, err := r.db.Exec(ctx, query, userID, appID, raw); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
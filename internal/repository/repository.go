@@ -1,10 +1,22 @@
 package repository
 
-import "errors"
+import "auth-service/internal/apperr"
 
 var (
-	ErrUserExists         = errors.New("user already exists")
-	ErrUserNotFound       = errors.New("user not found")
-	ErrAppNotFound        = errors.New("app not found")
-	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrUserExists         = apperr.New(apperr.AlreadyExists, "USER_EXISTS", "user already exists")
+	ErrInvalidEmail       = apperr.New(apperr.InvalidArgument, "INVALID_EMAIL", "invalid email")
+	ErrUserNotFound       = apperr.New(apperr.NotFound, "USER_NOT_FOUND", "user not found")
+	ErrUserDisabled       = apperr.New(apperr.PermissionDenied, "USER_DISABLED", "user is disabled")
+	ErrUserSourceConflict = apperr.New(apperr.AlreadyExists, "USER_SOURCE_CONFLICT", "user with this email is not managed by the directory")
+	ErrCannotModifySelf   = apperr.New(apperr.FailedPrecondition, "CANNOT_MODIFY_SELF", "cannot disable, delete or demote own account")
+	ErrAppNotFound        = apperr.New(apperr.NotFound, "APP_NOT_FOUND", "app not found")
+	ErrInvalidCredentials = apperr.New(apperr.Unauthenticated, "INVALID_CREDENTIALS", "invalid credentials")
+	ErrCodeNotFound       = apperr.New(apperr.NotFound, "CODE_NOT_FOUND", "authorization code not found")
+
+	ErrEmailChangeNotFound = apperr.New(apperr.NotFound, "EMAIL_CHANGE_NOT_FOUND", "email change link is invalid or expired")
+
+	ErrAPIKeyNotFound = apperr.New(apperr.NotFound, "API_KEY_NOT_FOUND", "api key not found")
+	ErrInvalidAPIKey  = apperr.New(apperr.Unauthenticated, "INVALID_API_KEY", "invalid api key")
+
+	ErrIdentityNotFound = apperr.New(apperr.NotFound, "IDENTITY_NOT_FOUND", "linked identity not found")
 )
@@ -2,33 +2,47 @@ package repository
 
 import (
 	"auth-service/internal/model"
+	"auth-service/internal/tracing"
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
-	"github.com/lib/pq"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type UserRepository struct {
-	db *sql.DB
+	db *pgxpool.Pool
 }
 
-func NewUserRepository(db *sql.DB) *UserRepository {
+func NewUserRepository(db *pgxpool.Pool) *UserRepository {
 	return &UserRepository{db: db}
 }
 
+// startSpan открывает спан запроса к базе с именем операции репозитория
+func startSpan(ctx context.Context, op string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, op, semconv.DBSystemNamePostgreSQL)
+}
+
 func (r *UserRepository) SaveUser(ctx context.Context, email string, passHash []byte) (int64, error) {
 	const op = "repository.SaveUser"
 
+	ctx, span := startSpan(ctx, op)
+	defer span.End()
+
 	query := `INSERT INTO users (email, pass_hash) VALUES ($1, $2) RETURNING id`
 
 	var id int64
-	err := r.db.QueryRowContext(ctx, query, email, passHash).Scan(&id)
+	err := r.db.QueryRow(ctx, query, email, passHash).Scan(&id)
 	if err != nil {
 		// Проверка на уникальность email (PostgreSQL unique violation)
-		var pqErr *pq.Error
-		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		if isUniqueViolation(err) {
 			// <- возвращаем именно ErrUserExists
 			return 0, ErrUserExists
 		}
@@ -39,26 +53,73 @@ func (r *UserRepository) SaveUser(ctx context.Context, email string, passHash []
 	return id, nil
 }
 
+// SaveExternalUser создаёт пользователя без пароля, которым управляет внешний
+// источник, например теневую запись каталога LDAP
+func (r *UserRepository) SaveExternalUser(ctx context.Context, email, source string) (int64, error) {
+	const op = "repository.SaveExternalUser"
+
+	ctx, span := startSpan(ctx, op)
+	defer span.End()
+
+	query := `INSERT INTO users (email, pass_hash, source) VALUES ($1, '', $2) RETURNING id`
+
+	var id int64
+	err := r.db.QueryRow(ctx, query, email, source).Scan(&id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, ErrUserExists
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// uniqueViolation — SQLSTATE нарушения уникального индекса
+const uniqueViolation = "23505"
+
+// isUniqueViolation сообщает, что запись нарушила уникальный индекс, например занятый email
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
+
+// GetUser возвращает пользователя по email без учёта регистра; удалённые пользователи не находятся
 func (r *UserRepository) GetUser(ctx context.Context, email string) (model.User, error) {
 	const op = "repository.GetUser"
 
-	var user model.User
+	ctx, span := startSpan(ctx, op)
+	defer span.End()
+
 	// SQL-запрос для PostgreSQL
-	query := `SELECT id, email, pass_hash, is_admin, created_at, updated_at
+	query := `SELECT ` + userColumns + `
 	          FROM users
-	          WHERE email = $1`
+	          WHERE lower(email) = lower($1) AND deleted_at IS NULL`
 
-	err := r.db.QueryRowContext(ctx, query, email).Scan(
-		&user.ID,
-		&user.Email,
-		&user.PassHash,
-		&user.IsAdmin,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+	user, err := scanUser(r.db.QueryRow(ctx, query, email))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.User{}, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+		return model.User{}, fmt.Errorf("%s: %w", op, err)
+	}
 
+	return user, nil
+}
+
+func (r *UserRepository) UserByID(ctx context.Context, userID int64) (model.User, error) {
+	const op = "repository.UserByID"
+
+	ctx, span := startSpan(ctx, op)
+	defer span.End()
+
+	query := `SELECT ` + userColumns + `
+	          FROM users
+	          WHERE id = $1 AND deleted_at IS NULL`
+
+	user, err := scanUser(r.db.QueryRow(ctx, query, userID))
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return model.User{}, fmt.Errorf("%s: %w", op, ErrUserNotFound)
 		}
 		return model.User{}, fmt.Errorf("%s: %w", op, err)
@@ -67,17 +128,134 @@ func (r *UserRepository) GetUser(ctx context.Context, email string) (model.User,
 	return user, nil
 }
 
+// ListUsers возвращает до limit пользователей с id больше afterID,
+// у которых email начинается с emailPrefix без учёта регистра (пустой префикс — все)
+func (r *UserRepository) ListUsers(ctx context.Context, emailPrefix string, afterID int64, limit int) ([]model.User, error) {
+	const op = "repository.ListUsers"
+
+	ctx, span := startSpan(ctx, op)
+	defer span.End()
+
+	query := `SELECT ` + userColumns + `
+	          FROM users
+	          WHERE deleted_at IS NULL AND lower(email) LIKE lower($1) AND id > $2
+	          ORDER BY id LIMIT $3`
+
+	rows, err := r.db.Query(ctx, query, likePrefix(emailPrefix), afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var users []model.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: scan: %w", op, err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return users, nil
+}
+
+// UpdateUser сохраняет email и флаг администратора пользователя
+func (r *UserRepository) UpdateUser(ctx context.Context, user model.User, audit model.AuditEntry) (model.User, error) {
+	const op = "repository.UpdateUser"
+
+	ctx, span := startSpan(ctx, op)
+	defer span.End()
+
+	query := `UPDATE users SET email = $2, is_admin = $3, updated_at = NOW()
+	          WHERE id = $1 AND deleted_at IS NULL
+	          RETURNING ` + userColumns
+
+	err := withAudit(ctx, r.db, op, &audit, func(tx pgx.Tx) error {
+		var err error
+		user, err = scanUser(tx.QueryRow(ctx, query, user.ID, user.Email, user.IsAdmin))
+		if err != nil {
+			switch {
+			case errors.Is(err, pgx.ErrNoRows):
+				return fmt.Errorf("%s: %w", op, ErrUserNotFound)
+			case isUniqueViolation(err):
+				return fmt.Errorf("%s: %w", op, ErrUserExists)
+			}
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return model.User{}, err
+	}
+
+	return user, nil
+}
+
+// SetDisabled блокирует или разблокирует пользователя; повторная блокировка
+// не меняет время первой
+func (r *UserRepository) SetDisabled(ctx context.Context, userID int64, disabled bool, audit model.AuditEntry) error {
+	const op = "repository.SetDisabled"
+
+	ctx, span := startSpan(ctx, op)
+	defer span.End()
+
+	query := `UPDATE users
+	          SET disabled_at = CASE WHEN $2 THEN COALESCE(disabled_at, $3) END, updated_at = NOW()
+	          WHERE id = $1 AND deleted_at IS NULL`
+
+	return withAudit(ctx, r.db, op, &audit, func(tx pgx.Tx) error {
+		res, err := tx.Exec(ctx, query, userID, disabled, time.Now().UTC())
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		return checkAffected(op, res, ErrUserNotFound)
+	})
+}
+
+// DeleteUser помечает пользователя удалённым и отвязывает учётные записи внешних
+// провайдеров, чтобы следующий вход через них не попадал в удалённую запись
+func (r *UserRepository) DeleteUser(ctx context.Context, userID int64, audit model.AuditEntry) error {
+	const op = "repository.DeleteUser"
+
+	ctx, span := startSpan(ctx, op)
+	defer span.End()
+
+	return withAudit(ctx, r.db, op, &audit, func(tx pgx.Tx) error {
+		res, err := tx.Exec(ctx,
+			`UPDATE users SET deleted_at = $2, updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL`,
+			userID, time.Now().UTC(),
+		)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if err := checkAffected(op, res, ErrUserNotFound); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(ctx, `DELETE FROM linked_identities WHERE user_id = $1`, userID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		return nil
+	})
+}
+
 func (r *UserRepository) IsAdmin(ctx context.Context, userID int64) (bool, error) {
 	const op = "repository.IsAdmin"
 
+	ctx, span := startSpan(ctx, op)
+	defer span.End()
+
 	var isAdmin bool
 	// SQL-запрос для PostgreSQL
-	query := `SELECT is_admin FROM users WHERE id = $1`
+	query := `SELECT is_admin FROM users WHERE id = $1 AND deleted_at IS NULL`
 
-	err := r.db.QueryRowContext(ctx, query, userID).Scan(&isAdmin)
+	err := r.db.QueryRow(ctx, query, userID).Scan(&isAdmin)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return false, fmt.Errorf("%s: user not found: %w", op, ErrAppNotFound)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, fmt.Errorf("%s: %w", op, ErrUserNotFound)
 		}
 		return false, fmt.Errorf("%s: query error: %w", op, err)
 	}
@@ -88,16 +266,26 @@ func (r *UserRepository) IsAdmin(ctx context.Context, userID int64) (bool, error
 func (r *UserRepository) App(ctx context.Context, appID int) (model.App, error) {
 	const op = "repository.App"
 
+	ctx, span := startSpan(ctx, op)
+	defer span.End()
+
 	var app model.App
-	query := `SELECT id, name, secret FROM apps WHERE id = $1`
+	query := `SELECT id, name, secret_hash, secret_enc, secret_mode, scopes, redirect_uris, authenticators, public
+	          FROM apps WHERE id = $1`
 
-	err := r.db.QueryRowContext(ctx, query, appID).Scan(
+	err := r.db.QueryRow(ctx, query, appID).Scan(
 		&app.ID,
 		&app.Name,
-		&app.Secret,
+		&app.SecretHash,
+		&app.SecretEnc,
+		&app.SecretMode,
+		&app.Scopes,
+		&app.RedirectURIs,
+		&app.Authenticators,
+		&app.Public,
 	)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if err == pgx.ErrNoRows {
 			return model.App{}, fmt.Errorf("%s: app not found: %w", op, ErrAppNotFound)
 		}
 		return model.App{}, fmt.Errorf("%s: query error: %w", op, err)
@@ -105,3 +293,59 @@ func (r *UserRepository) App(ctx context.Context, appID int) (model.App, error)
 
 	return app, nil
 }
+
+// SetAdmin меняет флаг администратора, например по группам каталога LDAP
+func (r *UserRepository) SetAdmin(ctx context.Context, userID int64, isAdmin bool) error {
+	const op = "repository.SetAdmin"
+
+	ctx, span := startSpan(ctx, op)
+	defer span.End()
+
+	query := `UPDATE users SET is_admin = $2, updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+
+	res, err := r.db.Exec(ctx, query, userID, isAdmin)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return checkAffected(op, res, ErrUserNotFound)
+}
+
+const userColumns = `id, email, pass_hash, is_admin, created_at, updated_at, disabled_at, source,
+	          display_name, locale, timezone, avatar_url`
+
+func scanUser(row scanner) (model.User, error) {
+	var (
+		user       model.User
+		disabledAt pgtype.Timestamp
+	)
+
+	err := row.Scan(
+		&user.ID,
+		&user.Email,
+		&user.PassHash,
+		&user.IsAdmin,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&disabledAt,
+		&user.Source,
+		&user.DisplayName,
+		&user.Locale,
+		&user.Timezone,
+		&user.AvatarURL,
+	)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	user.DisabledAt = disabledAt.Time
+
+	return user, nil
+}
+
+// likePrefix строит шаблон LIKE для поиска по префиксу, экранируя спецсимволы
+func likePrefix(prefix string) string {
+	return likeEscaper.Replace(prefix) + "%"
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
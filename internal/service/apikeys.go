@@ -0,0 +1,302 @@
+package service
+
+import (
+	"auth-service/internal/appsecret"
+	"auth-service/internal/dynamic"
+	"auth-service/internal/jwt"
+	"auth-service/internal/logger/sl"
+	"auth-service/internal/metrics"
+	"auth-service/internal/model"
+	"auth-service/internal/random"
+	"auth-service/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// ключ имеет вид ak_<prefix>.<secret>; prefix хранится открыто и ищется по индексу
+	apiKeyMarker    = "ak_"
+	apiKeyPrefixLen = 6
+	apiKeySecretLen = 32
+)
+
+type APIKeyStore interface {
+	// изменения записывают audit в журнал аудита в той же транзакции
+	CreateAPIKey(ctx context.Context, key model.APIKey, audit model.AuditEntry) (model.APIKey, error)
+	APIKey(ctx context.Context, id int64) (model.APIKey, error)
+	APIKeyByPrefix(ctx context.Context, prefix string) (model.APIKey, error)
+	ListUserAPIKeys(ctx context.Context, userID, afterID int64, limit int) ([]model.APIKey, error)
+	ListServiceAPIKeys(ctx context.Context, appID int, afterID int64, limit int) ([]model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int64, audit model.AuditEntry) error
+	TouchAPIKey(ctx context.Context, id int64, usedAt time.Time) error
+}
+
+// APIKeys управляет API ключами и обменивает их на короткие JWT
+type APIKeys struct {
+	log         *slog.Logger
+	keys        APIKeyStore
+	users       UserByIDProvider
+	appProvider AppProvider
+	jwtSecret   string
+	tokenTTL    *dynamic.Duration
+	keyTTL      *dynamic.Duration
+}
+
+// NewAPIKeys returns a new instance of the APIKeys service.
+// tokenTTL — время жизни JWT после обмена, keyTTL — срок ключа по умолчанию.
+func NewAPIKeys(
+	log *slog.Logger,
+	keys APIKeyStore,
+	users UserByIDProvider,
+	appProvider AppProvider,
+	jwtSecret string,
+	tokenTTL *dynamic.Duration,
+	keyTTL *dynamic.Duration,
+) *APIKeys {
+	return &APIKeys{
+		log:         log,
+		keys:        keys,
+		users:       users,
+		appProvider: appProvider,
+		jwtSecret:   jwtSecret,
+		tokenTTL:    tokenTTL,
+		keyTTL:      keyTTL,
+	}
+}
+
+// CreateAPIKey создаёт ключ key.Name для приложения key.AppID. Если key.UserID == 0,
+// это ключ сервисного аккаунта: его scopes ограничены scopes приложения, а пустой
+// список означает все scopes приложения. Полный ключ возвращается только здесь.
+func (s *APIKeys) CreateAPIKey(ctx context.Context, actorID int64, key model.APIKey, ttl time.Duration) (model.APIKey, string, error) {
+	const op = "apikeys.CreateAPIKey"
+
+	log := sl.FromContext(ctx, s.log).With(
+		slog.String("op", op),
+		slog.Int64("actor_id", actorID),
+		slog.Int("app_id", key.AppID),
+	)
+
+	app, err := s.appProvider.App(ctx, key.AppID)
+	if err != nil {
+		return model.APIKey{}, "", fmt.Errorf("%s:%w", op, err)
+	}
+
+	if key.IsServiceAccount() {
+		if len(key.Scopes) == 0 {
+			key.Scopes = app.Scopes
+		}
+		for _, scope := range key.Scopes {
+			if !slices.Contains(app.Scopes, scope) {
+				log.Warn("scope is not granted to app", slog.String("scope", scope))
+				return model.APIKey{}, "", fmt.Errorf("%s:%w", op, ErrInvalidScope)
+			}
+		}
+	} else if _, err := s.users.UserByID(ctx, key.UserID); err != nil {
+		return model.APIKey{}, "", fmt.Errorf("%s:%w", op, err)
+	}
+
+	if ttl <= 0 {
+		ttl = s.keyTTL.Get()
+	}
+	key.ExpiresAt = time.Now().Add(ttl)
+
+	prefix, err := random.Token(apiKeyPrefixLen)
+	if err != nil {
+		return model.APIKey{}, "", fmt.Errorf("%s:%w", op, err)
+	}
+	secret, err := random.Token(apiKeySecretLen)
+	if err != nil {
+		return model.APIKey{}, "", fmt.Errorf("%s:%w", op, err)
+	}
+
+	raw := apiKeyMarker + prefix + "." + secret
+	key.Prefix = prefix
+	key.SecretHash = appsecret.Hash(raw)
+
+	// id ключа в записи журнала заполняет репозиторий
+	key, err = s.keys.CreateAPIKey(ctx, key, apiKeyAudit(actorID, model.AuditAPIKeyCreate, key))
+	if err != nil {
+		log.Error("failed to create api key", sl.Err(err))
+		return model.APIKey{}, "", fmt.Errorf("%s:%w", op, err)
+	}
+
+	log.Info("api key created", slog.Int64("key_id", key.ID), slog.Bool("service_account", key.IsServiceAccount()))
+
+	return key, raw, nil
+}
+
+// ListUserAPIKeys возвращает страницу личных ключей пользователя после afterID
+func (s *APIKeys) ListUserAPIKeys(ctx context.Context, userID, afterID int64, limit int) ([]model.APIKey, error) {
+	const op = "apikeys.ListUserAPIKeys"
+
+	keys, err := s.keys.ListUserAPIKeys(ctx, userID, afterID, limit)
+	if err != nil {
+		sl.FromContext(ctx, s.log).Error("failed to list api keys", slog.String("op", op), sl.Err(err))
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+
+	return keys, nil
+}
+
+// ListServiceAPIKeys возвращает страницу ключей сервисного аккаунта приложения после afterID
+func (s *APIKeys) ListServiceAPIKeys(ctx context.Context, appID int, afterID int64, limit int) ([]model.APIKey, error) {
+	const op = "apikeys.ListServiceAPIKeys"
+
+	keys, err := s.keys.ListServiceAPIKeys(ctx, appID, afterID, limit)
+	if err != nil {
+		sl.FromContext(ctx, s.log).Error("failed to list api keys", slog.String("op", op), sl.Err(err))
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey отзывает ключ. Личный ключ может отозвать владелец или администратор,
+// ключ сервисного аккаунта — только администратор. Чужие ключи выглядят как
+// несуществующие, чтобы не раскрывать их id.
+func (s *APIKeys) RevokeAPIKey(ctx context.Context, actorID int64, actorIsAdmin bool, keyID int64) error {
+	const op = "apikeys.RevokeAPIKey"
+
+	log := sl.FromContext(ctx, s.log).With(
+		slog.String("op", op),
+		slog.Int64("actor_id", actorID),
+		slog.Int64("key_id", keyID),
+	)
+
+	key, err := s.keys.APIKey(ctx, keyID)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	if !actorIsAdmin && (key.IsServiceAccount() || key.UserID != actorID) {
+		log.Warn("api key belongs to another owner")
+		return fmt.Errorf("%s:%w", op, repository.ErrAPIKeyNotFound)
+	}
+
+	if err := s.keys.RevokeAPIKey(ctx, keyID, apiKeyAudit(actorID, model.AuditAPIKeyRevoke, key)); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	log.Info("api key revoked")
+
+	return nil
+}
+
+// ExchangeAPIKey проверяет ключ и выдаёт JWT: для личного ключа — токен пользователя,
+// как Login, для ключа сервисного аккаунта — машинный токен приложения
+func (s *APIKeys) ExchangeAPIKey(ctx context.Context, raw string) (string, []string, error) {
+	const op = "apikeys.ExchangeAPIKey"
+
+	prefix, ok := parseAPIKey(raw)
+	if !ok {
+		return "", nil, fmt.Errorf("%s:%w", op, repository.ErrInvalidAPIKey)
+	}
+
+	log := sl.FromContext(ctx, s.log).With(
+		slog.String("op", op),
+		slog.String("prefix", prefix),
+	)
+
+	key, err := s.keys.APIKeyByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, repository.ErrAPIKeyNotFound) {
+			log.Warn("api key not found")
+			return "", nil, fmt.Errorf("%s:%w", op, repository.ErrInvalidAPIKey)
+		}
+		return "", nil, fmt.Errorf("%s:%w", op, err)
+	}
+
+	now := time.Now()
+	switch {
+	case !appsecret.Verify(key.SecretHash, raw):
+		log.Warn("api key secret mismatch")
+		return "", nil, fmt.Errorf("%s:%w", op, repository.ErrInvalidAPIKey)
+	case !key.RevokedAt.IsZero():
+		log.Warn("api key is revoked", slog.Int64("key_id", key.ID))
+		return "", nil, fmt.Errorf("%s:%w", op, repository.ErrInvalidAPIKey)
+	case now.After(key.ExpiresAt):
+		log.Warn("api key is expired", slog.Int64("key_id", key.ID))
+		return "", nil, fmt.Errorf("%s:%w", op, repository.ErrInvalidAPIKey)
+	}
+
+	app, err := s.appProvider.App(ctx, key.AppID)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s:%w", op, err)
+	}
+
+	var token string
+	kind := metrics.TokenClient
+	if key.IsServiceAccount() {
+		token, err = jwt.NewClientToken(app, key.Scopes, s.jwtSecret, s.tokenTTL.Get())
+	} else {
+		kind = metrics.TokenAccess
+
+		var user model.User
+		user, err = s.users.UserByID(ctx, key.UserID)
+		if err != nil {
+			// ключи удалённого пользователя перестают работать
+			if errors.Is(err, repository.ErrUserNotFound) {
+				log.Warn("api key owner not found", slog.Int64("key_id", key.ID))
+				return "", nil, fmt.Errorf("%s:%w", op, repository.ErrInvalidAPIKey)
+			}
+			return "", nil, fmt.Errorf("%s:%w", op, err)
+		}
+		if user.Disabled() {
+			log.Warn("api key owner is disabled", slog.Int64("key_id", key.ID))
+			return "", nil, fmt.Errorf("%s:%w", op, repository.ErrUserDisabled)
+		}
+		token, err = jwt.NewAPIKeyToken(user, app, key.ID, key.Scopes, s.jwtSecret, s.tokenTTL.Get())
+	}
+	if err != nil {
+		log.Error("failed to sign token", sl.Err(err))
+		return "", nil, fmt.Errorf("%s:%w", op, err)
+	}
+	metrics.TokenIssued(kind)
+
+	// время использования — вспомогательная информация, обмен из-за него не проваливаем
+	if err := s.keys.TouchAPIKey(ctx, key.ID, now); err != nil {
+		log.Error("failed to update last_used_at", sl.Err(err))
+	}
+
+	log.Info("api key exchanged", slog.Int64("key_id", key.ID))
+
+	return token, key.Scopes, nil
+}
+
+// parseAPIKey достаёт открытую часть из ключа ak_<prefix>.<secret>
+func parseAPIKey(raw string) (string, bool) {
+	rest, ok := strings.CutPrefix(raw, apiKeyMarker)
+	if !ok {
+		return "", false
+	}
+
+	prefix, secret, ok := strings.Cut(rest, ".")
+	if !ok || prefix == "" || secret == "" {
+		return "", false
+	}
+
+	return prefix, true
+}
+
+// apiKeyAudit — запись журнала аудита об изменении ключа
+func apiKeyAudit(actorID int64, action string, key model.APIKey) model.AuditEntry {
+	return model.AuditEntry{
+		ActorID:  actorID,
+		Action:   action,
+		Entity:   "api_key",
+		EntityID: strconv.FormatInt(key.ID, 10),
+		Details: map[string]any{
+			"name":    key.Name,
+			"prefix":  key.Prefix,
+			"app_id":  key.AppID,
+			"user_id": key.UserID,
+			"scopes":  key.Scopes,
+		},
+	}
+}
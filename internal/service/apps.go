@@ -0,0 +1,215 @@
+package service
+
+import (
+	"auth-service/internal/appsecret"
+	"auth-service/internal/logger/sl"
+	"auth-service/internal/model"
+	"auth-service/internal/random"
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+)
+
+// длина секрета приложения в байтах до кодирования
+const appSecretLen = 32
+
+type AppManager interface {
+	// изменения записывают audit в журнал аудита в той же транзакции
+	CreateApp(ctx context.Context, app model.App, audit model.AuditEntry) (model.App, error)
+	UpdateApp(ctx context.Context, app model.App, audit model.AuditEntry) (model.App, error)
+	ListApps(ctx context.Context, afterID, limit int) ([]model.App, error)
+	SecretMode(ctx context.Context, appID int) (string, error)
+	UpdateAppSecret(ctx context.Context, appID int, secretHash, secretEnc []byte, audit model.AuditEntry) error
+	DeleteApp(ctx context.Context, appID int, audit model.AuditEntry) error
+}
+
+type Apps struct {
+	log      *slog.Logger
+	apps     AppManager
+	envelope *appsecret.Envelope
+}
+
+// NewApps returns a new instance of the Apps service.
+func NewApps(log *slog.Logger, apps AppManager, envelope *appsecret.Envelope) *Apps {
+	return &Apps{
+		log:      log,
+		apps:     apps,
+		envelope: envelope,
+	}
+}
+
+// CreateApp создаёт приложение и возвращает сгенерированный секрет.
+// Секрет больше нигде не отдаётся, поэтому клиент должен сохранить его сразу.
+func (a *Apps) CreateApp(ctx context.Context, actorID int64, app model.App) (model.App, string, error) {
+	const op = "apps.CreateApp"
+
+	log := sl.FromContext(ctx, a.log).With(
+		slog.String("op", op),
+		slog.Int64("actor_id", actorID),
+	)
+
+	if app.SecretMode == "" {
+		app.SecretMode = model.AppSecretAuthOnly
+	}
+
+	secret, secretEnc, err := a.newSecret(app.SecretMode)
+	if err != nil {
+		log.Error("failed to generate secret", sl.Err(err))
+		return model.App{}, "", fmt.Errorf("%s:%w", op, err)
+	}
+
+	app.SecretHash = appsecret.Hash(secret)
+	app.SecretEnc = secretEnc
+	app.Authenticators = defaultAuthenticators(app.Authenticators)
+
+	// id приложения в записи журнала заполняет репозиторий
+	app, err = a.apps.CreateApp(ctx, app, appAudit(actorID, model.AuditAppCreate, 0, auditDetails(app)))
+	if err != nil {
+		log.Error("failed to create app", sl.Err(err))
+		return model.App{}, "", fmt.Errorf("%s:%w", op, err)
+	}
+
+	log.Info("app created", slog.Int("app_id", app.ID))
+
+	return app, secret, nil
+}
+
+// UpdateApp заменяет имя, scopes, redirect_uri, источники учётных данных и тип клиента приложения app.ID
+func (a *Apps) UpdateApp(ctx context.Context, actorID int64, app model.App) (model.App, error) {
+	const op = "apps.UpdateApp"
+
+	log := sl.FromContext(ctx, a.log).With(
+		slog.String("op", op),
+		slog.Int64("actor_id", actorID),
+		slog.Int("app_id", app.ID),
+	)
+
+	app.Authenticators = defaultAuthenticators(app.Authenticators)
+
+	app, err := a.apps.UpdateApp(ctx, app, appAudit(actorID, model.AuditAppUpdate, app.ID, auditDetails(app)))
+	if err != nil {
+		log.Warn("failed to update app", sl.Err(err))
+		return model.App{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	log.Info("app updated")
+
+	return app, nil
+}
+
+// ListApps возвращает страницу приложений после afterID, секреты не заполняются
+func (a *Apps) ListApps(ctx context.Context, afterID, limit int) ([]model.App, error) {
+	const op = "apps.ListApps"
+
+	apps, err := a.apps.ListApps(ctx, afterID, limit)
+	if err != nil {
+		sl.FromContext(ctx, a.log).Error("failed to list apps", slog.String("op", op), sl.Err(err))
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+
+	return apps, nil
+}
+
+// RotateAppSecret заменяет секрет приложения новым и возвращает его один раз
+func (a *Apps) RotateAppSecret(ctx context.Context, actorID int64, appID int) (string, error) {
+	const op = "apps.RotateAppSecret"
+
+	log := sl.FromContext(ctx, a.log).With(
+		slog.String("op", op),
+		slog.Int64("actor_id", actorID),
+		slog.Int("app_id", appID),
+	)
+
+	mode, err := a.apps.SecretMode(ctx, appID)
+	if err != nil {
+		log.Warn("failed to get app secret mode", sl.Err(err))
+		return "", fmt.Errorf("%s:%w", op, err)
+	}
+
+	secret, secretEnc, err := a.newSecret(mode)
+	if err != nil {
+		log.Error("failed to generate secret", sl.Err(err))
+		return "", fmt.Errorf("%s:%w", op, err)
+	}
+
+	audit := appAudit(actorID, model.AuditAppSecretRotate, appID, nil)
+	if err := a.apps.UpdateAppSecret(ctx, appID, appsecret.Hash(secret), secretEnc, audit); err != nil {
+		log.Warn("failed to rotate app secret", sl.Err(err))
+		return "", fmt.Errorf("%s:%w", op, err)
+	}
+
+	log.Info("app secret rotated")
+
+	return secret, nil
+}
+
+func (a *Apps) DeleteApp(ctx context.Context, actorID int64, appID int) error {
+	const op = "apps.DeleteApp"
+
+	log := sl.FromContext(ctx, a.log).With(
+		slog.String("op", op),
+		slog.Int64("actor_id", actorID),
+		slog.Int("app_id", appID),
+	)
+
+	if err := a.apps.DeleteApp(ctx, appID, appAudit(actorID, model.AuditAppDelete, appID, nil)); err != nil {
+		log.Warn("failed to delete app", sl.Err(err))
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	log.Info("app deleted")
+
+	return nil
+}
+
+// newSecret генерирует секрет. Зашифрованная копия возвращается только в режиме
+// AppSecretSigning: секрет, которым лишь аутентифицируются, не должен быть восстановим.
+func (a *Apps) newSecret(mode string) (string, []byte, error) {
+	secret, err := random.Token(appSecretLen)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if mode != model.AppSecretSigning {
+		return secret, nil, nil
+	}
+
+	secretEnc, err := a.envelope.Seal(secret)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return secret, secretEnc, nil
+}
+
+// defaultAuthenticators — без явного списка пользователи входят по локальному паролю
+func defaultAuthenticators(authenticators []string) []string {
+	if len(authenticators) == 0 {
+		return []string{model.AuthenticatorLocal}
+	}
+	return authenticators
+}
+
+// auditDetails возвращает изменяемые поля приложения без секретов
+func auditDetails(app model.App) map[string]any {
+	return map[string]any{
+		"name":           app.Name,
+		"scopes":         app.Scopes,
+		"redirect_uris":  app.RedirectURIs,
+		"authenticators": app.Authenticators,
+		"secret_mode":    app.SecretMode,
+		"public":         app.Public,
+	}
+}
+
+// appAudit — запись журнала аудита об изменении приложения
+func appAudit(actorID int64, action string, appID int, details map[string]any) model.AuditEntry {
+	return model.AuditEntry{
+		ActorID:  actorID,
+		Action:   action,
+		Entity:   "app",
+		EntityID: strconv.Itoa(appID),
+		Details:  details,
+	}
+}
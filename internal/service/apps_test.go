@@ -0,0 +1,166 @@
+package service_test
+
+import (
+	"auth-service/internal/appsecret"
+	"auth-service/internal/model"
+	"auth-service/internal/repository"
+	"auth-service/internal/service"
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newApps(t *testing.T) (*service.Apps, *memApps, *appsecret.Envelope) {
+	t.Helper()
+
+	envelope, err := appsecret.NewEnvelope(bytes.Repeat([]byte{7}, 32))
+	require.NoError(t, err)
+
+	apps := &memApps{byID: make(map[int]model.App)}
+	svc := service.NewApps(slog.New(slog.NewTextHandler(io.Discard, nil)), apps, envelope)
+
+	return svc, apps, envelope
+}
+
+func TestApps_AuthOnlySecretIsNotRecoverable(t *testing.T) {
+	ctx := context.Background()
+	svc, apps, _ := newApps(t)
+
+	app, secret, err := svc.CreateApp(ctx, 1, model.App{Name: "api"})
+	require.NoError(t, err)
+	assert.Equal(t, model.AppSecretAuthOnly, app.SecretMode)
+
+	stored := apps.byID[app.ID]
+	assert.Nil(t, stored.SecretEnc)
+	assert.True(t, appsecret.Verify(stored.SecretHash, secret))
+
+	// новый секрет тоже хранится только хешем
+	secret, err = svc.RotateAppSecret(ctx, 1, app.ID)
+	require.NoError(t, err)
+
+	stored = apps.byID[app.ID]
+	assert.Nil(t, stored.SecretEnc)
+	assert.True(t, appsecret.Verify(stored.SecretHash, secret))
+}
+
+func TestApps_SigningSecretIsEncrypted(t *testing.T) {
+	ctx := context.Background()
+	svc, apps, envelope := newApps(t)
+
+	app, secret, err := svc.CreateApp(ctx, 1, model.App{Name: "signer", SecretMode: model.AppSecretSigning})
+	require.NoError(t, err)
+
+	opened, err := envelope.Open(apps.byID[app.ID].SecretEnc)
+	require.NoError(t, err)
+	assert.Equal(t, secret, opened)
+
+	secret, err = svc.RotateAppSecret(ctx, 1, app.ID)
+	require.NoError(t, err)
+
+	opened, err = envelope.Open(apps.byID[app.ID].SecretEnc)
+	require.NoError(t, err)
+	assert.Equal(t, secret, opened)
+}
+
+func TestApps_ChangesCarryAuditEntries(t *testing.T) {
+	ctx := context.Background()
+	svc, apps, _ := newApps(t)
+
+	app, _, err := svc.CreateApp(ctx, 1, model.App{Name: "api"})
+	require.NoError(t, err)
+	_, err = svc.RotateAppSecret(ctx, 1, app.ID)
+	require.NoError(t, err)
+	require.NoError(t, svc.DeleteApp(ctx, 1, app.ID))
+
+	require.Len(t, apps.audit, 3)
+	for i, action := range []string{model.AuditAppCreate, model.AuditAppSecretRotate, model.AuditAppDelete} {
+		assert.Equal(t, model.AuditEntry{
+			ActorID:  1,
+			Action:   action,
+			Entity:   "app",
+			EntityID: strconv.Itoa(app.ID),
+			Details:  apps.audit[i].Details,
+		}, apps.audit[i])
+	}
+	assert.Equal(t, "api", apps.audit[0].Details["name"])
+}
+
+type memApps struct {
+	mu   sync.Mutex
+	byID map[int]model.App
+	// записи журнала аудита, переданные вместе с изменениями
+	audit []model.AuditEntry
+}
+
+func (m *memApps) CreateApp(_ context.Context, app model.App, audit model.AuditEntry) (model.App, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	app.ID = len(m.byID) + 1
+	m.byID[app.ID] = app
+
+	audit.EntityID = strconv.Itoa(app.ID)
+	m.audit = append(m.audit, audit)
+
+	return app, nil
+}
+
+func (m *memApps) UpdateApp(_ context.Context, app model.App, _ model.AuditEntry) (model.App, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.byID[app.ID]
+	if !ok {
+		return model.App{}, repository.ErrAppNotFound
+	}
+	stored.Name, stored.Scopes, stored.RedirectURIs, stored.Authenticators = app.Name, app.Scopes, app.RedirectURIs, app.Authenticators
+	m.byID[app.ID] = stored
+
+	return stored, nil
+}
+
+func (m *memApps) ListApps(context.Context, int, int) ([]model.App, error) {
+	return nil, nil
+}
+
+func (m *memApps) SecretMode(_ context.Context, appID int) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	app, ok := m.byID[appID]
+	if !ok {
+		return "", repository.ErrAppNotFound
+	}
+	return app.SecretMode, nil
+}
+
+func (m *memApps) UpdateAppSecret(_ context.Context, appID int, secretHash, secretEnc []byte, audit model.AuditEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	app, ok := m.byID[appID]
+	if !ok {
+		return repository.ErrAppNotFound
+	}
+	app.SecretHash, app.SecretEnc = secretHash, secretEnc
+	m.byID[appID] = app
+	m.audit = append(m.audit, audit)
+
+	return nil
+}
+
+func (m *memApps) DeleteApp(_ context.Context, appID int, audit model.AuditEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.byID, appID)
+	m.audit = append(m.audit, audit)
+	return nil
+}
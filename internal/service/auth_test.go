@@ -0,0 +1,289 @@
+package service_test
+
+import (
+	"auth-service/config"
+	"auth-service/internal/dynamic"
+	"auth-service/internal/emailaddr"
+	"auth-service/internal/jwt"
+	"auth-service/internal/ldapauth"
+	"auth-service/internal/ldapauth/ldaptest"
+	"auth-service/internal/model"
+	"auth-service/internal/repository"
+	"auth-service/internal/service"
+	"auth-service/internal/tracing"
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	localApp    = 1
+	ldapApp     = 2
+	ldapOrLocal = 3
+)
+
+func newLDAPAuth(t *testing.T, users *memUsers) *service.Auth {
+	t.Helper()
+
+	srv := ldaptest.NewServer(t, ldaptest.Entry{
+		DN:       "uid=alice,ou=people,dc=example,dc=com",
+		Password: "alice-pass",
+		Attributes: map[string][]string{
+			"mail":     {"alice@example.com"},
+			"memberOf": {"cn=admins,ou=groups,dc=example,dc=com"},
+		},
+	})
+
+	return newAuthWithLDAP(users, config.LDAPConfig{
+		URL:            srv.URL,
+		BaseDN:         "dc=example,dc=com",
+		UserFilter:     "(mail=%s)",
+		EmailAttribute: "mail",
+		GroupAttribute: "memberOf",
+		GroupRoles:     config.LDAPGroupRoles{"cn=admins,ou=groups,dc=example,dc=com": service.RoleAdmin},
+		Timeout:        time.Second,
+	})
+}
+
+func newAuthWithLDAP(users *memUsers, cfg config.LDAPConfig) *service.Auth {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	return service.New(
+		log,
+		users,
+		users,
+		apps{
+			localApp:    {ID: localApp, Authenticators: []string{model.AuthenticatorLocal}},
+			ldapApp:     {ID: ldapApp, Authenticators: []string{model.AuthenticatorLDAP}},
+			ldapOrLocal: {ID: ldapOrLocal, Authenticators: []string{model.AuthenticatorLDAP, model.AuthenticatorLocal}},
+		},
+		map[string]service.Authenticator{
+			model.AuthenticatorLDAP: service.NewLDAPAuthenticator(log, ldapauth.New(cfg), users, emailaddr.Normalizer{FoldLocalPart: true}, len(cfg.GroupRoles) > 0),
+		},
+		emailaddr.Normalizer{FoldLocalPart: true},
+		dynamic.NewDuration(time.Hour),
+		jwtSecret,
+	)
+}
+
+func TestLogin_LDAPShadowsUserAndMapsRoles(t *testing.T) {
+	users := newMemUsers()
+	auth := newLDAPAuth(t, users)
+
+	token, err := auth.Login(context.Background(), "alice@example.com", "alice-pass", ldapApp)
+	require.NoError(t, err)
+
+	claims, err := jwt.ParseToken(token, jwtSecret)
+	require.NoError(t, err)
+	require.NotZero(t, claims.UserID)
+
+	// теневая запись с тем же user_id при повторном входе, роль admin из группы
+	shadow, err := users.UserByID(context.Background(), claims.UserID)
+	require.NoError(t, err)
+	assert.Equal(t, "alice@example.com", shadow.Email)
+	assert.True(t, shadow.IsAdmin)
+
+	token, err = auth.Login(context.Background(), "alice@example.com", "alice-pass", ldapApp)
+	require.NoError(t, err)
+	again, err := jwt.ParseToken(token, jwtSecret)
+	require.NoError(t, err)
+	assert.Equal(t, claims.UserID, again.UserID)
+	assert.Len(t, users.byID, 1)
+
+	// у теневой записи нет локального пароля
+	_, err = auth.Login(context.Background(), "alice@example.com", "", localApp)
+	require.ErrorIs(t, err, repository.ErrInvalidCredentials)
+}
+
+func TestLogin_ChainFallsBackToLocal(t *testing.T) {
+	users := newMemUsers()
+	auth := newLDAPAuth(t, users)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("bob-pass"), bcrypt.MinCost)
+	require.NoError(t, err)
+	bobID, err := users.SaveUser(context.Background(), "bob@example.com", hash)
+	require.NoError(t, err)
+
+	token, err := auth.Login(context.Background(), "bob@example.com", "bob-pass", ldapOrLocal)
+	require.NoError(t, err)
+	claims, err := jwt.ParseToken(token, jwtSecret)
+	require.NoError(t, err)
+	assert.Equal(t, bobID, claims.UserID)
+
+	// приложение только с LDAP не принимает локальные пароли
+	_, err = auth.Login(context.Background(), "bob@example.com", "bob-pass", ldapApp)
+	require.ErrorIs(t, err, repository.ErrInvalidCredentials)
+
+	// а приложение только с локальными паролями — пароли каталога
+	_, err = auth.Login(context.Background(), "alice@example.com", "alice-pass", localApp)
+	require.ErrorIs(t, err, repository.ErrInvalidCredentials)
+}
+
+func TestLogin_LDAPRefusesLocalUser(t *testing.T) {
+	users := newMemUsers()
+	auth := newLDAPAuth(t, users)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("local-pass"), bcrypt.MinCost)
+	require.NoError(t, err)
+	localID, err := users.SaveUser(context.Background(), "Alice@Example.com", hash)
+	require.NoError(t, err)
+
+	// пароль каталога не открывает локальную учётную запись с тем же email
+	_, err = auth.Login(context.Background(), "alice@example.com", "alice-pass", ldapApp)
+	require.ErrorIs(t, err, repository.ErrUserSourceConflict)
+
+	// и не делает её администратором по группам каталога
+	local, err := users.UserByID(context.Background(), localID)
+	require.NoError(t, err)
+	assert.False(t, local.IsAdmin)
+	assert.Len(t, users.byID, 1)
+
+	// локальный пароль по-прежнему работает
+	_, err = auth.Login(context.Background(), "alice@example.com", "local-pass", localApp)
+	require.NoError(t, err)
+}
+
+func TestLogin_LDAPUnavailable(t *testing.T) {
+	users := newMemUsers()
+	auth := newAuthWithLDAP(users, config.LDAPConfig{
+		URL:     "ldap://127.0.0.1:1",
+		Timeout: time.Second,
+	})
+
+	_, err := auth.Login(context.Background(), "alice@example.com", "alice-pass", ldapOrLocal)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, repository.ErrInvalidCredentials)
+}
+
+func TestLogin_DisabledUser(t *testing.T) {
+	users := newMemUsers()
+	auth := newLDAPAuth(t, users)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("bob-pass"), bcrypt.MinCost)
+	require.NoError(t, err)
+	bobID, err := users.SaveUser(context.Background(), "bob@example.com", hash)
+	require.NoError(t, err)
+
+	_, err = auth.Login(context.Background(), "alice@example.com", "alice-pass", ldapApp)
+	require.NoError(t, err)
+	alice, err := users.GetUser(context.Background(), "alice@example.com")
+	require.NoError(t, err)
+
+	users.disable(bobID)
+	users.disable(alice.ID)
+
+	// блокировка действует для любого источника учётных данных
+	_, err = auth.Login(context.Background(), "bob@example.com", "bob-pass", localApp)
+	require.ErrorIs(t, err, repository.ErrUserDisabled)
+	_, err = auth.Login(context.Background(), "alice@example.com", "alice-pass", ldapApp)
+	require.ErrorIs(t, err, repository.ErrUserDisabled)
+
+	// неверный пароль не раскрывает, что пользователь заблокирован
+	_, err = auth.Login(context.Background(), "bob@example.com", "wrong", localApp)
+	require.ErrorIs(t, err, repository.ErrInvalidCredentials)
+}
+
+func TestRegister_NormalizesEmail(t *testing.T) {
+	users := newMemUsers()
+	auth := newLDAPAuth(t, users)
+
+	id, err := auth.Register(context.Background(), "  Bob@Example.COM ", "bob-pass")
+	require.NoError(t, err)
+
+	user, err := users.UserByID(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, "bob@example.com", user.Email)
+
+	// другой регистр — та же учётная запись
+	_, err = auth.Register(context.Background(), "BOB@example.com", "other-pass")
+	require.ErrorIs(t, err, repository.ErrUserExists)
+
+	token, err := auth.Login(context.Background(), "Bob@EXAMPLE.com", "bob-pass", localApp)
+	require.NoError(t, err)
+	claims, err := jwt.ParseToken(token, jwtSecret)
+	require.NoError(t, err)
+	assert.Equal(t, id, claims.UserID)
+
+	_, err = auth.Register(context.Background(), "bob", "bob-pass")
+	require.ErrorIs(t, err, repository.ErrInvalidEmail)
+}
+
+func TestIsAdmin_UnknownUser(t *testing.T) {
+	users := newMemUsers()
+	auth := newLDAPAuth(t, users)
+
+	_, err := auth.IsAdmin(context.Background(), 42)
+	require.ErrorIs(t, err, repository.ErrUserNotFound)
+}
+
+func (m *memUsers) IsAdmin(ctx context.Context, userID int64) (bool, error) {
+	user, err := m.UserByID(ctx, userID)
+	return user.IsAdmin, err
+}
+
+func (m *memUsers) SetAdmin(_ context.Context, userID int64, isAdmin bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, ok := m.byID[userID]
+	if !ok {
+		return repository.ErrUserNotFound
+	}
+	user.IsAdmin = isAdmin
+	m.byID[userID] = user
+
+	return nil
+}
+
+func (m *memUsers) disable(userID int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user := m.byID[userID]
+	user.DisabledAt = time.Now()
+	m.byID[userID] = user
+}
+
+func TestLogin_Spans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	prev := otel.GetTracerProvider()
+	tracing.Install(slog.New(slog.NewTextHandler(io.Discard, nil)), sdktrace.NewSimpleSpanProcessor(exporter), 1)
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	users := newMemUsers()
+	auth := newAuthWithLDAP(users, config.LDAPConfig{})
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("bob-pass"), bcrypt.MinCost)
+	require.NoError(t, err)
+	_, err = users.SaveUser(context.Background(), "bob@example.com", hash)
+	require.NoError(t, err)
+
+	_, err = auth.Login(context.Background(), "bob@example.com", "bob-pass", localApp)
+	require.NoError(t, err)
+
+	spans := map[string]tracetest.SpanStub{}
+	for _, s := range exporter.GetSpans() {
+		spans[s.Name] = s
+	}
+
+	// bcrypt виден отдельным спаном внутри проверки пароля
+	chain := []string{"auth.Login", "auth.authenticate", "auth.LocalAuthenticator", "bcrypt.compare"}
+	for i, name := range chain {
+		require.Contains(t, spans, name)
+		if i == 0 {
+			continue
+		}
+		parent := spans[chain[i-1]]
+		assert.Equal(t, parent.SpanContext.SpanID(), spans[name].Parent.SpanID(), name)
+		assert.Equal(t, parent.SpanContext.TraceID(), spans[name].SpanContext.TraceID(), name)
+	}
+}
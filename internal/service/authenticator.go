@@ -0,0 +1,210 @@
+package service
+
+import (
+	"auth-service/internal/emailaddr"
+	"auth-service/internal/ldapauth"
+	"auth-service/internal/logger/sl"
+	"auth-service/internal/model"
+	"auth-service/internal/repository"
+	"auth-service/internal/tracing"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+)
+
+// RoleAdmin — роль из LDAP_GROUP_ROLES, которая даёт пользователю is_admin
+const RoleAdmin = "admin"
+
+// Authenticator проверяет email и пароль в одном источнике учётных данных.
+// ErrInvalidCredentials означает, что нужно попробовать следующий источник.
+type Authenticator interface {
+	Authenticate(ctx context.Context, email, password string) (model.User, error)
+}
+
+// LocalAuthenticator проверяет пароль по bcrypt-хешу из таблицы users
+type LocalAuthenticator struct {
+	log         *slog.Logger
+	usrProvider UserProvider
+}
+
+func NewLocalAuthenticator(log *slog.Logger, userProvider UserProvider) *LocalAuthenticator {
+	return &LocalAuthenticator{
+		log:         log,
+		usrProvider: userProvider,
+	}
+}
+
+// Authenticate проверяет email и пароль и возвращает пользователя.
+// Неизвестный email и неверный пароль дают одну и ту же ErrInvalidCredentials.
+func (l *LocalAuthenticator) Authenticate(ctx context.Context, email, password string) (model.User, error) {
+	const op = "auth.LocalAuthenticator"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := sl.FromContext(ctx, l.log).With(
+		slog.String("op", op),
+		slog.String("username", email),
+	)
+
+	// получить пользователя
+	user, err := l.usrProvider.GetUser(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			log.Warn("user not found", sl.Err(err))
+
+			return model.User{}, fmt.Errorf("%s:%w", op, repository.ErrInvalidCredentials)
+		}
+
+		log.Error("failed to get user", sl.Err(err))
+
+		return model.User{}, fmt.Errorf("%s:%w", op, err)
+
+	}
+
+	// проверка пароля; у пользователей из LDAP и внешних провайдеров хеш пустой
+	err = comparePassword(ctx, user.PassHash, password)
+	if err != nil {
+		log.Error("invalid credentials", sl.Err(err))
+
+		return model.User{}, fmt.Errorf("%s:%w", op, repository.ErrInvalidCredentials)
+	}
+
+	return user, nil
+}
+
+type Directory interface {
+	Authenticate(ctx context.Context, email, password string) (ldapauth.Entry, error)
+}
+
+type ShadowUserStore interface {
+	SaveExternalUser(ctx context.Context, email, source string) (int64, error)
+	UserByIDProvider
+	GetUser(ctx context.Context, email string) (model.User, error)
+	SetAdmin(ctx context.Context, userID int64, isAdmin bool) error
+}
+
+// LDAPAuthenticator проверяет пароль bind'ом в каталоге и ведёт локальную
+// теневую запись пользователя, чтобы у него был user_id
+type LDAPAuthenticator struct {
+	log   *slog.Logger
+	dir   Directory
+	users ShadowUserStore
+	// emails — адрес из каталога приводится к тому же виду, что и при регистрации
+	emails emailaddr.Normalizer
+	// syncRoles — is_admin берётся из групп каталога при каждом входе
+	syncRoles bool
+}
+
+func NewLDAPAuthenticator(
+	log *slog.Logger,
+	dir Directory,
+	users ShadowUserStore,
+	emails emailaddr.Normalizer,
+	syncRoles bool,
+) *LDAPAuthenticator {
+	return &LDAPAuthenticator{
+		log:       log,
+		dir:       dir,
+		users:     users,
+		emails:    emails,
+		syncRoles: syncRoles,
+	}
+}
+
+func (l *LDAPAuthenticator) Authenticate(ctx context.Context, email, password string) (model.User, error) {
+	const op = "auth.LDAPAuthenticator"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := sl.FromContext(ctx, l.log).With(
+		slog.String("op", op),
+		slog.String("username", email),
+	)
+
+	entry, err := l.dir.Authenticate(ctx, email, password)
+	if err != nil {
+		if errors.Is(err, ldapauth.ErrInvalidCredentials) {
+			log.Warn("ldap bind failed")
+
+			return model.User{}, fmt.Errorf("%s:%w", op, repository.ErrInvalidCredentials)
+		}
+
+		log.Error("ldap is unavailable", sl.Err(err))
+
+		return model.User{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	user, err := l.shadowUser(ctx, entry.Email)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserSourceConflict) {
+			log.Warn("ldap account matches a user from another source")
+
+			return model.User{}, fmt.Errorf("%s:%w", op, err)
+		}
+
+		log.Error("failed to shadow ldap user", sl.Err(err))
+
+		return model.User{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	if l.syncRoles {
+		isAdmin := slices.Contains(entry.Roles, RoleAdmin)
+		if user.IsAdmin != isAdmin {
+			if err := l.users.SetAdmin(ctx, user.ID, isAdmin); err != nil {
+				log.Error("failed to sync admin role", sl.Err(err))
+
+				return model.User{}, fmt.Errorf("%s:%w", op, err)
+			}
+			log.Info("admin role synced from ldap groups", slog.Bool("is_admin", isAdmin))
+			user.IsAdmin = isAdmin
+		}
+	}
+
+	return user, nil
+}
+
+// shadowUser возвращает локальную запись пользователя каталога, создавая её без пароля.
+// Пользователь с тем же email, созданный не каталогом, даёт ErrUserSourceConflict:
+// пароль из LDAP не должен открывать чужую учётную запись и менять её роль.
+func (l *LDAPAuthenticator) shadowUser(ctx context.Context, email string) (model.User, error) {
+	email, err := l.emails.Normalize(email)
+	if err != nil {
+		return model.User{}, repository.ErrInvalidEmail
+	}
+
+	user, err := l.users.GetUser(ctx, email)
+	if err == nil {
+		return ldapUser(user)
+	}
+	if !errors.Is(err, repository.ErrUserNotFound) {
+		return model.User{}, err
+	}
+
+	id, err := l.users.SaveExternalUser(ctx, email, model.UserSourceLDAP)
+	if err != nil {
+		// запись создал параллельный вход или регистрация
+		if errors.Is(err, repository.ErrUserExists) {
+			if user, err = l.users.GetUser(ctx, email); err != nil {
+				return model.User{}, err
+			}
+			return ldapUser(user)
+		}
+		return model.User{}, err
+	}
+
+	sl.FromContext(ctx, l.log).Info("created shadow user for ldap account", slog.Int64("user_id", id))
+
+	return l.users.UserByID(ctx, id)
+}
+
+// ldapUser пропускает только записи, созданные каталогом
+func ldapUser(user model.User) (model.User, error) {
+	if user.Source != model.UserSourceLDAP {
+		return model.User{}, repository.ErrUserSourceConflict
+	}
+	return user, nil
+}
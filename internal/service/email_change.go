@@ -0,0 +1,241 @@
+package service
+
+import (
+	"auth-service/internal/appsecret"
+	"auth-service/internal/dynamic"
+	"auth-service/internal/emailaddr"
+	"auth-service/internal/logger/sl"
+	"auth-service/internal/mail"
+	"auth-service/internal/model"
+	"auth-service/internal/random"
+	"auth-service/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// длина токенов ссылок подтверждения и отмены в байтах до кодирования
+const emailChangeTokenLen = 32
+
+type EmailChangeStore interface {
+	CreateEmailChange(ctx context.Context, change model.EmailChange) (model.EmailChange, error)
+	EmailChangeByConfirmHash(ctx context.Context, hash []byte) (model.EmailChange, error)
+	EmailChangeByCancelHash(ctx context.Context, hash []byte) (model.EmailChange, error)
+	ApplyEmailChange(ctx context.Context, change model.EmailChange) error
+	RevertEmailChange(ctx context.Context, change model.EmailChange) error
+}
+
+type EmailChangeUsers interface {
+	UserByIDProvider
+	GetUser(ctx context.Context, email string) (model.User, error)
+}
+
+// EmailChange меняет email только после подтверждения с нового адреса.
+// Старый адрес получает уведомление со ссылкой отмены, которая действует
+// и после подтверждения, чтобы владелец мог вернуть учётную запись.
+type EmailChange struct {
+	log        *slog.Logger
+	users      EmailChangeUsers
+	changes    EmailChangeStore
+	sender     mail.Sender
+	emails     emailaddr.Normalizer
+	baseURL    string
+	confirmTTL *dynamic.Duration
+	cancelTTL  *dynamic.Duration
+}
+
+// NewEmailChange returns a new instance of the EmailChange service.
+// baseURL — внешний адрес HTTP сервера, на который ведут ссылки из писем.
+func NewEmailChange(
+	log *slog.Logger,
+	users EmailChangeUsers,
+	changes EmailChangeStore,
+	sender mail.Sender,
+	emails emailaddr.Normalizer,
+	baseURL string,
+	confirmTTL *dynamic.Duration,
+	cancelTTL *dynamic.Duration,
+) *EmailChange {
+	return &EmailChange{
+		log:        log,
+		users:      users,
+		changes:    changes,
+		sender:     sender,
+		emails:     emails,
+		baseURL:    baseURL,
+		confirmTTL: confirmTTL,
+		cancelTTL:  cancelTTL,
+	}
+}
+
+// RequestEmailChange проверяет текущий пароль и отправляет ссылку подтверждения
+// на newEmail и уведомление со ссылкой отмены на текущий адрес
+func (e *EmailChange) RequestEmailChange(ctx context.Context, userID int64, newEmail, password string) error {
+	const op = "emailchange.RequestEmailChange"
+
+	log := sl.FromContext(ctx, e.log).With(
+		slog.String("op", op),
+		slog.Int64("user_id", userID),
+	)
+
+	user, err := e.users.UserByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	if user.Disabled() {
+		return fmt.Errorf("%s:%w", op, repository.ErrUserDisabled)
+	}
+
+	// у пользователей LDAP и внешних провайдеров хеш пустой: email меняется у них в каталоге
+	if err := comparePassword(ctx, user.PassHash, password); err != nil {
+		log.Warn("invalid current password")
+		return fmt.Errorf("%s:%w", op, repository.ErrInvalidCredentials)
+	}
+
+	newEmail, err = e.emails.Normalize(newEmail)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, repository.ErrInvalidEmail)
+	}
+	// email сравниваются без учёта регистра: смена одного регистра — не смена адреса
+	if strings.EqualFold(newEmail, user.Email) {
+		return fmt.Errorf("%s: email is unchanged: %w", op, ErrInvalidRequest)
+	}
+
+	// ранняя проверка, окончательную делает уникальный индекс при подтверждении
+	if _, err := e.users.GetUser(ctx, newEmail); err == nil {
+		log.Warn("new email is already taken")
+		return fmt.Errorf("%s:%w", op, repository.ErrUserExists)
+	} else if !errors.Is(err, repository.ErrUserNotFound) {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	confirmToken, err := random.Token(emailChangeTokenLen)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	cancelToken, err := random.Token(emailChangeTokenLen)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	now := time.Now()
+	change, err := e.changes.CreateEmailChange(ctx, model.EmailChange{
+		UserID:          user.ID,
+		OldEmail:        user.Email,
+		NewEmail:        newEmail,
+		ConfirmHash:     appsecret.Hash(confirmToken),
+		CancelHash:      appsecret.Hash(cancelToken),
+		ExpiresAt:       now.Add(e.confirmTTL.Get()),
+		CancelExpiresAt: now.Add(e.cancelTTL.Get()),
+	})
+	if err != nil {
+		log.Error("failed to save email change", sl.Err(err))
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	// уведомление старому адресу уходит первым: без него подтверждать смену нельзя
+	err = e.sender.Send(ctx, mail.Message{
+		To:      change.OldEmail,
+		Subject: "Запрошена смена email",
+		Body: fmt.Sprintf(
+			"Для вашей учётной записи запрошена смена email на %s.\n\n"+
+				"Если это были не вы, отмените смену по ссылке (действует до %s):\n%s\n",
+			change.NewEmail, change.CancelExpiresAt.UTC().Format(time.RFC1123), e.link("cancel", cancelToken),
+		),
+	})
+	if err != nil {
+		log.Error("failed to send notice to old email", sl.Err(err))
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	err = e.sender.Send(ctx, mail.Message{
+		To:      change.NewEmail,
+		Subject: "Подтвердите новый email",
+		Body: fmt.Sprintf(
+			"Чтобы сделать этот адрес email вашей учётной записи, перейдите по ссылке (действует до %s):\n%s\n",
+			change.ExpiresAt.UTC().Format(time.RFC1123), e.link("confirm", confirmToken),
+		),
+	})
+	if err != nil {
+		log.Error("failed to send confirmation to new email", sl.Err(err))
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	log.Info("email change requested", slog.Int64("change_id", change.ID))
+
+	return nil
+}
+
+// ConfirmEmailChange меняет email по токену из письма на новый адрес
+func (e *EmailChange) ConfirmEmailChange(ctx context.Context, token string) error {
+	const op = "emailchange.ConfirmEmailChange"
+
+	change, err := e.changes.EmailChangeByConfirmHash(ctx, appsecret.Hash(token))
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	log := sl.FromContext(ctx, e.log).With(
+		slog.String("op", op),
+		slog.Int64("user_id", change.UserID),
+		slog.Int64("change_id", change.ID),
+	)
+
+	if change.Confirmed() || change.Canceled() || time.Now().After(change.ExpiresAt) {
+		log.Warn("email change is no longer pending")
+		return fmt.Errorf("%s:%w", op, repository.ErrEmailChangeNotFound)
+	}
+
+	if err := e.changes.ApplyEmailChange(ctx, change); err != nil {
+		if errors.Is(err, repository.ErrUserExists) {
+			log.Warn("new email was taken before confirmation")
+		}
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	log.Info("email changed")
+
+	return nil
+}
+
+// CancelEmailChange отменяет смену по токену из уведомления на старый адрес
+// и возвращает старый email, если смену уже подтвердили
+func (e *EmailChange) CancelEmailChange(ctx context.Context, token string) error {
+	const op = "emailchange.CancelEmailChange"
+
+	change, err := e.changes.EmailChangeByCancelHash(ctx, appsecret.Hash(token))
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	log := sl.FromContext(ctx, e.log).With(
+		slog.String("op", op),
+		slog.Int64("user_id", change.UserID),
+		slog.Int64("change_id", change.ID),
+	)
+
+	if change.Canceled() || time.Now().After(change.CancelExpiresAt) {
+		log.Warn("email change can no longer be canceled")
+		return fmt.Errorf("%s:%w", op, repository.ErrEmailChangeNotFound)
+	}
+
+	if err := e.changes.RevertEmailChange(ctx, change); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	if change.Confirmed() {
+		log.Warn("confirmed email change reverted by the old address owner")
+	} else {
+		log.Info("email change canceled")
+	}
+
+	return nil
+}
+
+func (e *EmailChange) link(action, token string) string {
+	return e.baseURL + "/email-change/" + action + "?token=" + url.QueryEscape(token)
+}
@@ -0,0 +1,231 @@
+package service_test
+
+import (
+	"auth-service/internal/dynamic"
+	"auth-service/internal/emailaddr"
+	"auth-service/internal/mail"
+	"auth-service/internal/model"
+	"auth-service/internal/repository"
+	"auth-service/internal/service"
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const baseURL = "https://auth.example.com"
+
+func newEmailChange(t *testing.T) (*service.EmailChange, *memUsers, *outbox, int64) {
+	t.Helper()
+
+	users := newMemUsers()
+	hash, err := bcrypt.GenerateFromPassword([]byte("bob-pass"), bcrypt.MinCost)
+	require.NoError(t, err)
+	userID, err := users.SaveUser(context.Background(), "bob@example.com", hash)
+	require.NoError(t, err)
+
+	sent := &outbox{}
+	svc := service.NewEmailChange(
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		users,
+		&memEmailChanges{users: users},
+		sent,
+		emailaddr.Normalizer{},
+		baseURL,
+		dynamic.NewDuration(time.Hour),
+		dynamic.NewDuration(24*time.Hour),
+	)
+
+	return svc, users, sent, userID
+}
+
+func TestEmailChange_ConfirmAndCancel(t *testing.T) {
+	ctx := context.Background()
+	svc, users, sent, userID := newEmailChange(t)
+
+	require.NoError(t, svc.RequestEmailChange(ctx, userID, "bob@new.example.com", "bob-pass"))
+
+	// уведомление со ссылкой отмены уходит на старый адрес, ссылка подтверждения — на новый
+	require.Len(t, sent.messages, 2)
+	assert.Equal(t, "bob@example.com", sent.messages[0].To)
+	assert.Equal(t, "bob@new.example.com", sent.messages[1].To)
+	cancelToken := linkToken(t, sent.messages[0].Body, "cancel")
+	confirmToken := linkToken(t, sent.messages[1].Body, "confirm")
+
+	// email не меняется до подтверждения
+	user, err := users.UserByID(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, "bob@example.com", user.Email)
+
+	// токен отмены не подходит для подтверждения
+	require.ErrorIs(t, svc.ConfirmEmailChange(ctx, cancelToken), repository.ErrEmailChangeNotFound)
+
+	require.NoError(t, svc.ConfirmEmailChange(ctx, confirmToken))
+	user, err = users.UserByID(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, "bob@new.example.com", user.Email)
+
+	require.ErrorIs(t, svc.ConfirmEmailChange(ctx, confirmToken), repository.ErrEmailChangeNotFound)
+
+	// владелец старого адреса возвращает учётную запись и после подтверждения
+	require.NoError(t, svc.CancelEmailChange(ctx, cancelToken))
+	user, err = users.UserByID(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, "bob@example.com", user.Email)
+
+	require.ErrorIs(t, svc.CancelEmailChange(ctx, cancelToken), repository.ErrEmailChangeNotFound)
+}
+
+func TestEmailChange_RequiresCurrentPassword(t *testing.T) {
+	svc, _, sent, userID := newEmailChange(t)
+
+	err := svc.RequestEmailChange(context.Background(), userID, "bob@new.example.com", "wrong")
+	require.ErrorIs(t, err, repository.ErrInvalidCredentials)
+	assert.Empty(t, sent.messages)
+}
+
+func TestEmailChange_EmailTaken(t *testing.T) {
+	ctx := context.Background()
+	svc, users, sent, userID := newEmailChange(t)
+
+	_, err := users.SaveUser(ctx, "alice@example.com", nil)
+	require.NoError(t, err)
+
+	err = svc.RequestEmailChange(ctx, userID, "alice@example.com", "bob-pass")
+	require.ErrorIs(t, err, repository.ErrUserExists)
+	assert.Empty(t, sent.messages)
+
+	// адрес заняли между запросом и подтверждением
+	require.NoError(t, svc.RequestEmailChange(ctx, userID, "carol@example.com", "bob-pass"))
+	_, err = users.SaveUser(ctx, "carol@example.com", nil)
+	require.NoError(t, err)
+
+	err = svc.ConfirmEmailChange(ctx, linkToken(t, sent.messages[1].Body, "confirm"))
+	require.ErrorIs(t, err, repository.ErrUserExists)
+}
+
+// linkToken достаёт токен из ссылки вида <baseURL>/email-change/<action>?token=...
+func linkToken(t *testing.T, body, action string) string {
+	t.Helper()
+
+	link := regexp.MustCompile(regexp.QuoteMeta(baseURL+"/email-change/"+action) + `\?\S+`).FindString(body)
+	require.NotEmpty(t, link, "в письме нет ссылки %s", action)
+
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+
+	return u.Query().Get("token")
+}
+
+type outbox struct {
+	messages []mail.Message
+}
+
+func (o *outbox) Send(_ context.Context, msg mail.Message) error {
+	o.messages = append(o.messages, msg)
+	return nil
+}
+
+// memEmailChanges повторяет проверки EmailChangeRepository в памяти
+type memEmailChanges struct {
+	mu      sync.Mutex
+	users   *memUsers
+	changes []model.EmailChange
+}
+
+func (m *memEmailChanges) CreateEmailChange(_ context.Context, change model.EmailChange) (model.EmailChange, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, c := range m.changes {
+		if c.UserID == change.UserID && !c.Confirmed() && !c.Canceled() {
+			m.changes[i].CanceledAt = time.Now()
+		}
+	}
+	change.ID = int64(len(m.changes) + 1)
+	change.CreatedAt = time.Now()
+	m.changes = append(m.changes, change)
+
+	return change, nil
+}
+
+func (m *memEmailChanges) EmailChangeByConfirmHash(_ context.Context, hash []byte) (model.EmailChange, error) {
+	return m.find(func(c model.EmailChange) bool { return bytes.Equal(c.ConfirmHash, hash) })
+}
+
+func (m *memEmailChanges) EmailChangeByCancelHash(_ context.Context, hash []byte) (model.EmailChange, error) {
+	return m.find(func(c model.EmailChange) bool { return bytes.Equal(c.CancelHash, hash) })
+}
+
+func (m *memEmailChanges) ApplyEmailChange(ctx context.Context, change model.EmailChange) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c := &m.changes[change.ID-1]
+	if c.Confirmed() || c.Canceled() {
+		return repository.ErrEmailChangeNotFound
+	}
+	if err := m.setEmail(ctx, c.UserID, c.OldEmail, c.NewEmail); err != nil {
+		return err
+	}
+	c.ConfirmedAt = time.Now()
+
+	return nil
+}
+
+func (m *memEmailChanges) RevertEmailChange(ctx context.Context, change model.EmailChange) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c := &m.changes[change.ID-1]
+	if c.Canceled() {
+		return repository.ErrEmailChangeNotFound
+	}
+	if c.Confirmed() {
+		if err := m.setEmail(ctx, c.UserID, c.NewEmail, c.OldEmail); err != nil {
+			return err
+		}
+	}
+	c.CanceledAt = time.Now()
+
+	return nil
+}
+
+func (m *memEmailChanges) find(match func(model.EmailChange) bool) (model.EmailChange, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, c := range m.changes {
+		if match(c) {
+			return c, nil
+		}
+	}
+	return model.EmailChange{}, repository.ErrEmailChangeNotFound
+}
+
+func (m *memEmailChanges) setEmail(ctx context.Context, userID int64, from, to string) error {
+	if _, err := m.users.GetUser(ctx, to); err == nil {
+		return repository.ErrUserExists
+	}
+
+	m.users.mu.Lock()
+	defer m.users.mu.Unlock()
+
+	user, ok := m.users.byID[userID]
+	if !ok || user.Email != from {
+		return repository.ErrEmailChangeNotFound
+	}
+	user.Email = to
+	m.users.byID[userID] = user
+
+	return nil
+}
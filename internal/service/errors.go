@@ -0,0 +1,22 @@
+package service
+
+import "auth-service/internal/apperr"
+
+// ошибки протоколов, которые сервисы возвращают поверх ошибок хранилища
+var (
+	// аутентификация клиента и запрошенные права
+	ErrInvalidClient = apperr.New(apperr.Unauthenticated, "INVALID_CLIENT", "invalid client")
+	ErrInvalidScope  = apperr.New(apperr.PermissionDenied, "INVALID_SCOPE", "requested scope is not granted to the app")
+
+	// ошибки протокола OAuth 2.0 (RFC 6749, раздел 4.1.2.1 и 5.2)
+	ErrInvalidRequest     = apperr.New(apperr.InvalidArgument, "INVALID_REQUEST", "invalid request")
+	ErrInvalidRedirectURI = apperr.New(apperr.InvalidArgument, "INVALID_REDIRECT_URI", "invalid redirect_uri")
+	ErrInvalidGrant       = apperr.New(apperr.InvalidArgument, "INVALID_GRANT", "invalid grant")
+	ErrAccessDenied       = apperr.New(apperr.PermissionDenied, "ACCESS_DENIED", "access denied")
+	ErrInvalidToken       = apperr.New(apperr.Unauthenticated, "INVALID_TOKEN", "invalid token")
+	ErrInsufficientScope  = apperr.New(apperr.PermissionDenied, "INSUFFICIENT_SCOPE", "insufficient scope")
+
+	// ошибки входа через внешних провайдеров
+	ErrProviderNotFound = apperr.New(apperr.NotFound, "PROVIDER_NOT_FOUND", "identity provider not found")
+	ErrEmailNotVerified = apperr.New(apperr.FailedPrecondition, "EMAIL_NOT_VERIFIED", "email is not verified by identity provider")
+)
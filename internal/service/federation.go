@@ -0,0 +1,256 @@
+package service
+
+import (
+	"auth-service/internal/dynamic"
+	"auth-service/internal/emailaddr"
+	"auth-service/internal/federation"
+	"auth-service/internal/jwt"
+	"auth-service/internal/logger/sl"
+	"auth-service/internal/metrics"
+	"auth-service/internal/model"
+	"auth-service/internal/random"
+	"auth-service/internal/repository"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// длина state, nonce и PKCE verifier для запросов к внешнему провайдеру
+const federationTokenLen = 32
+
+type IdentityProvider interface {
+	Name() string
+	AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error)
+	Exchange(ctx context.Context, code, verifier, nonce string) (federation.Identity, error)
+}
+
+type IdentityStore interface {
+	LinkedUser(ctx context.Context, provider, subject string) (int64, error)
+	LinkIdentity(ctx context.Context, identity model.LinkedIdentity) error
+}
+
+type FederatedUsers interface {
+	UserSaver
+	UserByIDProvider
+	GetUser(ctx context.Context, email string) (model.User, error)
+}
+
+// FederationStart — куда отправить пользователя и что положить в cookie до возврата
+type FederationStart struct {
+	RedirectURL string
+	State       string
+}
+
+// Federation реализует вход через внешних OIDC провайдеров: пользователи
+// связываются по (provider, sub), новые создаются при первом входе
+type Federation struct {
+	log         *slog.Logger
+	providers   map[string]IdentityProvider
+	users       FederatedUsers
+	identities  IdentityStore
+	appProvider AppProvider
+	emails      emailaddr.Normalizer
+	jwtSecret   string
+	tokenTTL    *dynamic.Duration
+	stateTTL    *dynamic.Duration
+}
+
+// NewFederation returns a new instance of the Federation service.
+func NewFederation(
+	log *slog.Logger,
+	providers []IdentityProvider,
+	users FederatedUsers,
+	identities IdentityStore,
+	appProvider AppProvider,
+	emails emailaddr.Normalizer,
+	jwtSecret string,
+	tokenTTL *dynamic.Duration,
+	stateTTL *dynamic.Duration,
+) *Federation {
+	byName := make(map[string]IdentityProvider, len(providers))
+	for _, p := range providers {
+		byName[p.Name()] = p
+	}
+
+	return &Federation{
+		log:         log,
+		providers:   byName,
+		users:       users,
+		identities:  identities,
+		appProvider: appProvider,
+		emails:      emails,
+		jwtSecret:   jwtSecret,
+		tokenTTL:    tokenTTL,
+		stateTTL:    stateTTL,
+	}
+}
+
+// Start готовит редирект к провайдеру для входа в приложение appID
+func (f *Federation) Start(ctx context.Context, providerName string, appID int) (FederationStart, error) {
+	const op = "federation.Start"
+
+	log := sl.FromContext(ctx, f.log).With(
+		slog.String("op", op),
+		slog.String("provider", providerName),
+		slog.Int("app_id", appID),
+	)
+
+	provider, ok := f.providers[providerName]
+	if !ok {
+		return FederationStart{}, fmt.Errorf("%s:%w", op, ErrProviderNotFound)
+	}
+
+	if _, err := f.appProvider.App(ctx, appID); err != nil {
+		if errors.Is(err, repository.ErrAppNotFound) {
+			return FederationStart{}, fmt.Errorf("%s:%w", op, ErrInvalidClient)
+		}
+		return FederationStart{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	state := jwt.FederationState{Provider: providerName, AppID: appID}
+	for _, v := range []*string{&state.State, &state.Nonce, &state.Verifier} {
+		token, err := random.Token(federationTokenLen)
+		if err != nil {
+			return FederationStart{}, fmt.Errorf("%s:%w", op, err)
+		}
+		*v = token
+	}
+
+	redirectURL, err := provider.AuthCodeURL(ctx, state.State, state.Nonce, state.Verifier)
+	if err != nil {
+		log.Error("identity provider is unavailable", sl.Err(err))
+		return FederationStart{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	signed, err := jwt.NewFederationState(state, f.jwtSecret, f.stateTTL.Get())
+	if err != nil {
+		return FederationStart{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	return FederationStart{RedirectURL: redirectURL, State: signed}, nil
+}
+
+// Finish завершает вход по коду провайдера: проверяет state из cookie,
+// находит или создаёт пользователя и выдаёт обычный токен приложения
+func (f *Federation) Finish(ctx context.Context, providerName, signedState, state, code string) (string, error) {
+	const op = "federation.Finish"
+
+	log := sl.FromContext(ctx, f.log).With(
+		slog.String("op", op),
+		slog.String("provider", providerName),
+	)
+
+	provider, ok := f.providers[providerName]
+	if !ok {
+		return "", fmt.Errorf("%s:%w", op, ErrProviderNotFound)
+	}
+
+	s, err := jwt.ParseFederationState(signedState, f.jwtSecret)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w: %w", op, ErrInvalidRequest, err)
+	}
+	if s.Provider != providerName || subtle.ConstantTimeCompare([]byte(s.State), []byte(state)) != 1 {
+		log.Warn("federation state mismatch")
+		return "", fmt.Errorf("%s: state mismatch: %w", op, ErrInvalidRequest)
+	}
+
+	identity, err := provider.Exchange(ctx, code, s.Verifier, s.Nonce)
+	if err != nil {
+		log.Warn("failed to exchange code with identity provider", sl.Err(err))
+		return "", fmt.Errorf("%s: %w: %w", op, ErrInvalidGrant, err)
+	}
+
+	user, err := f.resolveUser(ctx, providerName, identity)
+	if err != nil {
+		return "", fmt.Errorf("%s:%w", op, err)
+	}
+	if user.Disabled() {
+		log.Warn("user is disabled", slog.Int64("user_id", user.ID))
+		return "", fmt.Errorf("%s:%w", op, repository.ErrUserDisabled)
+	}
+
+	app, err := f.appProvider.App(ctx, s.AppID)
+	if err != nil {
+		if errors.Is(err, repository.ErrAppNotFound) {
+			return "", fmt.Errorf("%s:%w", op, ErrInvalidClient)
+		}
+		return "", fmt.Errorf("%s:%w", op, err)
+	}
+
+	token, err := jwt.NewToken(user, app, f.jwtSecret, f.tokenTTL.Get())
+	if err != nil {
+		log.Error("failed to sign token", sl.Err(err))
+		return "", fmt.Errorf("%s:%w", op, err)
+	}
+	metrics.TokenIssued(metrics.TokenAccess)
+
+	log.Info("user logged in via identity provider", slog.Int64("user_id", user.ID), slog.Int("app_id", app.ID))
+
+	return token, nil
+}
+
+// resolveUser находит пользователя по связанной учётной записи. При первом входе
+// учётная запись связывается с пользователем с тем же email, если провайдер его
+// подтвердил, или с новым пользователем без пароля.
+func (f *Federation) resolveUser(ctx context.Context, providerName string, identity federation.Identity) (model.User, error) {
+	const op = "federation.resolveUser"
+
+	log := sl.FromContext(ctx, f.log).With(
+		slog.String("op", op),
+		slog.String("provider", providerName),
+	)
+
+	userID, err := f.identities.LinkedUser(ctx, providerName, identity.Subject)
+	if err == nil {
+		return f.users.UserByID(ctx, userID)
+	}
+	if !errors.Is(err, repository.ErrIdentityNotFound) {
+		return model.User{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	// без подтверждённого email нельзя ни связать, ни занять адрес новым пользователем
+	if identity.Email == "" || !identity.EmailVerified {
+		log.Warn("identity provider did not verify email")
+		return model.User{}, fmt.Errorf("%s:%w", op, ErrEmailNotVerified)
+	}
+
+	// адрес от провайдера сравнивается и сохраняется в том же виде, что и при регистрации
+	email, err := f.emails.Normalize(identity.Email)
+	if err != nil {
+		log.Warn("identity provider returned invalid email")
+		return model.User{}, fmt.Errorf("%s:%w", op, repository.ErrInvalidEmail)
+	}
+
+	user, err := f.users.GetUser(ctx, email)
+	switch {
+	case err == nil:
+		log.Info("linking identity to existing user", slog.Int64("user_id", user.ID))
+	case errors.Is(err, repository.ErrUserNotFound):
+		// пустой хеш не совпадёт ни с одним паролем: войти можно только через провайдера
+		id, err := f.users.SaveUser(ctx, email, []byte{})
+		if err != nil {
+			return model.User{}, fmt.Errorf("%s:%w", op, err)
+		}
+		if user, err = f.users.UserByID(ctx, id); err != nil {
+			return model.User{}, fmt.Errorf("%s:%w", op, err)
+		}
+		log.Info("provisioned user from identity provider", slog.Int64("user_id", user.ID))
+	default:
+		return model.User{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	err = f.identities.LinkIdentity(ctx, model.LinkedIdentity{
+		UserID:   user.ID,
+		Provider: providerName,
+		Subject:  identity.Subject,
+		Email:    email,
+	})
+	if err != nil {
+		log.Error("failed to link identity", sl.Err(err))
+		return model.User{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	return user, nil
+}
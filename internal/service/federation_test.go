@@ -0,0 +1,459 @@
+package service_test
+
+import (
+	"auth-service/config"
+	"auth-service/internal/dynamic"
+	"auth-service/internal/emailaddr"
+	"auth-service/internal/federation"
+	"auth-service/internal/jwt"
+	"auth-service/internal/model"
+	"auth-service/internal/random"
+	"auth-service/internal/repository"
+	"auth-service/internal/service"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	idpClientID     = "auth-service"
+	idpClientSecret = "idp-secret"
+	idpProvider     = "corp"
+	jwtSecret       = "test-secret"
+	testAppID       = 1
+)
+
+func TestFederation_ProvisionsUserOnFirstLogin(t *testing.T) {
+	idp := newMockIdP(t)
+	users, identities := newMemUsers(), newMemIdentities()
+	fed := newFederation(idp, users, identities)
+
+	idp.user = idpUser{Subject: "alice-sub", Email: "alice@corp.example", EmailVerified: true}
+
+	token := federatedLogin(t, fed, idp)
+
+	claims, err := jwt.ParseToken(token, jwtSecret)
+	require.NoError(t, err)
+	assert.Equal(t, "alice@corp.example", claims.Email)
+	assert.Equal(t, testAppID, claims.AppID)
+
+	userID, err := identities.LinkedUser(context.Background(), idpProvider, "alice-sub")
+	require.NoError(t, err)
+	assert.Equal(t, claims.UserID, userID)
+
+	// повторный вход находит пользователя по связи, даже если email у провайдера сменился
+	idp.user.Email = "alice.new@corp.example"
+
+	claims, err = jwt.ParseToken(federatedLogin(t, fed, idp), jwtSecret)
+	require.NoError(t, err)
+	assert.Equal(t, userID, claims.UserID)
+	assert.Len(t, users.byID, 1)
+}
+
+func TestFederation_LinksExistingUserByVerifiedEmail(t *testing.T) {
+	idp := newMockIdP(t)
+	users, identities := newMemUsers(), newMemIdentities()
+	fed := newFederation(idp, users, identities)
+
+	existingID, err := users.SaveUser(context.Background(), "bob@corp.example", []byte("hash"))
+	require.NoError(t, err)
+
+	idp.user = idpUser{Subject: "bob-sub", Email: "bob@corp.example", EmailVerified: true}
+
+	claims, err := jwt.ParseToken(federatedLogin(t, fed, idp), jwtSecret)
+	require.NoError(t, err)
+	assert.Equal(t, existingID, claims.UserID)
+	assert.Len(t, users.byID, 1)
+}
+
+func TestFederation_NormalizesProviderEmail(t *testing.T) {
+	idp := newMockIdP(t)
+	users, identities := newMemUsers(), newMemIdentities()
+	fed := newFederation(idp, users, identities)
+
+	// так адрес сохраняет Register
+	existingID, err := users.SaveUser(context.Background(), "dave@xn--e1afmkfd.xn--p1ai", []byte("hash"))
+	require.NoError(t, err)
+
+	idp.user = idpUser{Subject: "dave-sub", Email: "  Dave@Пример.РФ", EmailVerified: true}
+
+	claims, err := jwt.ParseToken(federatedLogin(t, fed, idp), jwtSecret)
+	require.NoError(t, err)
+	assert.Equal(t, existingID, claims.UserID)
+	assert.Equal(t, "dave@xn--e1afmkfd.xn--p1ai", claims.Email)
+	assert.Len(t, users.byID, 1)
+
+	// новый пользователь создаётся уже с нормализованным адресом
+	idp.user = idpUser{Subject: "erin-sub", Email: "Erin@Пример.рф", EmailVerified: true}
+
+	claims, err = jwt.ParseToken(federatedLogin(t, fed, idp), jwtSecret)
+	require.NoError(t, err)
+	assert.Equal(t, "erin@xn--e1afmkfd.xn--p1ai", users.byID[claims.UserID].Email)
+	assert.Len(t, users.byID, 2)
+}
+
+func TestFederation_RejectsUnverifiedEmail(t *testing.T) {
+	idp := newMockIdP(t)
+	users, identities := newMemUsers(), newMemIdentities()
+	fed := newFederation(idp, users, identities)
+
+	_, err := users.SaveUser(context.Background(), "carol@corp.example", []byte("hash"))
+	require.NoError(t, err)
+
+	idp.user = idpUser{Subject: "mallory-sub", Email: "carol@corp.example", EmailVerified: false}
+
+	start, code := startLogin(t, fed, idp)
+	_, err = fed.Finish(context.Background(), idpProvider, start.State, code.state, code.code)
+	require.ErrorIs(t, err, service.ErrEmailNotVerified)
+	assert.Empty(t, identities.links)
+}
+
+func TestFederation_RejectsStateMismatch(t *testing.T) {
+	idp := newMockIdP(t)
+	fed := newFederation(idp, newMemUsers(), newMemIdentities())
+
+	idp.user = idpUser{Subject: "dave-sub", Email: "dave@corp.example", EmailVerified: true}
+
+	start, code := startLogin(t, fed, idp)
+	_, err := fed.Finish(context.Background(), idpProvider, start.State, "forged", code.code)
+	require.ErrorIs(t, err, service.ErrInvalidRequest)
+}
+
+func TestFederation_RejectsWrongNonce(t *testing.T) {
+	idp := newMockIdP(t)
+	fed := newFederation(idp, newMemUsers(), newMemIdentities())
+
+	idp.user = idpUser{Subject: "erin-sub", Email: "erin@corp.example", EmailVerified: true}
+	idp.nonceOverride = "replayed"
+
+	start, code := startLogin(t, fed, idp)
+	_, err := fed.Finish(context.Background(), idpProvider, start.State, code.state, code.code)
+	require.ErrorIs(t, err, service.ErrInvalidGrant)
+}
+
+func TestFederation_UnknownProvider(t *testing.T) {
+	fed := newFederation(newMockIdP(t), newMemUsers(), newMemIdentities())
+
+	_, err := fed.Start(context.Background(), "unknown", testAppID)
+	require.ErrorIs(t, err, service.ErrProviderNotFound)
+}
+
+func newFederation(idp *mockIdP, users *memUsers, identities *memIdentities) *service.Federation {
+	provider := federation.NewProvider(config.FederationProvider{
+		Name:         idpProvider,
+		Issuer:       idp.server.URL,
+		ClientID:     idpClientID,
+		ClientSecret: idpClientSecret,
+	}, "http://localhost:8080/federation/corp/callback")
+
+	return service.NewFederation(
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		[]service.IdentityProvider{provider},
+		users,
+		identities,
+		apps{testAppID: {ID: testAppID, Name: "test"}},
+		emailaddr.Normalizer{FoldLocalPart: true},
+		jwtSecret,
+		dynamic.NewDuration(time.Hour),
+		dynamic.NewDuration(time.Minute),
+	)
+}
+
+// federatedLogin проходит весь вход и возвращает токен приложения
+func federatedLogin(t *testing.T, fed *service.Federation, idp *mockIdP) string {
+	t.Helper()
+
+	start, code := startLogin(t, fed, idp)
+
+	token, err := fed.Finish(context.Background(), idpProvider, start.State, code.state, code.code)
+	require.NoError(t, err)
+
+	return token
+}
+
+type callback struct {
+	code  string
+	state string
+}
+
+// startLogin начинает вход и проходит авторизацию у провайдера
+func startLogin(t *testing.T, fed *service.Federation, idp *mockIdP) (service.FederationStart, callback) {
+	t.Helper()
+
+	start, err := fed.Start(context.Background(), idpProvider, testAppID)
+	require.NoError(t, err)
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(start.RedirectURL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+
+	return start, callback{code: location.Query().Get("code"), state: location.Query().Get("state")}
+}
+
+type idpUser struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+type idpCode struct {
+	nonce     string
+	challenge string
+	user      idpUser
+}
+
+// mockIdP — минимальный OIDC провайдер: discovery, JWKS, /authorize с PKCE и /token
+type mockIdP struct {
+	server *httptest.Server
+	signer *jwt.IDTokenSigner
+
+	// пользователь, который «входит» у провайдера
+	user idpUser
+	// nonceOverride подменяет nonce в id_token
+	nonceOverride string
+
+	mu    sync.Mutex
+	codes map[string]idpCode
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+
+	signer, err := jwt.GenerateIDTokenSigner()
+	require.NoError(t, err)
+
+	idp := &mockIdP{signer: signer, codes: make(map[string]idpCode)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", idp.discovery)
+	mux.HandleFunc("GET /jwks", idp.jwks)
+	mux.HandleFunc("GET /authorize", idp.authorize)
+	mux.HandleFunc("POST /token", idp.token)
+
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+
+	return idp
+}
+
+func (idp *mockIdP) discovery(w http.ResponseWriter, _ *http.Request) {
+	base := idp.server.URL
+	writeJSON(w, map[string]any{
+		"issuer":                                base,
+		"authorization_endpoint":                base + "/authorize",
+		"token_endpoint":                        base + "/token",
+		"jwks_uri":                              base + "/jwks",
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (idp *mockIdP) jwks(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, map[string]any{"keys": idp.signer.JWKS()})
+}
+
+func (idp *mockIdP) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client_id") != idpClientID || query.Get("code_challenge_method") != "S256" {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	code, err := random.Token(16)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	idp.mu.Lock()
+	idp.codes[code] = idpCode{
+		nonce:     query.Get("nonce"),
+		challenge: query.Get("code_challenge"),
+		user:      idp.user,
+	}
+	idp.mu.Unlock()
+
+	redirect, _ := url.Parse(query.Get("redirect_uri"))
+	redirect.RawQuery = url.Values{"code": {code}, "state": {query.Get("state")}}.Encode()
+
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (idp *mockIdP) token(w http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.FormValue("client_id"), r.FormValue("client_secret")
+	}
+	if clientID != idpClientID || clientSecret != idpClientSecret {
+		http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+		return
+	}
+
+	idp.mu.Lock()
+	code, ok := idp.codes[r.FormValue("code")]
+	delete(idp.codes, r.FormValue("code"))
+	idp.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != code.challenge {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+
+	nonce := code.nonce
+	if idp.nonceOverride != "" {
+		nonce = idp.nonceOverride
+	}
+
+	idToken, err := idp.signer.Sign(jwt.IDTokenClaims{
+		Issuer:   idp.server.URL,
+		Subject:  code.user.Subject,
+		Audience: idpClientID,
+		Nonce:    nonce,
+		Extra: map[string]any{
+			"email":          code.user.Email,
+			"email_verified": code.user.EmailVerified,
+		},
+	}, time.Minute)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, map[string]any{
+		"access_token": "upstream-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   60,
+		"id_token":     idToken,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+type apps map[int]model.App
+
+func (a apps) App(_ context.Context, appID int) (model.App, error) {
+	app, ok := a[appID]
+	if !ok {
+		return model.App{}, repository.ErrAppNotFound
+	}
+	return app, nil
+}
+
+type memUsers struct {
+	mu   sync.Mutex
+	byID map[int64]model.User
+}
+
+func newMemUsers() *memUsers {
+	return &memUsers{byID: make(map[int64]model.User)}
+}
+
+func (m *memUsers) SaveUser(_ context.Context, email string, passHash []byte) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, u := range m.byID {
+		// как уникальный индекс по lower(email)
+		if strings.EqualFold(u.Email, email) {
+			return 0, repository.ErrUserExists
+		}
+	}
+
+	id := int64(len(m.byID) + 1)
+	m.byID[id] = model.User{ID: id, Email: email, PassHash: passHash, Source: model.UserSourceLocal}
+
+	return id, nil
+}
+
+func (m *memUsers) SaveExternalUser(ctx context.Context, email, source string) (int64, error) {
+	id, err := m.SaveUser(ctx, email, []byte{})
+	if err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u := m.byID[id]
+	u.Source = source
+	m.byID[id] = u
+
+	return id, nil
+}
+
+func (m *memUsers) GetUser(_ context.Context, email string) (model.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, u := range m.byID {
+		if strings.EqualFold(u.Email, email) {
+			return u, nil
+		}
+	}
+	return model.User{}, repository.ErrUserNotFound
+}
+
+func (m *memUsers) UserByID(_ context.Context, userID int64) (model.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.byID[userID]
+	if !ok {
+		return model.User{}, repository.ErrUserNotFound
+	}
+	return u, nil
+}
+
+type memIdentities struct {
+	mu    sync.Mutex
+	links map[string]int64
+}
+
+func newMemIdentities() *memIdentities {
+	return &memIdentities{links: make(map[string]int64)}
+}
+
+func (m *memIdentities) LinkedUser(_ context.Context, provider, subject string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	userID, ok := m.links[provider+"/"+subject]
+	if !ok {
+		return 0, repository.ErrIdentityNotFound
+	}
+	return userID, nil
+}
+
+func (m *memIdentities) LinkIdentity(_ context.Context, identity model.LinkedIdentity) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := identity.Provider + "/" + identity.Subject
+	if _, ok := m.links[key]; !ok {
+		m.links[key] = identity.UserID
+	}
+	return nil
+}
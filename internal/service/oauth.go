@@ -0,0 +1,466 @@
+package service
+
+import (
+	"auth-service/internal/appsecret"
+	"auth-service/internal/dynamic"
+	"auth-service/internal/jwt"
+	"auth-service/internal/logger/sl"
+	"auth-service/internal/metrics"
+	"auth-service/internal/model"
+	"auth-service/internal/random"
+	"auth-service/internal/repository"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	// единственный поддерживаемый метод PKCE (RFC 7636)
+	CodeChallengeS256 = "S256"
+
+	// scopes OpenID Connect, определяющие состав id_token и /userinfo
+	ScopeOpenID  = "openid"
+	ScopeEmail   = "email"
+	ScopeProfile = "profile"
+
+	authCodeLen = 32
+	// длина code_challenge: base64url от SHA-256 без паддинга
+	codeChallengeLen = 43
+)
+
+type UserAuthenticator interface {
+	Authenticate(ctx context.Context, email, password string, appID int) (model.User, error)
+}
+
+type UserByIDProvider interface {
+	UserByID(ctx context.Context, userID int64) (model.User, error)
+}
+
+type CodeStore interface {
+	SaveCode(ctx context.Context, code model.AuthorizationCode) error
+	ConsumeCode(ctx context.Context, codeHash []byte) (model.AuthorizationCode, error)
+}
+
+type ConsentStore interface {
+	Consent(ctx context.Context, userID int64, appID int) ([]string, error)
+	SaveConsent(ctx context.Context, userID int64, appID int, scopes []string) error
+}
+
+// AuthorizeResult — итог шага /authorize: либо код, либо тикет для страницы согласия
+type AuthorizeResult struct {
+	Code          string
+	ConsentTicket string
+}
+
+// TokenRequest — параметры обмена кода на токен (grant_type=authorization_code)
+type TokenRequest struct {
+	Code         string
+	RedirectURI  string
+	AppID        int
+	ClientSecret string
+	CodeVerifier string
+}
+
+// TokenResult — ответ эндпоинта /token для authorization code
+type TokenResult struct {
+	AccessToken string
+	// IDToken выдаётся, только если запрошен scope openid
+	IDToken string
+	Scopes  []string
+}
+
+// OAuth реализует authorization code grant с PKCE поверх пользователей и приложений
+// и OpenID Connect поверх него
+type OAuth struct {
+	log          *slog.Logger
+	users        UserAuthenticator
+	userProvider UserByIDProvider
+	appProvider  AppProvider
+	codes        CodeStore
+	consents     ConsentStore
+	idTokens     *jwt.IDTokenSigner
+	envelope     *appsecret.Envelope
+	issuer       string
+	jwtSecret    string
+	tokenTTL     *dynamic.Duration
+	codeTTL      *dynamic.Duration
+	ticketTTL    *dynamic.Duration
+}
+
+// NewOAuth returns a new instance of the OAuth service.
+func NewOAuth(
+	log *slog.Logger,
+	users UserAuthenticator,
+	userProvider UserByIDProvider,
+	appProvider AppProvider,
+	codes CodeStore,
+	consents ConsentStore,
+	idTokens *jwt.IDTokenSigner,
+	envelope *appsecret.Envelope,
+	issuer string,
+	jwtSecret string,
+	tokenTTL *dynamic.Duration,
+	codeTTL *dynamic.Duration,
+	ticketTTL *dynamic.Duration,
+) *OAuth {
+	return &OAuth{
+		log:          log,
+		users:        users,
+		userProvider: userProvider,
+		appProvider:  appProvider,
+		codes:        codes,
+		consents:     consents,
+		idTokens:     idTokens,
+		envelope:     envelope,
+		issuer:       issuer,
+		jwtSecret:    jwtSecret,
+		tokenTTL:     tokenTTL,
+		codeTTL:      codeTTL,
+		ticketTTL:    ticketTTL,
+	}
+}
+
+// ValidateAuthorization проверяет клиента, redirect_uri, scopes и параметры PKCE.
+// При ErrInvalidClient и ErrInvalidRedirectURI перенаправлять пользователя нельзя.
+func (o *OAuth) ValidateAuthorization(ctx context.Context, req model.AuthorizationRequest) (model.App, error) {
+	const op = "oauth.ValidateAuthorization"
+
+	app, err := o.appProvider.App(ctx, req.AppID)
+	if err != nil {
+		if errors.Is(err, repository.ErrAppNotFound) {
+			return model.App{}, fmt.Errorf("%s:%w", op, ErrInvalidClient)
+		}
+		return model.App{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	// сравнение строгое: RFC 6749 требует точного совпадения с зарегистрированным URI
+	if !slices.Contains(app.RedirectURIs, req.RedirectURI) {
+		return model.App{}, fmt.Errorf("%s:%w", op, ErrInvalidRedirectURI)
+	}
+
+	if req.CodeChallengeMethod != CodeChallengeS256 || len(req.CodeChallenge) != codeChallengeLen {
+		return model.App{}, fmt.Errorf("%s: PKCE S256 code_challenge is required: %w", op, ErrInvalidRequest)
+	}
+
+	// scope обязателен: без него согласие не спрашивается. Приложение получает
+	// только scopes, разрешённые ему администратором.
+	if len(req.Scopes) == 0 || !containsAll(app.Scopes, req.Scopes) {
+		return model.App{}, fmt.Errorf("%s:%w", op, ErrInvalidScope)
+	}
+
+	return app, nil
+}
+
+// Authorize аутентифицирует пользователя. Если согласие на scopes уже есть,
+// сразу выдаёт код, иначе возвращает тикет для подтверждения согласия.
+func (o *OAuth) Authorize(ctx context.Context, req model.AuthorizationRequest, email, password string) (AuthorizeResult, error) {
+	const op = "oauth.Authorize"
+
+	log := sl.FromContext(ctx, o.log).With(
+		slog.String("op", op),
+		slog.Int("app_id", req.AppID),
+	)
+
+	if _, err := o.ValidateAuthorization(ctx, req); err != nil {
+		return AuthorizeResult{}, err
+	}
+
+	user, err := o.users.Authenticate(ctx, email, password, req.AppID)
+	if err != nil {
+		return AuthorizeResult{}, fmt.Errorf("%s:%w", op, err)
+	}
+	authTime := time.Now()
+
+	consented, err := o.consents.Consent(ctx, user.ID, req.AppID)
+	if err != nil {
+		log.Error("failed to get consent", sl.Err(err))
+		return AuthorizeResult{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	if containsAll(consented, req.Scopes) {
+		code, err := o.issueCode(ctx, user.ID, authTime, req)
+		if err != nil {
+			log.Error("failed to issue code", sl.Err(err))
+			return AuthorizeResult{}, fmt.Errorf("%s:%w", op, err)
+		}
+
+		log.Info("authorization code issued", slog.Int64("user_id", user.ID))
+
+		return AuthorizeResult{Code: code}, nil
+	}
+
+	ticket, err := jwt.NewConsentTicket(jwt.ConsentTicket{
+		UserID:   user.ID,
+		AuthTime: authTime,
+		Request:  req,
+	}, o.jwtSecret, o.ticketTTL.Get())
+	if err != nil {
+		log.Error("failed to sign consent ticket", sl.Err(err))
+		return AuthorizeResult{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	return AuthorizeResult{ConsentTicket: ticket}, nil
+}
+
+// Consent применяет решение пользователя по тикету согласия.
+// Запрос возвращается и при отказе, чтобы вернуть ошибку на redirect_uri клиента.
+func (o *OAuth) Consent(ctx context.Context, ticket string, approved bool) (model.AuthorizationRequest, string, error) {
+	const op = "oauth.Consent"
+
+	t, err := jwt.ParseConsentTicket(ticket, o.jwtSecret)
+	if err != nil {
+		return model.AuthorizationRequest{}, "", fmt.Errorf("%s: %w: %w", op, ErrInvalidRequest, err)
+	}
+	userID, req := t.UserID, t.Request
+
+	log := sl.FromContext(ctx, o.log).With(
+		slog.String("op", op),
+		slog.Int("app_id", req.AppID),
+		slog.Int64("user_id", userID),
+	)
+
+	if !approved {
+		log.Info("user denied consent")
+		return req, "", fmt.Errorf("%s:%w", op, ErrAccessDenied)
+	}
+
+	// приложение могли изменить, пока пользователь был на странице согласия
+	if _, err := o.ValidateAuthorization(ctx, req); err != nil {
+		// redirect_uri ещё зарегистрирован, ошибку можно вернуть клиенту
+		if errors.Is(err, ErrInvalidScope) {
+			return req, "", fmt.Errorf("%s:%w", op, err)
+		}
+		return model.AuthorizationRequest{}, "", fmt.Errorf("%s:%w", op, err)
+	}
+
+	if err := o.consents.SaveConsent(ctx, userID, req.AppID, req.Scopes); err != nil {
+		log.Error("failed to save consent", sl.Err(err))
+		return req, "", fmt.Errorf("%s:%w", op, err)
+	}
+
+	code, err := o.issueCode(ctx, userID, t.AuthTime, req)
+	if err != nil {
+		log.Error("failed to issue code", sl.Err(err))
+		return req, "", fmt.Errorf("%s:%w", op, err)
+	}
+
+	log.Info("consent granted, authorization code issued")
+
+	return req, code, nil
+}
+
+// Exchange обменивает код авторизации на токен доступа (и id_token для scope openid)
+func (o *OAuth) Exchange(ctx context.Context, req TokenRequest) (TokenResult, error) {
+	const op = "oauth.Exchange"
+
+	log := sl.FromContext(ctx, o.log).With(
+		slog.String("op", op),
+		slog.Int("app_id", req.AppID),
+	)
+
+	app, err := o.appProvider.App(ctx, req.AppID)
+	if err != nil {
+		if errors.Is(err, repository.ErrAppNotFound) {
+			return TokenResult{}, fmt.Errorf("%s:%w", op, ErrInvalidClient)
+		}
+		return TokenResult{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	// конфиденциальный клиент обязан предъявить секрет; публичный обходится без него,
+	// код ему защищает PKCE, обязательный для всех клиентов
+	switch {
+	case req.ClientSecret == "" && !app.Public:
+		log.Warn("client secret is required")
+		return TokenResult{}, fmt.Errorf("%s:%w", op, ErrInvalidClient)
+	case req.ClientSecret != "" && !appsecret.Verify(app.SecretHash, req.ClientSecret):
+		log.Warn("invalid client secret")
+		return TokenResult{}, fmt.Errorf("%s:%w", op, ErrInvalidClient)
+	}
+
+	code, err := o.codes.ConsumeCode(ctx, hashCode(req.Code))
+	if err != nil {
+		if errors.Is(err, repository.ErrCodeNotFound) {
+			log.Warn("unknown or already used code")
+			return TokenResult{}, fmt.Errorf("%s:%w", op, ErrInvalidGrant)
+		}
+		log.Error("failed to consume code", sl.Err(err))
+		return TokenResult{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	switch {
+	case time.Now().After(code.ExpiresAt):
+		log.Warn("code expired")
+		return TokenResult{}, fmt.Errorf("%s: code expired: %w", op, ErrInvalidGrant)
+	case code.AppID != req.AppID:
+		log.Warn("code was issued to another client")
+		return TokenResult{}, fmt.Errorf("%s: client mismatch: %w", op, ErrInvalidGrant)
+	case code.RedirectURI != req.RedirectURI:
+		log.Warn("redirect_uri mismatch")
+		return TokenResult{}, fmt.Errorf("%s: redirect_uri mismatch: %w", op, ErrInvalidGrant)
+	case !verifyCodeChallenge(code.CodeChallenge, req.CodeVerifier):
+		log.Warn("PKCE verification failed")
+		return TokenResult{}, fmt.Errorf("%s: code_verifier mismatch: %w", op, ErrInvalidGrant)
+	}
+
+	user, err := o.userProvider.UserByID(ctx, code.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return TokenResult{}, fmt.Errorf("%s:%w", op, ErrInvalidGrant)
+		}
+		log.Error("failed to get user", sl.Err(err))
+		return TokenResult{}, fmt.Errorf("%s:%w", op, err)
+	}
+	// пользователя могли заблокировать после выдачи кода
+	if user.Disabled() {
+		log.Warn("user is disabled", slog.Int64("user_id", user.ID))
+		return TokenResult{}, fmt.Errorf("%s:%w", op, ErrInvalidGrant)
+	}
+
+	token, err := jwt.NewScopedToken(user, app, code.Scopes, o.jwtSecret, o.tokenTTL.Get())
+	if err != nil {
+		log.Error("failed to sign token", sl.Err(err))
+		return TokenResult{}, fmt.Errorf("%s:%w", op, err)
+	}
+	metrics.TokenIssued(metrics.TokenAccess)
+
+	result := TokenResult{AccessToken: token, Scopes: code.Scopes}
+
+	if slices.Contains(code.Scopes, ScopeOpenID) {
+		result.IDToken, err = o.signIDToken(app, jwt.IDTokenClaims{
+			Issuer:      o.issuer,
+			Subject:     strconv.FormatInt(user.ID, 10),
+			Audience:    strconv.Itoa(app.ID),
+			AuthTime:    code.AuthTime,
+			Nonce:       code.Nonce,
+			AccessToken: token,
+			Extra:       UserInfoClaims(user, code.Scopes),
+		})
+		if err != nil {
+			log.Error("failed to sign id_token", sl.Err(err))
+			return TokenResult{}, fmt.Errorf("%s:%w", op, err)
+		}
+		metrics.TokenIssued(metrics.TokenID)
+	}
+
+	log.Info("authorization code exchanged", slog.Int64("user_id", user.ID))
+
+	return result, nil
+}
+
+// signIDToken подписывает id_token ключом сервиса (RS256), а для приложений
+// AppSecretSigning — их секретом (HS256), который для этого хранится зашифрованным
+func (o *OAuth) signIDToken(app model.App, claims jwt.IDTokenClaims) (string, error) {
+	if app.SecretMode != model.AppSecretSigning {
+		return o.idTokens.Sign(claims, o.tokenTTL.Get())
+	}
+
+	secret, err := o.envelope.Open(app.SecretEnc)
+	if err != nil {
+		return "", fmt.Errorf("open secret of app %d: %w", app.ID, err)
+	}
+
+	return jwt.SignIDTokenHS256(claims, secret, o.tokenTTL.Get())
+}
+
+// UserInfo возвращает claims пользователя для /userinfo по токену доступа со scope openid
+func (o *OAuth) UserInfo(ctx context.Context, accessToken string) (map[string]any, error) {
+	const op = "oauth.UserInfo"
+
+	claims, err := jwt.ParseToken(accessToken, o.jwtSecret)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrInvalidToken, err)
+	}
+
+	if !slices.Contains(claims.Scopes, ScopeOpenID) {
+		return nil, fmt.Errorf("%s:%w", op, ErrInsufficientScope)
+	}
+
+	user, err := o.userProvider.UserByID(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, fmt.Errorf("%s:%w", op, ErrInvalidToken)
+		}
+		sl.FromContext(ctx, o.log).Error("failed to get user", slog.String("op", op), sl.Err(err))
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+	if user.Disabled() {
+		return nil, fmt.Errorf("%s:%w", op, ErrInvalidToken)
+	}
+
+	info := UserInfoClaims(user, claims.Scopes)
+	info["sub"] = strconv.FormatInt(user.ID, 10)
+
+	return info, nil
+}
+
+// UserInfoClaims возвращает claims пользователя, разрешённые scopes (OpenID Connect Core, раздел 5.4)
+func UserInfoClaims(user model.User, scopes []string) map[string]any {
+	claims := map[string]any{}
+
+	if slices.Contains(scopes, ScopeEmail) {
+		claims["email"] = user.Email
+	}
+	if slices.Contains(scopes, ScopeProfile) {
+		claims["updated_at"] = user.UpdatedAt.Unix()
+		maps.Copy(claims, jwt.ProfileClaims(user))
+	}
+
+	return claims
+}
+
+// issueCode сохраняет хеш нового кода и возвращает сам код
+func (o *OAuth) issueCode(ctx context.Context, userID int64, authTime time.Time, req model.AuthorizationRequest) (string, error) {
+	code, err := random.Token(authCodeLen)
+	if err != nil {
+		return "", err
+	}
+
+	err = o.codes.SaveCode(ctx, model.AuthorizationCode{
+		CodeHash:      hashCode(code),
+		AppID:         req.AppID,
+		UserID:        userID,
+		RedirectURI:   req.RedirectURI,
+		Scopes:        req.Scopes,
+		CodeChallenge: req.CodeChallenge,
+		Nonce:         req.Nonce,
+		AuthTime:      authTime,
+		ExpiresAt:     time.Now().Add(o.codeTTL.Get()),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return code, nil
+}
+
+func hashCode(code string) []byte {
+	sum := sha256.Sum256([]byte(code))
+	return sum[:]
+}
+
+// verifyCodeChallenge проверяет BASE64URL(SHA256(code_verifier)) == code_challenge
+func verifyCodeChallenge(challenge, verifier string) bool {
+	if verifier == "" {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+func containsAll(have, want []string) bool {
+	for _, s := range want {
+		if !slices.Contains(have, s) {
+			return false
+		}
+	}
+	return true
+}
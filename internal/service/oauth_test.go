@@ -0,0 +1,301 @@
+package service_test
+
+import (
+	"auth-service/gen/apikeys"
+	"auth-service/gen/profile"
+	"auth-service/internal/appsecret"
+	"auth-service/internal/dynamic"
+	"auth-service/internal/grpc/grpcauth"
+	"auth-service/internal/model"
+	"auth-service/internal/repository"
+	"auth-service/internal/service"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ILmira-116/protos/gen/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	confidentialApp = 1
+	publicApp       = 2
+	signingApp      = 3
+
+	oauthRedirectURI  = "https://client.example/callback"
+	oauthClientSecret = "client-secret"
+	oauthUser         = "dana@example.com"
+)
+
+func newOAuth(t *testing.T) (*service.OAuth, *memCodes, *memUsers) {
+	t.Helper()
+
+	users := newMemUsers()
+	userID, err := users.SaveUser(context.Background(), oauthUser, []byte("hash"))
+	require.NoError(t, err)
+
+	app := model.App{
+		SecretHash:   appsecret.Hash(oauthClientSecret),
+		Scopes:       []string{service.ScopeProfile, "orders:read"},
+		RedirectURIs: []string{oauthRedirectURI},
+	}
+	confidential, public, signing := app, app, app
+	confidential.ID = confidentialApp
+	public.ID, public.Public = publicApp, true
+
+	envelope, err := appsecret.NewEnvelope(bytes.Repeat([]byte{7}, 32))
+	require.NoError(t, err)
+	signing.ID, signing.SecretMode = signingApp, model.AppSecretSigning
+	signing.Scopes = []string{service.ScopeOpenID, service.ScopeProfile}
+	signing.SecretEnc, err = envelope.Seal(oauthClientSecret)
+	require.NoError(t, err)
+
+	// согласие уже дано, Authorize сразу выдаёт код
+	consents := &memConsents{scopes: map[int][]string{
+		confidentialApp: app.Scopes,
+		publicApp:       app.Scopes,
+		signingApp:      signing.Scopes,
+	}, userID: userID}
+
+	codes := &memCodes{codes: make(map[string]model.AuthorizationCode)}
+
+	o := service.NewOAuth(
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		userLookup{users},
+		users,
+		apps{confidentialApp: confidential, publicApp: public, signingApp: signing},
+		codes,
+		consents,
+		nil,
+		envelope,
+		"https://auth.example.com",
+		jwtSecret,
+		dynamic.NewDuration(time.Hour),
+		dynamic.NewDuration(time.Minute),
+		dynamic.NewDuration(time.Minute),
+	)
+
+	return o, codes, users
+}
+
+func TestOAuth_RejectsScopeOutsideApp(t *testing.T) {
+	o, _, _ := newOAuth(t)
+
+	req, _ := authorizationRequest(confidentialApp, service.ScopeProfile, "admin")
+
+	_, err := o.ValidateAuthorization(context.Background(), req)
+	require.ErrorIs(t, err, service.ErrInvalidScope)
+
+	_, err = o.Authorize(context.Background(), req, oauthUser, "pass")
+	require.ErrorIs(t, err, service.ErrInvalidScope)
+}
+
+func TestOAuth_RejectsEmptyScope(t *testing.T) {
+	o, _, _ := newOAuth(t)
+
+	req, _ := authorizationRequest(confidentialApp)
+
+	_, err := o.Authorize(context.Background(), req, oauthUser, "pass")
+	require.ErrorIs(t, err, service.ErrInvalidScope)
+}
+
+func TestOAuth_TokenWithoutScopesIsNotASession(t *testing.T) {
+	ctx := context.Background()
+	o, store, users := newOAuth(t)
+
+	// код без scopes, выданный до того, как scope стал обязательным
+	req, verifier := authorizationRequest(confidentialApp)
+	require.NoError(t, store.SaveCode(ctx, model.AuthorizationCode{
+		CodeHash:      sha256Sum("legacy-code"),
+		AppID:         confidentialApp,
+		UserID:        1,
+		RedirectURI:   oauthRedirectURI,
+		CodeChallenge: req.CodeChallenge,
+		ExpiresAt:     time.Now().Add(time.Minute),
+	}))
+
+	res, err := o.Exchange(ctx, service.TokenRequest{
+		Code:         "legacy-code",
+		RedirectURI:  oauthRedirectURI,
+		AppID:        confidentialApp,
+		ClientSecret: oauthClientSecret,
+		CodeVerifier: verifier,
+	})
+	require.NoError(t, err)
+
+	authenticator := grpcauth.New(slog.New(slog.NewTextHandler(io.Discard, nil)), jwtSecret, users, grpcauth.DefaultRules)
+	md := metadata.Pairs("authorization", "Bearer "+res.AccessToken)
+
+	methods := map[string]any{
+		auth.Auth_IsAdmin_FullMethodName:            &auth.IsAdminRequest{UserId: 1},
+		apikeys.APIKeys_CreateAPIKey_FullMethodName: &apikeys.CreateAPIKeyRequest{},
+		profile.Profile_UpdateMe_FullMethodName:     &profile.UpdateMeRequest{},
+	}
+	for method, req := range methods {
+		_, err := authenticator.Authorize(metadata.NewIncomingContext(ctx, md), method, req)
+		assert.Equal(t, codes.PermissionDenied, status.Code(err), method)
+	}
+}
+
+func TestOAuth_ExchangeRequiresSecretOfConfidentialClient(t *testing.T) {
+	ctx := context.Background()
+	o, _, _ := newOAuth(t)
+
+	req := authorizeCode(t, o, confidentialApp)
+
+	// неверный и отсутствующий секрет отклоняются до погашения кода
+	req.ClientSecret = "wrong-secret"
+	_, err := o.Exchange(ctx, req)
+	require.ErrorIs(t, err, service.ErrInvalidClient)
+
+	req.ClientSecret = ""
+	_, err = o.Exchange(ctx, req)
+	require.ErrorIs(t, err, service.ErrInvalidClient)
+
+	req.ClientSecret = oauthClientSecret
+	res, err := o.Exchange(ctx, req)
+	require.NoError(t, err)
+	assert.NotEmpty(t, res.AccessToken)
+	assert.Equal(t, []string{service.ScopeProfile}, res.Scopes)
+}
+
+func TestOAuth_PublicClientExchangesWithPKCEOnly(t *testing.T) {
+	ctx := context.Background()
+	o, _, _ := newOAuth(t)
+
+	// без верного code_verifier публичному клиенту код не обменять
+	req := authorizeCode(t, o, publicApp)
+	req.CodeVerifier = strings.Repeat("x", 43)
+	_, err := o.Exchange(ctx, req)
+	require.ErrorIs(t, err, service.ErrInvalidGrant)
+
+	res, err := o.Exchange(ctx, authorizeCode(t, o, publicApp))
+	require.NoError(t, err)
+	assert.NotEmpty(t, res.AccessToken)
+}
+
+func TestOAuth_SigningAppGetsIDTokenSignedWithItsSecret(t *testing.T) {
+	ctx := context.Background()
+	o, _, _ := newOAuth(t)
+
+	req, verifier := authorizationRequest(signingApp, service.ScopeOpenID, service.ScopeProfile)
+	res, err := o.Authorize(ctx, req, oauthUser, "pass")
+	require.NoError(t, err)
+
+	tokens, err := o.Exchange(ctx, service.TokenRequest{
+		Code:         res.Code,
+		RedirectURI:  oauthRedirectURI,
+		AppID:        signingApp,
+		ClientSecret: oauthClientSecret,
+		CodeVerifier: verifier,
+	})
+	require.NoError(t, err)
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(tokens.IDToken, claims, func(token *jwt.Token) (any, error) {
+		return []byte(oauthClientSecret), nil
+	}, jwt.WithValidMethods([]string{"HS256"}))
+	require.NoError(t, err)
+	assert.Equal(t, "3", claims["aud"])
+}
+
+// authorizationRequest возвращает запрос /authorize с PKCE и его code_verifier
+func authorizationRequest(appID int, scopes ...string) (model.AuthorizationRequest, string) {
+	verifier := strings.Repeat("v", 43) + strings.Join(scopes, "")
+	sum := sha256.Sum256([]byte(verifier))
+
+	return model.AuthorizationRequest{
+		AppID:               appID,
+		RedirectURI:         oauthRedirectURI,
+		Scopes:              scopes,
+		CodeChallenge:       base64.RawURLEncoding.EncodeToString(sum[:]),
+		CodeChallengeMethod: service.CodeChallengeS256,
+	}, verifier
+}
+
+// authorizeCode выдаёт код со scope profile и возвращает запрос его обмена без секрета
+func authorizeCode(t *testing.T, o *service.OAuth, appID int) service.TokenRequest {
+	t.Helper()
+
+	req, verifier := authorizationRequest(appID, service.ScopeProfile)
+
+	res, err := o.Authorize(context.Background(), req, oauthUser, "pass")
+	require.NoError(t, err)
+	require.NotEmpty(t, res.Code)
+
+	return service.TokenRequest{
+		Code:         res.Code,
+		RedirectURI:  oauthRedirectURI,
+		AppID:        appID,
+		CodeVerifier: verifier,
+	}
+}
+
+func sha256Sum(s string) []byte {
+	sum := sha256.Sum256([]byte(s))
+	return sum[:]
+}
+
+// userLookup принимает любой пароль: проверка учётных данных здесь не нужна
+type userLookup struct {
+	users *memUsers
+}
+
+func (u userLookup) Authenticate(ctx context.Context, email, _ string, _ int) (model.User, error) {
+	return u.users.GetUser(ctx, email)
+}
+
+type memCodes struct {
+	mu    sync.Mutex
+	codes map[string]model.AuthorizationCode
+}
+
+func (m *memCodes) SaveCode(_ context.Context, code model.AuthorizationCode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.codes[string(code.CodeHash)] = code
+	return nil
+}
+
+func (m *memCodes) ConsumeCode(_ context.Context, codeHash []byte) (model.AuthorizationCode, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	code, ok := m.codes[string(codeHash)]
+	if !ok {
+		return model.AuthorizationCode{}, repository.ErrCodeNotFound
+	}
+	delete(m.codes, string(codeHash))
+
+	return code, nil
+}
+
+type memConsents struct {
+	userID int64
+	scopes map[int][]string
+}
+
+func (m *memConsents) Consent(_ context.Context, userID int64, appID int) ([]string, error) {
+	if userID != m.userID {
+		return nil, nil
+	}
+	return m.scopes[appID], nil
+}
+
+func (m *memConsents) SaveConsent(_ context.Context, _ int64, appID int, scopes []string) error {
+	m.scopes[appID] = scopes
+	return nil
+}
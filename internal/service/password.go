@@ -0,0 +1,28 @@
+package service
+
+import (
+	"auth-service/internal/metrics"
+	"auth-service/internal/tracing"
+	"context"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// hashPassword хеширует пароль bcrypt и учитывает время в метриках и трассе
+func hashPassword(ctx context.Context, password string) ([]byte, error) {
+	_, span := tracing.Start(ctx, "bcrypt.hash")
+	defer span.End()
+	defer metrics.ObserveBcrypt(metrics.BcryptHash, time.Now())
+
+	return bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+}
+
+// comparePassword сверяет пароль с bcrypt-хешем и учитывает время в метриках и трассе
+func comparePassword(ctx context.Context, hash []byte, password string) error {
+	_, span := tracing.Start(ctx, "bcrypt.compare")
+	defer span.End()
+	defer metrics.ObserveBcrypt(metrics.BcryptCompare, time.Now())
+
+	return bcrypt.CompareHashAndPassword(hash, []byte(password))
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"auth-service/internal/logger/sl"
+	"auth-service/internal/model"
+	"auth-service/internal/repository"
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+type ProfileStore interface {
+	UserByIDProvider
+	UpdateProfile(ctx context.Context, user model.User) (model.User, error)
+	AppAttributes(ctx context.Context, userID int64, appID int) (map[string]any, error)
+	SetAppAttributes(ctx context.Context, userID int64, appID int, attrs map[string]any) error
+}
+
+// ProfileUpdate — поля профиля, nil означает «не менять».
+// Attributes, если не nil, полностью заменяют атрибуты в приложении.
+type ProfileUpdate struct {
+	DisplayName *string
+	Locale      *string
+	Timezone    *string
+	AvatarURL   *string
+	Attributes  map[string]any
+}
+
+// Profile — самообслуживание пользователя: просмотр и изменение своего профиля
+type Profile struct {
+	log         *slog.Logger
+	store       ProfileStore
+	appProvider AppProvider
+}
+
+// NewProfile returns a new instance of the Profile service.
+func NewProfile(log *slog.Logger, store ProfileStore, appProvider AppProvider) *Profile {
+	return &Profile{
+		log:         log,
+		store:       store,
+		appProvider: appProvider,
+	}
+}
+
+// GetMe возвращает профиль пользователя и его атрибуты в приложении appID
+func (p *Profile) GetMe(ctx context.Context, userID int64, appID int) (model.Profile, error) {
+	const op = "profile.GetMe"
+
+	user, err := p.activeUser(ctx, userID)
+	if err != nil {
+		return model.Profile{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	attrs, err := p.store.AppAttributes(ctx, userID, appID)
+	if err != nil {
+		sl.FromContext(ctx, p.log).Error("failed to get attributes", slog.String("op", op), sl.Err(err))
+		return model.Profile{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	return model.Profile{User: user, AppID: appID, Attributes: attrs}, nil
+}
+
+// UpdateMe меняет заданные поля профиля и атрибуты пользователя в приложении appID
+func (p *Profile) UpdateMe(ctx context.Context, userID int64, appID int, update ProfileUpdate) (model.Profile, error) {
+	const op = "profile.UpdateMe"
+
+	log := sl.FromContext(ctx, p.log).With(
+		slog.String("op", op),
+		slog.Int64("user_id", userID),
+		slog.Int("app_id", appID),
+	)
+
+	user, err := p.activeUser(ctx, userID)
+	if err != nil {
+		return model.Profile{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	changed := setField(&user.DisplayName, update.DisplayName)
+	changed = setField(&user.Locale, update.Locale) || changed
+	changed = setField(&user.Timezone, update.Timezone) || changed
+	changed = setField(&user.AvatarURL, update.AvatarURL) || changed
+
+	if changed {
+		if user, err = p.store.UpdateProfile(ctx, user); err != nil {
+			log.Error("failed to update profile", sl.Err(err))
+			return model.Profile{}, fmt.Errorf("%s:%w", op, err)
+		}
+	}
+
+	if update.Attributes != nil {
+		// атрибуты ссылаются на приложение, токен удалённого приложения их не меняет
+		if _, err := p.appProvider.App(ctx, appID); err != nil {
+			return model.Profile{}, fmt.Errorf("%s:%w", op, err)
+		}
+		if err := p.store.SetAppAttributes(ctx, userID, appID, update.Attributes); err != nil {
+			log.Error("failed to set attributes", sl.Err(err))
+			return model.Profile{}, fmt.Errorf("%s:%w", op, err)
+		}
+	}
+
+	attrs, err := p.store.AppAttributes(ctx, userID, appID)
+	if err != nil {
+		log.Error("failed to get attributes", sl.Err(err))
+		return model.Profile{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	log.Info("profile updated")
+
+	return model.Profile{User: user, AppID: appID, Attributes: attrs}, nil
+}
+
+// activeUser возвращает пользователя токена; заблокированный получает ErrUserDisabled
+func (p *Profile) activeUser(ctx context.Context, userID int64) (model.User, error) {
+	user, err := p.store.UserByID(ctx, userID)
+	if err != nil {
+		return model.User{}, err
+	}
+	if user.Disabled() {
+		return model.User{}, repository.ErrUserDisabled
+	}
+
+	return user, nil
+}
+
+// setField записывает value в dst, если оно задано, и сообщает, изменилось ли поле
+func setField(dst, value *string) bool {
+	if value == nil || *value == *dst {
+		return false
+	}
+	*dst = *value
+	return true
+}
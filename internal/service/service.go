@@ -1,17 +1,21 @@
 package service
 
 import (
+	"auth-service/internal/appsecret"
+	"auth-service/internal/dynamic"
+	"auth-service/internal/emailaddr"
 	"auth-service/internal/jwt"
 	"auth-service/internal/logger/sl"
+	"auth-service/internal/metrics"
 	"auth-service/internal/model"
 	"auth-service/internal/repository"
+	"auth-service/internal/tracing"
 	"context"
 	"errors"
 	"fmt"
 	"log/slog"
-	"time"
-
-	"golang.org/x/crypto/bcrypt"
+	"maps"
+	"slices"
 )
 
 type UserSaver interface {
@@ -28,97 +32,175 @@ type AppProvider interface {
 }
 
 type Auth struct {
-	log         *slog.Logger
-	usrSaver    UserSaver
-	usrProvider UserProvider
-	appProvider AppProvider
-	tokenTTL    time.Duration
-	jwtSecret   string
+	log            *slog.Logger
+	usrSaver       UserSaver
+	usrProvider    UserProvider
+	appProvider    AppProvider
+	authenticators map[string]Authenticator
+	emails         emailaddr.Normalizer
+	tokenTTL       *dynamic.Duration
+	jwtSecret      string
 }
 
 // New returns a new instance of the Auth service.
+// Локальная проверка по таблице users доступна всегда как model.AuthenticatorLocal,
+// authenticators добавляют другие источники учётных данных (например, LDAP).
+// Email при регистрации и входе приводится к каноническому виду emails.
 func New(
 	log *slog.Logger,
 	userSaver UserSaver,
 	userProvider UserProvider,
 	appProvider AppProvider,
-	tokenTTL time.Duration,
+	authenticators map[string]Authenticator,
+	emails emailaddr.Normalizer,
+	tokenTTL *dynamic.Duration,
 	jwtSecret string,
 
 ) *Auth {
+	chain := map[string]Authenticator{
+		model.AuthenticatorLocal: NewLocalAuthenticator(log, userProvider),
+	}
+	maps.Copy(chain, authenticators)
+
 	return &Auth{
-		usrSaver:    userSaver,
-		usrProvider: userProvider,
-		log:         log,
-		appProvider: appProvider,
-		tokenTTL:    tokenTTL,
-		jwtSecret:   jwtSecret,
+		usrSaver:       userSaver,
+		usrProvider:    userProvider,
+		log:            log,
+		appProvider:    appProvider,
+		authenticators: chain,
+		emails:         emails,
+		tokenTTL:       tokenTTL,
+		jwtSecret:      jwtSecret,
 	}
 }
 
-func (a *Auth) Login(ctx context.Context, email, password string, appID int) (string, error) {
+// Login проверяет учётные данные и выдаёт токен приложения appID.
+// Исход каждой попытки учитывается в метриках входа.
+func (a *Auth) Login(ctx context.Context, email, password string, appID int) (token string, err error) {
 	const op = "auth.Login"
 
-	log := a.log.With(
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	defer func() { metrics.ObserveLogin(appID, err) }()
+
+	log := sl.FromContext(ctx, a.log).With(
 		slog.String("op", op),
 		slog.String("username", email),
 	)
 
 	log.Info("attempting to login user")
 
-	// получить пользователя
-	user, err := a.usrProvider.GetUser(ctx, email)
+	// получить приложение в которое пользователь хочет залогинится
+	app, err := a.appProvider.App(ctx, appID)
 	if err != nil {
-		if errors.Is(err, repository.ErrUserNotFound) {
-			a.log.Warn("user not found", sl.Err(err))
-
-			return "", fmt.Errorf("%s:%w", op, repository.ErrInvalidCredentials)
-		}
-
-		a.log.Error("failed to get user", sl.Err(err))
+		return "", fmt.Errorf("%s:%w", op, repository.ErrInvalidCredentials)
+	}
 
+	user, err := a.authenticate(ctx, app, email, password)
+	if err != nil {
 		return "", fmt.Errorf("%s:%w", op, err)
-
 	}
 
-	// проверка пароля
-	err = bcrypt.CompareHashAndPassword(user.PassHash, []byte(password))
-	if err != nil {
-		log.Error("invalid credentials", sl.Err(err))
+	log.Info("user logged in succesfully")
 
+	// создаем токен
+	token, err = jwt.NewToken(user, app, a.jwtSecret, a.tokenTTL.Get())
+	if err != nil {
 		return "", fmt.Errorf("%s:%w", op, repository.ErrInvalidCredentials)
 	}
+	metrics.TokenIssued(metrics.TokenAccess)
+
+	return token, nil
+
+}
+
+// Authenticate проверяет email и пароль источниками учётных данных приложения appID
+// и возвращает пользователя. Неизвестный email и неверный пароль дают ErrInvalidCredentials.
+func (a *Auth) Authenticate(ctx context.Context, email, password string, appID int) (model.User, error) {
+	const op = "auth.Authenticate"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
 
-	// получить приложение в которое пользователь хочет залогинится
 	app, err := a.appProvider.App(ctx, appID)
 	if err != nil {
-		return "", fmt.Errorf("%s:%w", op, repository.ErrInvalidCredentials)
+		if errors.Is(err, repository.ErrAppNotFound) {
+			return model.User{}, fmt.Errorf("%s:%w", op, repository.ErrInvalidCredentials)
+		}
+		return model.User{}, fmt.Errorf("%s:%w", op, err)
 	}
 
-	log.Info("user logged in succesfully")
+	return a.authenticate(ctx, app, email, password)
+}
 
-	// создаем токен
-	token, err := jwt.NewToken(user, app, a.jwtSecret, a.tokenTTL)
+// authenticate пробует источники учётных данных приложения по порядку.
+// Неверные учётные данные передают проверку следующему источнику,
+// остальные ошибки (например, недоступен LDAP) прерывают вход.
+// Заблокированный пользователь получает ErrUserDisabled.
+func (a *Auth) authenticate(ctx context.Context, app model.App, email, password string) (model.User, error) {
+	const op = "auth.authenticate"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := sl.FromContext(ctx, a.log).With(
+		slog.String("op", op),
+		slog.Int("app_id", app.ID),
+	)
+
+	// адрес, который нельзя нормализовать, не может принадлежать ни одному пользователю
+	email, err := a.emails.Normalize(email)
 	if err != nil {
-		return "", fmt.Errorf("%s:%w", op, repository.ErrInvalidCredentials)
+		log.Warn("invalid email")
+		return model.User{}, fmt.Errorf("%s:%w", op, repository.ErrInvalidCredentials)
 	}
 
-	return token, nil
+	for _, name := range defaultAuthenticators(app.Authenticators) {
+		authenticator, ok := a.authenticators[name]
+		if !ok {
+			log.Warn("authenticator is not configured", slog.String("authenticator", name))
+			continue
+		}
 
+		user, err := authenticator.Authenticate(ctx, email, password)
+		if err == nil {
+			if user.Disabled() {
+				log.Warn("user is disabled", slog.Int64("user_id", user.ID))
+				return model.User{}, fmt.Errorf("%s:%w", op, repository.ErrUserDisabled)
+			}
+			return user, nil
+		}
+		if !errors.Is(err, repository.ErrInvalidCredentials) {
+			return model.User{}, fmt.Errorf("%s:%w", op, err)
+		}
+	}
+
+	return model.User{}, fmt.Errorf("%s:%w", op, repository.ErrInvalidCredentials)
 }
 
 func (a *Auth) Register(ctx context.Context, email, password string) (int64, error) {
 	const op = "auth.Register"
 
-	log := a.log.With(
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := sl.FromContext(ctx, a.log).With(
 		slog.String("op", op),
 		slog.String("email", email),
 	)
 
 	log.Info("registering user")
 
+	email, err := a.emails.Normalize(email)
+	if err != nil {
+		log.Warn("invalid email")
+
+		return 0, fmt.Errorf("%s:%w", op, repository.ErrInvalidEmail)
+	}
+
 	// хешируем пароль
-	passHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	passHash, err := hashPassword(ctx, password)
 	if err != nil {
 		log.Error("failed to generate password hash", sl.Err(err))
 
@@ -129,11 +211,11 @@ func (a *Auth) Register(ctx context.Context, email, password string) (int64, err
 	id, err := a.usrSaver.SaveUser(ctx, email, passHash)
 	if err != nil {
 		if errors.Is(err, repository.ErrUserExists) {
-			a.log.Warn("user already exists", sl.Err(err))
+			sl.FromContext(ctx, a.log).Warn("user already exists", sl.Err(err))
 			return 0, err
 		}
 
-		a.log.Error("failed to save user", sl.Err(err))
+		sl.FromContext(ctx, a.log).Error("failed to save user", sl.Err(err))
 		return 0, fmt.Errorf("%s:%w", op, err)
 	}
 
@@ -145,22 +227,25 @@ func (a *Auth) Register(ctx context.Context, email, password string) (int64, err
 func (a *Auth) IsAdmin(ctx context.Context, userID int64) (bool, error) {
 	const op = "auth.IsAdmin"
 
-	log := a.log.With(
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := sl.FromContext(ctx, a.log).With(
 		slog.String("op", op),
-		slog.Int64("email", userID),
+		slog.Int64("user_id", userID),
 	)
 
 	log.Info("checking if user is admin")
 
 	isAdmin, err := a.usrProvider.IsAdmin(ctx, userID)
 	if err != nil {
-		if errors.Is(err, repository.ErrAppNotFound) {
-			a.log.Warn("user not found", sl.Err(err))
+		if errors.Is(err, repository.ErrUserNotFound) {
+			log.Warn("user not found", sl.Err(err))
 
-			return false, fmt.Errorf("%s:%w", op, repository.ErrAppNotFound)
+			return false, fmt.Errorf("%s:%w", op, repository.ErrUserNotFound)
 		}
 
-		a.log.Error("failed to get user", sl.Err(err))
+		sl.FromContext(ctx, a.log).Error("failed to get user", sl.Err(err))
 
 		return false, fmt.Errorf("%s:%w", op, err)
 
@@ -171,3 +256,62 @@ func (a *Auth) IsAdmin(ctx context.Context, userID int64) (bool, error) {
 	return isAdmin, nil
 
 }
+
+// ClientCredentials аутентифицирует приложение по id и секрету и выдаёт машинный токен.
+// Если scopes пуст, токен получает все права приложения.
+func (a *Auth) ClientCredentials(ctx context.Context, appID int, secret string, scopes []string) (string, []string, error) {
+	const op = "auth.ClientCredentials"
+
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	log := sl.FromContext(ctx, a.log).With(
+		slog.String("op", op),
+		slog.Int("app_id", appID),
+	)
+
+	log.Info("issuing client credentials token")
+
+	app, err := a.appProvider.App(ctx, appID)
+	if err != nil {
+		if errors.Is(err, repository.ErrAppNotFound) {
+			log.Warn("app not found", sl.Err(err))
+
+			return "", nil, fmt.Errorf("%s:%w", op, ErrInvalidClient)
+		}
+
+		log.Error("failed to get app", sl.Err(err))
+
+		return "", nil, fmt.Errorf("%s:%w", op, err)
+	}
+
+	if !appsecret.Verify(app.SecretHash, secret) {
+		log.Warn("invalid client secret")
+
+		return "", nil, fmt.Errorf("%s:%w", op, ErrInvalidClient)
+	}
+
+	granted := app.Scopes
+	if len(scopes) > 0 {
+		for _, scope := range scopes {
+			if !slices.Contains(app.Scopes, scope) {
+				log.Warn("scope is not granted to app", slog.String("scope", scope))
+
+				return "", nil, fmt.Errorf("%s:%w", op, ErrInvalidScope)
+			}
+		}
+		granted = scopes
+	}
+
+	token, err := jwt.NewClientToken(app, granted, a.jwtSecret, a.tokenTTL.Get())
+	if err != nil {
+		log.Error("failed to sign token", sl.Err(err))
+
+		return "", nil, fmt.Errorf("%s:%w", op, err)
+	}
+	metrics.TokenIssued(metrics.TokenClient)
+
+	log.Info("client credentials token issued")
+
+	return token, granted, nil
+}
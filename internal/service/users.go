@@ -0,0 +1,183 @@
+package service
+
+import (
+	"auth-service/internal/emailaddr"
+	"auth-service/internal/logger/sl"
+	"auth-service/internal/model"
+	"auth-service/internal/repository"
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+)
+
+type UserManager interface {
+	UserByID(ctx context.Context, userID int64) (model.User, error)
+	ListUsers(ctx context.Context, emailPrefix string, afterID int64, limit int) ([]model.User, error)
+	// изменения записывают audit в журнал аудита в той же транзакции
+	UpdateUser(ctx context.Context, user model.User, audit model.AuditEntry) (model.User, error)
+	SetDisabled(ctx context.Context, userID int64, disabled bool, audit model.AuditEntry) error
+	DeleteUser(ctx context.Context, userID int64, audit model.AuditEntry) error
+}
+
+// UserUpdate — изменяемые администратором поля, nil означает «не менять»
+type UserUpdate struct {
+	Email   *string
+	IsAdmin *bool
+}
+
+// Users — администрирование пользователей
+type Users struct {
+	log    *slog.Logger
+	users  UserManager
+	emails emailaddr.Normalizer
+}
+
+// NewUsers returns a new instance of the Users service.
+func NewUsers(log *slog.Logger, users UserManager, emails emailaddr.Normalizer) *Users {
+	return &Users{
+		log:    log,
+		users:  users,
+		emails: emails,
+	}
+}
+
+func (u *Users) GetUser(ctx context.Context, userID int64) (model.User, error) {
+	const op = "users.GetUser"
+
+	user, err := u.users.UserByID(ctx, userID)
+	if err != nil {
+		return model.User{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	return user, nil
+}
+
+// ListUsers возвращает страницу пользователей после afterID с email, начинающимся на emailPrefix
+func (u *Users) ListUsers(ctx context.Context, emailPrefix string, afterID int64, limit int) ([]model.User, error) {
+	const op = "users.ListUsers"
+
+	users, err := u.users.ListUsers(ctx, emailPrefix, afterID, limit)
+	if err != nil {
+		sl.FromContext(ctx, u.log).Error("failed to list users", slog.String("op", op), sl.Err(err))
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+
+	return users, nil
+}
+
+// UpdateUser меняет email и флаг администратора. Снять роль с себя нельзя,
+// чтобы последний администратор случайно не остался без доступа.
+func (u *Users) UpdateUser(ctx context.Context, actorID, userID int64, update UserUpdate) (model.User, error) {
+	const op = "users.UpdateUser"
+
+	log := sl.FromContext(ctx, u.log).With(
+		slog.String("op", op),
+		slog.Int64("actor_id", actorID),
+		slog.Int64("user_id", userID),
+	)
+
+	user, err := u.users.UserByID(ctx, userID)
+	if err != nil {
+		return model.User{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	details := map[string]any{}
+	if update.Email != nil {
+		email, err := u.emails.Normalize(*update.Email)
+		if err != nil {
+			return model.User{}, fmt.Errorf("%s:%w", op, repository.ErrInvalidEmail)
+		}
+		if email != user.Email {
+			details["email"] = map[string]string{"old": user.Email, "new": email}
+			user.Email = email
+		}
+	}
+	if update.IsAdmin != nil && *update.IsAdmin != user.IsAdmin {
+		if actorID == userID && !*update.IsAdmin {
+			log.Warn("admin tried to demote own account")
+			return model.User{}, fmt.Errorf("%s:%w", op, repository.ErrCannotModifySelf)
+		}
+		details["is_admin"] = *update.IsAdmin
+		user.IsAdmin = *update.IsAdmin
+	}
+
+	if len(details) == 0 {
+		return user, nil
+	}
+
+	user, err = u.users.UpdateUser(ctx, user, userAudit(actorID, model.AuditUserUpdate, userID, details))
+	if err != nil {
+		log.Error("failed to update user", sl.Err(err))
+		return model.User{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	log.Info("user updated")
+
+	return user, nil
+}
+
+// DisableUser запрещает пользователю вход и получение новых токенов.
+// Уже выданные токены действуют до истечения срока.
+func (u *Users) DisableUser(ctx context.Context, actorID, userID int64) error {
+	const op = "users.DisableUser"
+
+	if actorID == userID {
+		return fmt.Errorf("%s:%w", op, repository.ErrCannotModifySelf)
+	}
+
+	if err := u.users.SetDisabled(ctx, userID, true, userAudit(actorID, model.AuditUserDisable, userID, nil)); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	sl.FromContext(ctx, u.log).Info("user disabled", slog.String("op", op), slog.Int64("actor_id", actorID), slog.Int64("user_id", userID))
+
+	return nil
+}
+
+func (u *Users) EnableUser(ctx context.Context, actorID, userID int64) error {
+	const op = "users.EnableUser"
+
+	if err := u.users.SetDisabled(ctx, userID, false, userAudit(actorID, model.AuditUserEnable, userID, nil)); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	sl.FromContext(ctx, u.log).Info("user enabled", slog.String("op", op), slog.Int64("actor_id", actorID), slog.Int64("user_id", userID))
+
+	return nil
+}
+
+// DeleteUser мягко удаляет пользователя: запись остаётся для аудита,
+// но больше не находится, а email можно зарегистрировать заново
+func (u *Users) DeleteUser(ctx context.Context, actorID, userID int64) error {
+	const op = "users.DeleteUser"
+
+	if actorID == userID {
+		return fmt.Errorf("%s:%w", op, repository.ErrCannotModifySelf)
+	}
+
+	user, err := u.users.UserByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	audit := userAudit(actorID, model.AuditUserDelete, userID, map[string]any{"email": user.Email})
+	if err := u.users.DeleteUser(ctx, userID, audit); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	sl.FromContext(ctx, u.log).Info("user deleted", slog.String("op", op), slog.Int64("actor_id", actorID), slog.Int64("user_id", userID))
+
+	return nil
+}
+
+// userAudit — запись журнала аудита об изменении пользователя
+func userAudit(actorID int64, action string, userID int64, details map[string]any) model.AuditEntry {
+	return model.AuditEntry{
+		ActorID:  actorID,
+		Action:   action,
+		Entity:   "user",
+		EntityID: strconv.FormatInt(userID, 10),
+		Details:  details,
+	}
+}
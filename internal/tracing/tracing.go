@@ -0,0 +1,132 @@
+// Package tracing настраивает OpenTelemetry: глобальный TracerProvider,
+// распространение контекста W3C Trace Context и экспорт спанов.
+package tracing
+
+import (
+	"auth-service/config"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName — service.name в ресурсе спанов и имя инструментирования
+const ServiceName = "auth-service"
+
+// экспортёры для TRACING_EXPORTER
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// Provider владеет TracerProvider и реализует shutdown.Stoppable,
+// чтобы при остановке отправить накопленные спаны
+type Provider struct {
+	log *slog.Logger
+	tp  *sdktrace.TracerProvider
+}
+
+// New выбирает экспортёр по cfg.Exporter и ставит глобальный TracerProvider.
+// С ExporterNone спаны не записываются, но trace context входящих запросов
+// всё равно передаётся дальше и попадает в логи.
+func New(ctx context.Context, log *slog.Logger, cfg config.TracingConfig) (*Provider, error) {
+	const op = "tracing.New"
+
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var (
+		exporter sdktrace.SpanExporter
+		err      error
+	)
+	switch cfg.Exporter {
+	case ExporterNone, "":
+		return &Provider{log: log}, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterOTLP:
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.OTLPEndpoint)}
+		if cfg.OTLPInsecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		exporter, err = otlptracegrpc.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("%s: unknown exporter %q", op, cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("tracing is enabled", slog.String("exporter", cfg.Exporter))
+
+	return Install(log, sdktrace.NewBatchSpanProcessor(exporter), cfg.SampleRatio), nil
+}
+
+// Install ставит глобальный TracerProvider, который передаёт спаны в processor.
+// sampleRatio — доля корневых трасс, которые записываются; решение родителя
+// из входящего trace context соблюдается. Тесты передают сюда
+// sdktrace.NewSimpleSpanProcessor(tracetest.NewInMemoryExporter()).
+func Install(log *slog.Logger, processor sdktrace.SpanProcessor, sampleRatio float64) *Provider {
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(processor),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName(ServiceName),
+		)),
+	)
+	otel.SetTracerProvider(tp)
+
+	return &Provider{log: log, tp: tp}
+}
+
+// Start открывает дочерний спан name глобального TracerProvider
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(ServiceName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// LogAttrs возвращает trace_id и span_id спана из ctx для логгера запроса;
+// пусто, если в контексте нет трассы
+func LogAttrs(ctx context.Context) []any {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+
+	return []any{
+		slog.String("trace_id", sc.TraceID().String()),
+		slog.String("span_id", sc.SpanID().String()),
+	}
+}
+
+// Shutdown отправляет накопленные спаны и останавливает экспортёр
+func (p *Provider) Shutdown(ctx context.Context) error {
+	const op = "tracing.Shutdown"
+
+	if p.tp == nil {
+		return nil
+	}
+
+	if err := p.tp.Shutdown(ctx); err != nil {
+		p.log.With(slog.String("op", op)).Warn("failed to flush spans", slog.String("err", err.Error()))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Stop ничего не ждёт: если Shutdown не успел, недоставленные спаны теряются
+func (p *Provider) Stop() {}
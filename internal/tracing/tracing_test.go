@@ -0,0 +1,63 @@
+package tracing_test
+
+import (
+	"auth-service/config"
+	"auth-service/internal/tracing"
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestNew_Exporters(t *testing.T) {
+	prev := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	p, err := tracing.New(context.Background(), discard, config.TracingConfig{Exporter: tracing.ExporterNone})
+	require.NoError(t, err)
+	assert.NoError(t, p.Shutdown(context.Background()))
+
+	p, err = tracing.New(context.Background(), discard, config.TracingConfig{Exporter: tracing.ExporterStdout, SampleRatio: 1})
+	require.NoError(t, err)
+	assert.NoError(t, p.Shutdown(context.Background()))
+
+	_, err = tracing.New(context.Background(), discard, config.TracingConfig{Exporter: "zipkin"})
+	assert.Error(t, err)
+}
+
+func TestStart_ContinuesIncomingTrace(t *testing.T) {
+	prev := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	_, err := tracing.New(context.Background(), discard, config.TracingConfig{Exporter: tracing.ExporterNone})
+	require.NoError(t, err)
+
+	exporter := tracetest.NewInMemoryExporter()
+	tracing.Install(discard, sdktrace.NewSimpleSpanProcessor(exporter), 0)
+
+	// вызывающий сервис записывает трассу, поэтому она записывается и здесь, несмотря на долю 0
+	carrier := propagation.MapCarrier{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), carrier)
+
+	ctx, span := tracing.Start(ctx, "repository.GetUser")
+	attrs := tracing.LogAttrs(ctx)
+	span.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent.SpanID().String())
+	assert.Contains(t, attrs, slog.String("trace_id", "4bf92f3577b34da6a3ce929d0e0e4736"))
+
+	// без трассы в контексте атрибутов нет
+	assert.Empty(t, tracing.LogAttrs(context.Background()))
+}
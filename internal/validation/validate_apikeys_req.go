@@ -0,0 +1,75 @@
+package validation
+
+import (
+	"auth-service/gen/apikeys"
+	"auth-service/internal/model"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// maxAPIKeyTTL — ключ нельзя выпустить дольше чем на год
+const maxAPIKeyTTL = 366 * 24 * time.Hour
+
+func ValidateCreateAPIKeyRequest(ctx context.Context, apps *AppChecker, req *apikeys.CreateAPIKeyRequest) error {
+	var v violations
+	checkName(&v, "name", req.GetName())
+	checkAppID(&v, "app_id", req.GetAppId())
+	switch {
+	case req.GetTtlSeconds() < 0:
+		v.add("ttl_seconds", "must not be negative")
+	case req.GetTtlSeconds() > int64(maxAPIKeyTTL/time.Second):
+		v.addf("ttl_seconds", "must not exceed %d", int64(maxAPIKeyTTL/time.Second))
+	}
+	checkScopes(&v, "scopes", req.GetScopes())
+	// scopes ключа сервисного аккаунта ограничивает набор приложения, это проверяет сервис
+	if !req.GetServiceAccount() {
+		checkAPIKeyScopes(&v, "scopes", req.GetScopes())
+	}
+	if err := apps.check(ctx, &v, "app_id", int(req.GetAppId())); err != nil {
+		return err
+	}
+	return v.err()
+}
+
+func ValidateListAPIKeysRequest(ctx context.Context, apps *AppChecker, req *apikeys.ListAPIKeysRequest) error {
+	var v violations
+	checkPageSize(&v, "page_size", req.GetPageSize())
+	checkPageToken(&v, "page_token", req.GetPageToken())
+	if req.GetServiceAccount() {
+		checkAppID(&v, "app_id", req.GetAppId())
+		if err := apps.check(ctx, &v, "app_id", int(req.GetAppId())); err != nil {
+			return err
+		}
+	}
+	return v.err()
+}
+
+func ValidateRevokeAPIKeyRequest(req *apikeys.RevokeAPIKeyRequest) error {
+	var v violations
+	switch {
+	case req.GetKeyId() == emptyvalue:
+		v.add("key_id", "is required")
+	case req.GetKeyId() < 0:
+		v.add("key_id", "must be positive")
+	}
+	return v.err()
+}
+
+func ValidateExchangeAPIKeyRequest(req *apikeys.ExchangeAPIKeyRequest) error {
+	var v violations
+	if v.required("key", req.GetKey()) {
+		v.maxLen("key", req.GetKey(), maxTokenLen)
+	}
+	return v.err()
+}
+
+// checkAPIKeyScopes проверяет, что scopes личного ключа известны
+func checkAPIKeyScopes(v *violations, field string, scopes []string) {
+	for i, scope := range scopes {
+		if !slices.Contains(model.APIKeyScopes, scope) {
+			v.addf(fmt.Sprintf("%s[%d]", field, i), "unknown scope %q", scope)
+		}
+	}
+}
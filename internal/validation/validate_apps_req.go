@@ -0,0 +1,118 @@
+package validation
+
+import (
+	"auth-service/gen/apps"
+	"auth-service/internal/model"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+)
+
+const (
+	maxRedirectURIs   = 20
+	maxRedirectURILen = 2048
+)
+
+func ValidateCreateAppRequest(req *apps.CreateAppRequest) error {
+	var v violations
+	checkName(&v, "name", req.GetName())
+	checkScopes(&v, "scopes", req.GetScopes())
+	checkRedirectURIs(&v, "redirect_uris", req.GetRedirectUris())
+	checkAuthenticators(&v, "authenticators", req.GetAuthenticators())
+	checkSecretMode(&v, "secret_mode", req.GetSecretMode())
+	return v.err()
+}
+
+func ValidateUpdateAppRequest(req *apps.UpdateAppRequest) error {
+	var v violations
+	checkAppID(&v, "app_id", req.GetAppId())
+	checkName(&v, "name", req.GetName())
+	checkScopes(&v, "scopes", req.GetScopes())
+	checkRedirectURIs(&v, "redirect_uris", req.GetRedirectUris())
+	checkAuthenticators(&v, "authenticators", req.GetAuthenticators())
+	return v.err()
+}
+
+func ValidateListAppsRequest(req *apps.ListAppsRequest) error {
+	var v violations
+	checkPageSize(&v, "page_size", req.GetPageSize())
+	checkPageToken(&v, "page_token", req.GetPageToken())
+	return v.err()
+}
+
+func ValidateRotateAppSecretRequest(req *apps.RotateAppSecretRequest) error {
+	var v violations
+	checkAppID(&v, "app_id", req.GetAppId())
+	return v.err()
+}
+
+func ValidateDeleteAppRequest(req *apps.DeleteAppRequest) error {
+	var v violations
+	checkAppID(&v, "app_id", req.GetAppId())
+	return v.err()
+}
+
+func checkName(v *violations, field, name string) {
+	if v.required(field, name) {
+		v.maxLen(field, name, maxNameLen)
+	}
+}
+
+func checkPageSize(v *violations, field string, size int32) {
+	if size < 0 {
+		v.add(field, "must not be negative")
+	}
+}
+
+// checkPageToken проверяет page_token — id последней записи предыдущей страницы
+func checkPageToken(v *violations, field, token string) {
+	if token == "" {
+		return
+	}
+	if id, err := strconv.ParseInt(token, 10, 64); err != nil || id < 0 {
+		v.add(field, "is not a token from a previous response")
+	}
+}
+
+// checkRedirectURIs проверяет, что redirect_uri абсолютные и без фрагмента (RFC 6749, раздел 3.1.2).
+// Для мобильных приложений допустимы собственные схемы вида com.example.app:/callback.
+func checkRedirectURIs(v *violations, field string, uris []string) {
+	if len(uris) > maxRedirectURIs {
+		v.addf(field, "must not have more than %d items", maxRedirectURIs)
+		return
+	}
+	for i, uri := range uris {
+		item := fmt.Sprintf("%s[%d]", field, i)
+		if len(uri) > maxRedirectURILen {
+			v.addf(item, "must not exceed %d bytes", maxRedirectURILen)
+			continue
+		}
+		u, err := url.Parse(uri)
+		if err != nil || !u.IsAbs() || u.Fragment != "" ||
+			((u.Scheme == "http" || u.Scheme == "https") && u.Host == "") {
+			v.add(item, "must be an absolute URI without a fragment")
+		}
+	}
+}
+
+// checkAuthenticators проверяет, что источники учётных данных известны и не повторяются
+func checkAuthenticators(v *violations, field string, authenticators []string) {
+	for i, name := range authenticators {
+		item := fmt.Sprintf("%s[%d]", field, i)
+		if name != model.AuthenticatorLocal && name != model.AuthenticatorLDAP {
+			v.addf(item, "unknown authenticator %q", name)
+			continue
+		}
+		if slices.Contains(authenticators[:i], name) {
+			v.addf(item, "duplicate authenticator %q", name)
+		}
+	}
+}
+
+// checkSecretMode проверяет режим хранения секрета; пустой означает auth_only
+func checkSecretMode(v *violations, field, mode string) {
+	if mode != "" && mode != model.AppSecretAuthOnly && mode != model.AppSecretSigning {
+		v.addf(field, "unknown secret mode %q", mode)
+	}
+}
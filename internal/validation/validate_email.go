@@ -0,0 +1,84 @@
+package validation
+
+import (
+	_ "embed"
+	"net/mail"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// ограничения RFC 5321, раздел 4.5.3.1
+const (
+	maxEmailLen      = 254
+	maxEmailLocalLen = 64
+)
+
+//go:embed disposable_domains.txt
+var disposableDomainsList string
+
+var disposableDomains = parseDomainList(disposableDomainsList)
+
+// checkEmail проверяет синтаксис адреса по RFC 5322 (только addr-spec, без
+// отображаемого имени) и ограничения длины. Если allowDisposable == false,
+// адреса одноразовой почты отклоняются.
+func checkEmail(v *violations, field, email string, allowDisposable bool) {
+	if !v.required(field, strings.TrimSpace(email)) {
+		return
+	}
+	email = strings.TrimSpace(email)
+	if len(email) > maxEmailLen {
+		v.addf(field, "must not exceed %d bytes", maxEmailLen)
+		return
+	}
+
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Name != "" || strings.ContainsAny(email, "<>") {
+		v.add(field, "must be a valid email address")
+		return
+	}
+
+	at := strings.LastIndexByte(addr.Address, '@')
+	local, domain := addr.Address[:at], addr.Address[at+1:]
+	if len(local) > maxEmailLocalLen {
+		v.addf(field, "local part must not exceed %d bytes", maxEmailLocalLen)
+		return
+	}
+
+	// адрес-литерал [192.0.2.1] и домены с недопустимыми метками не принимаем
+	domain, err = idna.Lookup.ToASCII(strings.TrimSuffix(domain, "."))
+	if err != nil || domain == "" {
+		v.add(field, "must be a valid email address")
+		return
+	}
+
+	if !allowDisposable && isDisposable(domain) {
+		v.add(field, "must not use a disposable email domain")
+	}
+}
+
+// isDisposable сообщает, что домен или один из его родительских доменов в списке
+func isDisposable(domain string) bool {
+	for {
+		if _, ok := disposableDomains[domain]; ok {
+			return true
+		}
+		_, parent, ok := strings.Cut(domain, ".")
+		if !ok {
+			return false
+		}
+		domain = parent
+	}
+}
+
+func parseDomainList(list string) map[string]struct{} {
+	domains := make(map[string]struct{})
+	for line := range strings.Lines(list) {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		domains[strings.ToLower(line)] = struct{}{}
+	}
+	return domains
+}
@@ -0,0 +1,50 @@
+package validation
+
+import (
+	"auth-service/gen/oauth"
+	"fmt"
+)
+
+// ValidateClientCredentialsRequest не проверяет существование приложения:
+// неизвестный app_id, как и неверный секрет, — invalid client
+func ValidateClientCredentialsRequest(req *oauth.ClientCredentialsRequest) error {
+	var v violations
+	checkAppID(&v, "app_id", req.GetAppId())
+	if v.required("client_secret", req.GetClientSecret()) {
+		v.maxLen("client_secret", req.GetClientSecret(), maxClientSecretLen)
+	}
+	checkScopes(&v, "scopes", req.GetScopes())
+	return v.err()
+}
+
+// checkScopes проверяет, что каждый scope — непустой scope-token по RFC 6749, раздел 3.3
+func checkScopes(v *violations, field string, scopes []string) {
+	if len(scopes) > maxScopes {
+		v.addf(field, "must not have more than %d items", maxScopes)
+		return
+	}
+	for i, scope := range scopes {
+		item := fmt.Sprintf("%s[%d]", field, i)
+		if len(scope) > maxScopeLen {
+			v.addf(item, "must not exceed %d bytes", maxScopeLen)
+			continue
+		}
+		if !isScopeToken(scope) {
+			v.add(item, "must be a scope token (RFC 6749, section 3.3)")
+		}
+	}
+}
+
+func isScopeToken(scope string) bool {
+	if scope == "" {
+		return false
+	}
+	for i := 0; i < len(scope); i++ {
+		c := scope[i]
+		// допустимы %x21 / %x23-5B / %x5D-7E
+		if c < 0x21 || c > 0x7e || c == '"' || c == '\\' {
+			return false
+		}
+	}
+	return true
+}
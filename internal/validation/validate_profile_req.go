@@ -0,0 +1,135 @@
+package validation
+
+import (
+	"auth-service/gen/profile"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"time"
+	// база часовых поясов внутри бинарника: в образе alpine её нет
+	_ "time/tzdata"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/language"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	maxDisplayNameLen     = 100
+	maxAvatarURLLen       = 2048
+	maxAttributes         = 32
+	maxAttributeStringLen = 1024
+	// размер атрибутов приложения в JSON
+	maxAttributesSize = 4096
+)
+
+var attributeKeyRe = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+func ValidateUpdateMeRequest(req *profile.UpdateMeRequest) error {
+	var v violations
+	if req.DisplayName != nil {
+		checkDisplayName(&v, "display_name", req.GetDisplayName())
+	}
+	if req.GetLocale() != "" {
+		if _, err := language.Parse(req.GetLocale()); err != nil {
+			v.add("locale", "must be a BCP 47 language tag")
+		}
+	}
+	if req.GetTimezone() != "" {
+		if _, err := time.LoadLocation(req.GetTimezone()); err != nil {
+			v.add("timezone", "must be an IANA time zone name")
+		}
+	}
+	if req.GetAvatarUrl() != "" {
+		checkAvatarURL(&v, "avatar_url", req.GetAvatarUrl())
+	}
+	if req.GetAttributes() != nil {
+		checkAttributes(&v, "attributes", req.GetAttributes())
+	}
+	return v.err()
+}
+
+func checkDisplayName(v *violations, field, name string) {
+	if utf8.RuneCountInString(name) > maxDisplayNameLen {
+		v.addf(field, "must not exceed %d characters", maxDisplayNameLen)
+		return
+	}
+	for _, r := range name {
+		if unicode.IsControl(r) {
+			v.add(field, "must not contain control characters")
+			return
+		}
+	}
+}
+
+func checkAvatarURL(v *violations, field, raw string) {
+	if len(raw) > maxAvatarURLLen {
+		v.addf(field, "must not exceed %d bytes", maxAvatarURLLen)
+		return
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		v.add(field, "must be an absolute http(s) URL")
+	}
+}
+
+// checkAttributes проверяет атрибуты приложения: плоский объект со строками,
+// числами и булевыми значениями, ограниченный по числу ключей и размеру
+func checkAttributes(v *violations, field string, attrs *structpb.Struct) {
+	fields := attrs.GetFields()
+	if len(fields) > maxAttributes {
+		v.addf(field, "must not have more than %d keys", maxAttributes)
+		return
+	}
+
+	for key, value := range fields {
+		item := fmt.Sprintf("%s.%s", field, key)
+		if !attributeKeyRe.MatchString(key) {
+			v.addf(item, "name must match %s", attributeKeyRe)
+			continue
+		}
+		switch val := value.GetKind().(type) {
+		case *structpb.Value_StringValue:
+			if utf8.RuneCountInString(val.StringValue) > maxAttributeStringLen {
+				v.addf(item, "must not exceed %d characters", maxAttributeStringLen)
+			}
+		case *structpb.Value_NumberValue, *structpb.Value_BoolValue:
+		default:
+			v.add(item, "must be a string, number or boolean")
+		}
+	}
+
+	raw, err := json.Marshal(attrs.AsMap())
+	if err != nil || len(raw) > maxAttributesSize {
+		v.addf(field, "must not exceed %d bytes", maxAttributesSize)
+	}
+}
+
+func ValidateRequestEmailChangeRequest(req *profile.RequestEmailChangeRequest) error {
+	var v violations
+	checkEmail(&v, "new_email", req.GetNewEmail(), false)
+	if v.required("current_password", req.GetCurrentPassword()) {
+		v.maxLen("current_password", req.GetCurrentPassword(), maxPasswordLen)
+	}
+	return v.err()
+}
+
+func ValidateConfirmEmailChangeRequest(req *profile.ConfirmEmailChangeRequest) error {
+	var v violations
+	checkToken(&v, "token", req.GetToken())
+	return v.err()
+}
+
+func ValidateCancelEmailChangeRequest(req *profile.CancelEmailChangeRequest) error {
+	var v violations
+	checkToken(&v, "token", req.GetToken())
+	return v.err()
+}
+
+func checkToken(v *violations, field, token string) {
+	if v.required(field, token) {
+		v.maxLen(field, token, maxTokenLen)
+	}
+}
@@ -1,41 +1,64 @@
 package validation
 
 import (
+	"context"
+
 	"github.com/ILmira-116/protos/gen/auth"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
-const emptyvalue = 0
-
-func ValidateLoginRequest(req *auth.LoginRequest) error {
-	if req.GetEmail() == "" {
-		return status.Error(codes.InvalidArgument, "email is required")
-	}
-	if req.GetPassword() == "" {
-		return status.Error(codes.InvalidArgument, "password is required")
+// ValidateLoginRequest проверяет запрос входа. Одноразовые домены здесь не
+// отклоняются: учётная запись могла появиться до того, как домен попал в список.
+func ValidateLoginRequest(ctx context.Context, apps *AppChecker, req *auth.LoginRequest) error {
+	var v violations
+	checkEmail(&v, "email", req.GetEmail(), true)
+	if v.required("password", req.GetPassword()) {
+		v.maxLen("password", req.GetPassword(), maxPasswordLen)
 	}
-	if req.GetAppId() == emptyvalue {
-		return status.Error(codes.InvalidArgument, "app_id is required")
+	checkAppID(&v, "app_id", req.GetAppId())
+	if err := apps.check(ctx, &v, "app_id", int(req.GetAppId())); err != nil {
+		return err
 	}
-	return nil
+	return v.err()
 }
 
 func ValidateRegisterRequest(req *auth.RegisterRequest) error {
-	if req.GetEmail() == "" {
-		return status.Error(codes.InvalidArgument, "email is required")
+	var v violations
+	checkEmail(&v, "email", req.GetEmail(), false)
+	checkNewPassword(&v, "password", req.GetPassword())
+	return v.err()
+}
+
+func ValidateIsAdminRequest(req *auth.IsAdminRequest) error {
+	var v violations
+	checkUserID(&v, "user_id", req.GetUserId())
+	return v.err()
+}
+
+// checkNewPassword проверяет пароль, который будет захеширован bcrypt
+func checkNewPassword(v *violations, field, password string) {
+	if !v.required(field, password) {
+		return
 	}
-	if req.GetPassword() == "" {
-		return status.Error(codes.InvalidArgument, "password is required")
+	if len(password) < minPasswordLen {
+		v.addf(field, "must be at least %d bytes", minPasswordLen)
 	}
-
-	return nil
+	v.maxLen(field, password, maxPasswordLen)
 }
 
-func ValidateIsAdminRequest(req *auth.IsAdminRequest) error {
-	if req.GetUserId() == emptyvalue {
-		return status.Error(codes.InvalidArgument, "email is required")
+func checkAppID(v *violations, field string, appID int32) {
+	switch {
+	case appID == emptyvalue:
+		v.add(field, "is required")
+	case appID < 0:
+		v.add(field, "must be positive")
 	}
+}
 
-	return nil
+func checkUserID(v *violations, field string, userID int64) {
+	switch {
+	case userID == emptyvalue:
+		v.add(field, "is required")
+	case userID < 0:
+		v.add(field, "must be positive")
+	}
 }
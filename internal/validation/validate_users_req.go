@@ -0,0 +1,48 @@
+package validation
+
+import (
+	"auth-service/gen/users"
+)
+
+func ValidateGetUserRequest(req *users.GetUserRequest) error {
+	var v violations
+	checkUserID(&v, "user_id", req.GetUserId())
+	return v.err()
+}
+
+func ValidateListUsersRequest(req *users.ListUsersRequest) error {
+	var v violations
+	checkPageSize(&v, "page_size", req.GetPageSize())
+	checkPageToken(&v, "page_token", req.GetPageToken())
+	v.maxLen("email_prefix", req.GetEmailPrefix(), maxEmailLen)
+	return v.err()
+}
+
+// ValidateUpdateUserRequest разрешает администратору одноразовые домены:
+// список защищает самостоятельную регистрацию
+func ValidateUpdateUserRequest(req *users.UpdateUserRequest) error {
+	var v violations
+	checkUserID(&v, "user_id", req.GetUserId())
+	if req.Email != nil {
+		checkEmail(&v, "email", req.GetEmail(), true)
+	}
+	return v.err()
+}
+
+func ValidateDisableUserRequest(req *users.DisableUserRequest) error {
+	var v violations
+	checkUserID(&v, "user_id", req.GetUserId())
+	return v.err()
+}
+
+func ValidateEnableUserRequest(req *users.EnableUserRequest) error {
+	var v violations
+	checkUserID(&v, "user_id", req.GetUserId())
+	return v.err()
+}
+
+func ValidateDeleteUserRequest(req *users.DeleteUserRequest) error {
+	var v violations
+	checkUserID(&v, "user_id", req.GetUserId())
+	return v.err()
+}
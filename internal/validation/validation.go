@@ -0,0 +1,124 @@
+// Package validation проверяет входящие gRPC запросы. Ошибка — статус
+// InvalidArgument с деталями errdetails.BadRequest: по одному нарушению на поле,
+// чтобы клиент мог показать их все сразу.
+package validation
+
+import (
+	"auth-service/internal/model"
+	"auth-service/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const emptyvalue = 0
+
+// ограничения длины полей
+const (
+	maxNameLen         = 100
+	maxPasswordLen     = 72 // bcrypt не учитывает байты после 72-го
+	minPasswordLen     = 8
+	maxClientSecretLen = 256
+	maxTokenLen        = 512
+	maxScopes          = 50
+	maxScopeLen        = 128
+)
+
+// violations собирает нарушения по полям запроса
+type violations struct {
+	list []*errdetails.BadRequest_FieldViolation
+}
+
+// add запоминает нарушение; для поля учитывается только первое
+func (v *violations) add(field, description string) {
+	if v.has(field) {
+		return
+	}
+	v.list = append(v.list, &errdetails.BadRequest_FieldViolation{
+		Field:       field,
+		Description: description,
+	})
+}
+
+func (v *violations) addf(field, format string, args ...any) {
+	v.add(field, fmt.Sprintf(format, args...))
+}
+
+func (v *violations) has(field string) bool {
+	for _, fv := range v.list {
+		if fv.GetField() == field {
+			return true
+		}
+	}
+	return false
+}
+
+// required добавляет нарушение для пустой строки и сообщает, что значение есть
+func (v *violations) required(field, value string) bool {
+	if value == "" {
+		v.add(field, "is required")
+		return false
+	}
+	return true
+}
+
+// maxLen проверяет длину значения в байтах
+func (v *violations) maxLen(field, value string, limit int) {
+	if len(value) > limit {
+		v.addf(field, "must not exceed %d bytes", limit)
+	}
+}
+
+// err возвращает InvalidArgument с деталями BadRequest или nil, если нарушений нет
+func (v *violations) err() error {
+	if len(v.list) == 0 {
+		return nil
+	}
+
+	msgs := make([]string, 0, len(v.list))
+	for _, fv := range v.list {
+		msgs = append(msgs, fv.GetField()+" "+fv.GetDescription())
+	}
+	msg := strings.Join(msgs, "; ")
+
+	st, err := status.New(codes.InvalidArgument, msg).WithDetails(&errdetails.BadRequest{FieldViolations: v.list})
+	if err != nil {
+		return status.Error(codes.InvalidArgument, msg)
+	}
+	return st.Err()
+}
+
+type AppProvider interface {
+	App(ctx context.Context, appID int) (model.App, error)
+}
+
+// AppChecker проверяет, что app_id в запросе ссылается на существующее приложение
+type AppChecker struct {
+	apps AppProvider
+}
+
+func NewAppChecker(apps AppProvider) *AppChecker {
+	return &AppChecker{apps: apps}
+}
+
+// check добавляет нарушение для несуществующего приложения. Ошибку хранилища
+// возвращает как Internal: по ней нельзя судить о запросе.
+func (c *AppChecker) check(ctx context.Context, v *violations, field string, appID int) error {
+	if appID == emptyvalue || v.has(field) {
+		return nil
+	}
+
+	if _, err := c.apps.App(ctx, appID); err != nil {
+		if errors.Is(err, repository.ErrAppNotFound) {
+			v.add(field, "does not reference an existing app")
+			return nil
+		}
+		return status.Error(codes.Internal, "internal error")
+	}
+	return nil
+}
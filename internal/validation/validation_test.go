@@ -0,0 +1,131 @@
+package validation_test
+
+import (
+	"auth-service/gen/apikeys"
+	"auth-service/internal/model"
+	"auth-service/internal/repository"
+	"auth-service/internal/validation"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ILmira-116/protos/gen/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type apps map[int]model.App
+
+func (a apps) App(_ context.Context, appID int) (model.App, error) {
+	app, ok := a[appID]
+	if !ok {
+		return model.App{}, repository.ErrAppNotFound
+	}
+	return app, nil
+}
+
+// fieldViolations возвращает нарушения из деталей BadRequest: поле -> описание
+func fieldViolations(t *testing.T, err error) map[string]string {
+	t.Helper()
+
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, codes.InvalidArgument, st.Code())
+
+	for _, detail := range st.Details() {
+		if br, ok := detail.(*errdetails.BadRequest); ok {
+			got := make(map[string]string)
+			for _, fv := range br.GetFieldViolations() {
+				got[fv.GetField()] = fv.GetDescription()
+			}
+			return got
+		}
+	}
+	t.Fatal("no BadRequest details")
+	return nil
+}
+
+func TestValidateRegisterRequest(t *testing.T) {
+	tests := []struct {
+		name   string
+		email  string
+		fields []string
+	}{
+		{name: "valid", email: "bob@example.com"},
+		{name: "idn domain", email: "bob@пример.рф"},
+		{name: "quoted local part", email: `"bob smith"@example.com`},
+		{name: "empty", email: "", fields: []string{"email"}},
+		{name: "no domain", email: "bob@", fields: []string{"email"}},
+		{name: "display name", email: "Bob <bob@example.com>", fields: []string{"email"}},
+		{name: "double dot", email: "bob..smith@example.com", fields: []string{"email"}},
+		{name: "address literal", email: "bob@[192.0.2.1]", fields: []string{"email"}},
+		{name: "disposable", email: "bob@mailinator.com", fields: []string{"email"}},
+		{name: "disposable subdomain", email: "bob@eu.yopmail.com", fields: []string{"email"}},
+		{name: "long local part", email: strings.Repeat("a", 65) + "@example.com", fields: []string{"email"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validation.ValidateRegisterRequest(&auth.RegisterRequest{Email: tt.email, Password: "long-enough"})
+			if len(tt.fields) == 0 {
+				require.NoError(t, err)
+				return
+			}
+			assert.Len(t, fieldViolations(t, err), len(tt.fields))
+			for _, field := range tt.fields {
+				assert.Contains(t, fieldViolations(t, err), field)
+			}
+		})
+	}
+}
+
+func TestValidateLoginRequest_OneViolationPerField(t *testing.T) {
+	checker := validation.NewAppChecker(apps{1: {ID: 1}})
+
+	err := validation.ValidateLoginRequest(context.Background(), checker, &auth.LoginRequest{
+		Email: "not an email",
+		AppId: 7,
+	})
+	assert.Equal(t, map[string]string{
+		"email":    "must be a valid email address",
+		"password": "is required",
+		"app_id":   "does not reference an existing app",
+	}, fieldViolations(t, err))
+
+	// одноразовый домен не мешает входу существующего пользователя
+	err = validation.ValidateLoginRequest(context.Background(), checker, &auth.LoginRequest{
+		Email:    "bob@mailinator.com",
+		Password: "secret",
+		AppId:    1,
+	})
+	require.NoError(t, err)
+}
+
+func TestValidateIsAdminRequest(t *testing.T) {
+	err := validation.ValidateIsAdminRequest(&auth.IsAdminRequest{})
+	assert.Equal(t, map[string]string{"user_id": "is required"}, fieldViolations(t, err))
+	assert.Equal(t, "user_id is required", status.Convert(err).Message())
+}
+
+func TestValidateCreateAPIKeyRequest_Scopes(t *testing.T) {
+	checker := validation.NewAppChecker(apps{1: {ID: 1, Scopes: []string{"orders:read"}}})
+
+	err := validation.ValidateCreateAPIKeyRequest(context.Background(), checker, &apikeys.CreateAPIKeyRequest{
+		Name:   "ci",
+		AppId:  1,
+		Scopes: []string{model.ScopeProfileRead, "apps:write"},
+	})
+	assert.Equal(t, map[string]string{"scopes[1]": `unknown scope "apps:write"`}, fieldViolations(t, err))
+
+	// scopes ключа сервисного аккаунта — из набора приложения, их проверяет сервис
+	err = validation.ValidateCreateAPIKeyRequest(context.Background(), checker, &apikeys.CreateAPIKeyRequest{
+		Name:           "ci",
+		AppId:          1,
+		ServiceAccount: true,
+		Scopes:         []string{"orders:read"},
+	})
+	require.NoError(t, err)
+}
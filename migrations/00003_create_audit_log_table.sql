@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    actor_id BIGINT,               -- id пользователя, выполнившего действие
+    action TEXT NOT NULL,          -- например app.create, app.secret_rotate
+    entity TEXT NOT NULL,          -- тип сущности: app, user ...
+    entity_id TEXT NOT NULL,
+    details JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX audit_log_entity_idx ON audit_log (entity, entity_id);
+
+-- +goose Down
+DROP TABLE audit_log;
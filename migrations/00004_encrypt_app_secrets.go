@@ -0,0 +1,142 @@
+// Package migrations содержит Go-миграции, которым нужен код приложения.
+// SQL-миграции лежат в этой же директории и применяются goose напрямую.
+package migrations
+
+import (
+	"auth-service/internal/appsecret"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/pressly/goose/v3"
+)
+
+// envelope задаётся из cmd/migrate до запуска goose.Up
+var envelope *appsecret.Envelope
+
+// SetAppSecretsEnvelope передаёт миграциям шифратор секретов приложений
+func SetAppSecretsEnvelope(e *appsecret.Envelope) {
+	envelope = e
+}
+
+func init() {
+	goose.AddMigrationContext(upEncryptAppSecrets, downEncryptAppSecrets)
+}
+
+// upEncryptAppSecrets заменяет открытый apps.secret на хеш и зашифрованную копию
+func upEncryptAppSecrets(ctx context.Context, tx *sql.Tx) error {
+	if envelope == nil {
+		return errors.New("app secrets envelope is not configured")
+	}
+
+	_, err := tx.ExecContext(ctx, `ALTER TABLE apps
+		ADD COLUMN secret_hash BYTEA,
+		ADD COLUMN secret_enc BYTEA`)
+	if err != nil {
+		return fmt.Errorf("add columns: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT id, secret FROM apps`)
+	if err != nil {
+		return fmt.Errorf("select secrets: %w", err)
+	}
+
+	secrets := make(map[int]string)
+	for rows.Next() {
+		var (
+			id     int
+			secret string
+		)
+		if err := rows.Scan(&id, &secret); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan secret: %w", err)
+		}
+		secrets[id] = secret
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("select secrets: %w", err)
+	}
+
+	for id, secret := range secrets {
+		secretEnc, err := envelope.Seal(secret)
+		if err != nil {
+			return fmt.Errorf("encrypt secret of app %d: %w", id, err)
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE apps SET secret_hash = $2, secret_enc = $3 WHERE id = $1`,
+			id, appsecret.Hash(secret), secretEnc)
+		if err != nil {
+			return fmt.Errorf("update app %d: %w", id, err)
+		}
+	}
+
+	// secret_enc остаётся nullable: приложения без восстановимого секрета хранят только хеш
+	_, err = tx.ExecContext(ctx, `ALTER TABLE apps
+		ALTER COLUMN secret_hash SET NOT NULL,
+		DROP COLUMN secret`)
+	if err != nil {
+		return fmt.Errorf("drop plaintext column: %w", err)
+	}
+
+	return nil
+}
+
+// downEncryptAppSecrets восстанавливает открытые секреты из зашифрованных копий
+func downEncryptAppSecrets(ctx context.Context, tx *sql.Tx) error {
+	if envelope == nil {
+		return errors.New("app secrets envelope is not configured")
+	}
+
+	if _, err := tx.ExecContext(ctx, `ALTER TABLE apps ADD COLUMN secret TEXT`); err != nil {
+		return fmt.Errorf("add column: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT id, secret_enc FROM apps`)
+	if err != nil {
+		return fmt.Errorf("select secrets: %w", err)
+	}
+
+	encrypted := make(map[int][]byte)
+	for rows.Next() {
+		var (
+			id        int
+			secretEnc []byte
+		)
+		if err := rows.Scan(&id, &secretEnc); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan secret: %w", err)
+		}
+		encrypted[id] = secretEnc
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("select secrets: %w", err)
+	}
+
+	for id, secretEnc := range encrypted {
+		if secretEnc == nil {
+			return fmt.Errorf("app %d has no recoverable secret", id)
+		}
+
+		secret, err := envelope.Open(secretEnc)
+		if err != nil {
+			return fmt.Errorf("decrypt secret of app %d: %w", id, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE apps SET secret = $2 WHERE id = $1`, id, secret); err != nil {
+			return fmt.Errorf("update app %d: %w", id, err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `ALTER TABLE apps
+		ALTER COLUMN secret SET NOT NULL,
+		DROP COLUMN secret_hash,
+		DROP COLUMN secret_enc`)
+	if err != nil {
+		return fmt.Errorf("drop encrypted columns: %w", err)
+	}
+
+	return nil
+}
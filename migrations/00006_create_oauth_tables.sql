@@ -0,0 +1,29 @@
+-- +goose Up
+ALTER TABLE apps ADD COLUMN redirect_uris TEXT[] NOT NULL DEFAULT '{}';
+
+-- согласия пользователей на выдачу scopes приложениям
+CREATE TABLE oauth_consents (
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    app_id INT NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    scopes TEXT[] NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, app_id)
+);
+
+-- одноразовые коды авторизации, хранится только хеш кода
+CREATE TABLE oauth_codes (
+    code_hash BYTEA PRIMARY KEY,
+    app_id INT NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    redirect_uri TEXT NOT NULL,
+    scopes TEXT[] NOT NULL,
+    code_challenge TEXT NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE oauth_codes;
+DROP TABLE oauth_consents;
+ALTER TABLE apps DROP COLUMN redirect_uris;
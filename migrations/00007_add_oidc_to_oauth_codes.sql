@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE oauth_codes
+    ADD COLUMN nonce TEXT NOT NULL DEFAULT '',
+    ADD COLUMN auth_time TIMESTAMP NOT NULL DEFAULT NOW();
+
+-- +goose Down
+ALTER TABLE oauth_codes
+    DROP COLUMN nonce,
+    DROP COLUMN auth_time;
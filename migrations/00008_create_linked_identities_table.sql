@@ -0,0 +1,15 @@
+-- +goose Up
+-- учётные записи внешних OIDC провайдеров, через которые пользователь входил
+CREATE TABLE linked_identities (
+    provider TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (provider, subject)
+);
+
+CREATE INDEX linked_identities_user_id_idx ON linked_identities (user_id);
+
+-- +goose Down
+DROP TABLE linked_identities;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- источники учётных данных пользователей в порядке проверки при входе в приложение
+ALTER TABLE apps ADD COLUMN authenticators TEXT[] NOT NULL DEFAULT '{local}';
+
+-- +goose Down
+ALTER TABLE apps DROP COLUMN authenticators;
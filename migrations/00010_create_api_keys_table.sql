@@ -0,0 +1,22 @@
+-- +goose Up
+-- API ключи пользователей (user_id) и сервисных аккаунтов приложений (user_id IS NULL),
+-- хранится только хеш ключа, prefix — открытая часть для поиска и отображения
+CREATE TABLE api_keys (
+    id BIGSERIAL PRIMARY KEY,
+    prefix TEXT NOT NULL UNIQUE,
+    secret_hash BYTEA NOT NULL,
+    name TEXT NOT NULL,
+    user_id BIGINT REFERENCES users(id) ON DELETE CASCADE,
+    app_id INT NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    expires_at TIMESTAMP NOT NULL,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX api_keys_user_id_idx ON api_keys (user_id, id);
+CREATE INDEX api_keys_app_id_idx ON api_keys (app_id, id) WHERE user_id IS NULL;
+
+-- +goose Down
+DROP TABLE api_keys;
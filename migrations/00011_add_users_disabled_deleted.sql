@@ -0,0 +1,21 @@
+-- +goose Up
+-- disabled_at — вход и выдача токенов запрещены, deleted_at — мягкое удаление
+ALTER TABLE users
+    ADD COLUMN disabled_at TIMESTAMP,
+    ADD COLUMN deleted_at TIMESTAMP;
+
+-- email удалённого пользователя снова свободен для регистрации
+ALTER TABLE users DROP CONSTRAINT users_email_key;
+CREATE UNIQUE INDEX users_email_key ON users (email) WHERE deleted_at IS NULL;
+
+-- поиск по префиксу email в ListUsers
+CREATE INDEX users_email_prefix_idx ON users (email text_pattern_ops) WHERE deleted_at IS NULL;
+
+-- +goose Down
+DROP INDEX users_email_prefix_idx;
+DROP INDEX users_email_key;
+-- не выполнится, если email удалённого пользователя уже занят заново
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
+ALTER TABLE users
+    DROP COLUMN deleted_at,
+    DROP COLUMN disabled_at;
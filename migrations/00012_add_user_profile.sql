@@ -0,0 +1,24 @@
+-- +goose Up
+-- профиль, который пользователь редактирует сам через UpdateMe
+ALTER TABLE users
+    ADD COLUMN display_name TEXT NOT NULL DEFAULT '',
+    ADD COLUMN locale TEXT NOT NULL DEFAULT '',
+    ADD COLUMN timezone TEXT NOT NULL DEFAULT '',
+    ADD COLUMN avatar_url TEXT NOT NULL DEFAULT '';
+
+-- произвольные атрибуты пользователя в приложении, всегда JSON объект
+CREATE TABLE user_app_attributes (
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    app_id INT NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    attributes JSONB NOT NULL DEFAULT '{}' CHECK (jsonb_typeof(attributes) = 'object'),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, app_id)
+);
+
+-- +goose Down
+DROP TABLE user_app_attributes;
+ALTER TABLE users
+    DROP COLUMN avatar_url,
+    DROP COLUMN timezone,
+    DROP COLUMN locale,
+    DROP COLUMN display_name;
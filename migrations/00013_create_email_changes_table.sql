@@ -0,0 +1,21 @@
+-- +goose Up
+-- запросы на смену email: ссылка подтверждения уходит на новый адрес, ссылка отмены — на старый,
+-- хранятся только хеши ссылок
+CREATE TABLE email_changes (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    old_email TEXT NOT NULL,
+    new_email TEXT NOT NULL,
+    confirm_hash BYTEA NOT NULL UNIQUE,
+    cancel_hash BYTEA NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    cancel_expires_at TIMESTAMP NOT NULL,
+    confirmed_at TIMESTAMP,
+    canceled_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX email_changes_user_id_idx ON email_changes (user_id);
+
+-- +goose Down
+DROP TABLE email_changes;
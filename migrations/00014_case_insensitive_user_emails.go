@@ -0,0 +1,105 @@
+package migrations
+
+import (
+	"auth-service/internal/emailaddr"
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upCaseInsensitiveUserEmails, downCaseInsensitiveUserEmails)
+}
+
+// upCaseInsensitiveUserEmails нормализует email пользователей и делает их уникальность
+// регистронезависимой. Если после нормализации у нескольких пользователей совпадают
+// адреса, миграция ничего не меняет и перечисляет их: учётные записи нужно объединить
+// или удалить вручную и запустить миграцию снова.
+func upCaseInsensitiveUserEmails(ctx context.Context, tx *sql.Tx) error {
+	// локальная часть не меняется: уникальность и поиск всё равно по lower(email)
+	normalizer := emailaddr.Normalizer{}
+
+	rows, err := tx.QueryContext(ctx, `SELECT id, email FROM users WHERE deleted_at IS NULL ORDER BY id`)
+	if err != nil {
+		return fmt.Errorf("select emails: %w", err)
+	}
+
+	normalized := make(map[int64]string)
+	owners := make(map[string][]int64)
+	var changed []int64
+	for rows.Next() {
+		var (
+			id    int64
+			email string
+		)
+		if err := rows.Scan(&id, &email); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan email: %w", err)
+		}
+
+		// адрес, который не разбирается, оставляем как есть без пробелов по краям
+		norm, err := normalizer.Normalize(email)
+		if err != nil {
+			norm = strings.TrimSpace(email)
+		}
+		if norm != email {
+			normalized[id] = norm
+			changed = append(changed, id)
+		}
+
+		key := strings.ToLower(norm)
+		owners[key] = append(owners[key], id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("select emails: %w", err)
+	}
+
+	var collisions []string
+	for email, ids := range owners {
+		if len(ids) > 1 {
+			collisions = append(collisions, fmt.Sprintf("%s: users %v", email, ids))
+		}
+	}
+	if len(collisions) > 0 {
+		slices.Sort(collisions)
+		return fmt.Errorf("%d emails belong to several users after normalization, resolve them and rerun:\n%s",
+			len(collisions), strings.Join(collisions, "\n"))
+	}
+
+	for _, id := range changed {
+		if _, err := tx.ExecContext(ctx, `UPDATE users SET email = $2 WHERE id = $1`, id, normalized[id]); err != nil {
+			return fmt.Errorf("update user %d: %w", id, err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		DROP INDEX users_email_prefix_idx;
+		DROP INDEX users_email_key;
+		CREATE UNIQUE INDEX users_email_key ON users (lower(email)) WHERE deleted_at IS NULL;
+		CREATE INDEX users_email_prefix_idx ON users (lower(email) text_pattern_ops) WHERE deleted_at IS NULL`)
+	if err != nil {
+		return fmt.Errorf("replace email indexes: %w", err)
+	}
+
+	return nil
+}
+
+// downCaseInsensitiveUserEmails возвращает уникальность с учётом регистра;
+// нормализованные адреса остаются как есть
+func downCaseInsensitiveUserEmails(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `
+		DROP INDEX users_email_prefix_idx;
+		DROP INDEX users_email_key;
+		CREATE UNIQUE INDEX users_email_key ON users (email) WHERE deleted_at IS NULL;
+		CREATE INDEX users_email_prefix_idx ON users (email text_pattern_ops) WHERE deleted_at IS NULL`)
+	if err != nil {
+		return fmt.Errorf("replace email indexes: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,12 @@
+-- +goose Up
+-- source — кто создал пользователя: local (регистрация) или ldap (теневая запись каталога).
+-- LDAP может менять роль и входить только в свои записи.
+ALTER TABLE users ADD COLUMN source TEXT NOT NULL DEFAULT 'local';
+
+-- теневые записи, созданные до миграции: без пароля и без связи с внешним провайдером
+UPDATE users SET source = 'ldap'
+WHERE octet_length(pass_hash) = 0
+  AND NOT EXISTS (SELECT 1 FROM linked_identities li WHERE li.user_id = users.id);
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN source;
@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(upAppSecretMode, downAppSecretMode)
+}
+
+// upAppSecretMode добавляет режим хранения секрета. Существующие приложения
+// становятся auth_only, но зашифрованные копии из 00004 остаются: без них
+// откат 00004 не восстановит секреты. Копия удаляется при смене секрета.
+func upAppSecretMode(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `ALTER TABLE apps
+		ADD COLUMN secret_mode TEXT NOT NULL DEFAULT 'auth_only'
+		CHECK (secret_mode IN ('auth_only', 'signing'))`)
+	if err != nil {
+		return fmt.Errorf("add column: %w", err)
+	}
+
+	return nil
+}
+
+func downAppSecretMode(ctx context.Context, tx *sql.Tx) error {
+	if _, err := tx.ExecContext(ctx, `ALTER TABLE apps DROP COLUMN secret_mode`); err != nil {
+		return fmt.Errorf("drop column: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,6 @@
+-- +goose Up
+-- public — публичный клиент: обменивает код авторизации без секрета, только с PKCE
+ALTER TABLE apps ADD COLUMN public BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE apps DROP COLUMN public;
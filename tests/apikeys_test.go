@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"auth-service/gen/apikeys"
+	"auth-service/tests/suite"
+	"testing"
+	"time"
+
+	"github.com/ILmira-116/protos/gen/auth"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// happy path: личный ключ создаётся по токену пользователя, обменивается на JWT
+// и после отзыва перестаёт работать
+func TestAPIKeys_PersonalKey_ExchangeAndRevoke(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email := gofakeit.Email()
+	password := gofakeit.Password(true, true, true, true, false, passDefaultLen)
+
+	respReg, err := st.AuthClient.Register(ctx, &auth.RegisterRequest{
+		Email:    email,
+		Password: password,
+	})
+	require.NoError(t, err)
+
+	respLogin, err := st.AuthClient.Login(ctx, &auth.LoginRequest{
+		Email:    email,
+		Password: password,
+		AppId:    appID,
+	})
+	require.NoError(t, err)
+
+	userCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+respLogin.GetToken())
+
+	created, err := st.APIKeysClient.CreateAPIKey(userCtx, &apikeys.CreateAPIKeyRequest{
+		Name:  "ci",
+		AppId: appID,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, created.GetKey())
+	assert.Equal(t, respReg.GetUserId(), created.GetApiKey().GetUserId())
+	assert.Contains(t, created.GetKey(), created.GetApiKey().GetPrefix())
+
+	list, err := st.APIKeysClient.ListAPIKeys(userCtx, &apikeys.ListAPIKeysRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.GetApiKeys(), 1)
+	assert.Equal(t, created.GetApiKey().GetId(), list.GetApiKeys()[0].GetId())
+
+	exchanged, err := st.APIKeysClient.ExchangeAPIKey(ctx, &apikeys.ExchangeAPIKeyRequest{Key: created.GetKey()})
+	require.NoError(t, err)
+	issuedAt := time.Now()
+
+	assert.Equal(t, "Bearer", exchanged.GetTokenType())
+	assert.Equal(t, int64(st.Cfg.APIKeys.TokenTTL.Seconds()), exchanged.GetExpiresIn())
+
+	tokenParsed, err := jwt.Parse(exchanged.GetAccessToken(), func(token *jwt.Token) (interface{}, error) {
+		return []byte(st.Cfg.JWTSecret.Reveal()), nil
+	})
+	require.NoError(t, err)
+
+	claims, ok := tokenParsed.Claims.(jwt.MapClaims)
+	require.True(t, ok)
+
+	assert.Equal(t, respReg.GetUserId(), int64(claims["user_id"].(float64)))
+	assert.Equal(t, email, claims["email"].(string))
+
+	const deltaSeconds = 1
+	assert.InDelta(t, issuedAt.Add(st.Cfg.APIKeys.TokenTTL).Unix(), claims["exp"].(float64), deltaSeconds)
+
+	_, err = st.APIKeysClient.RevokeAPIKey(userCtx, &apikeys.RevokeAPIKeyRequest{KeyId: created.GetApiKey().GetId()})
+	require.NoError(t, err)
+
+	_, err = st.APIKeysClient.ExchangeAPIKey(ctx, &apikeys.ExchangeAPIKeyRequest{Key: created.GetKey()})
+	require.Error(t, err)
+
+	sts, ok := status.FromError(err)
+	require.True(t, ok, "ошибка должна быть gRPC status")
+	assert.Equal(t, codes.Unauthenticated, sts.Code())
+}
+
+// fail-кейс: ключ сервисного аккаунта может создать только администратор
+func TestAPIKeys_CreateServiceAccountKey_NotAdmin(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email := gofakeit.Email()
+	password := gofakeit.Password(true, true, true, true, false, passDefaultLen)
+
+	_, err := st.AuthClient.Register(ctx, &auth.RegisterRequest{
+		Email:    email,
+		Password: password,
+	})
+	require.NoError(t, err)
+
+	respLogin, err := st.AuthClient.Login(ctx, &auth.LoginRequest{
+		Email:    email,
+		Password: password,
+		AppId:    appID,
+	})
+	require.NoError(t, err)
+
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+respLogin.GetToken())
+
+	resp, err := st.APIKeysClient.CreateAPIKey(ctx, &apikeys.CreateAPIKeyRequest{
+		Name:           "deploy",
+		AppId:          appID,
+		ServiceAccount: true,
+	})
+	require.Error(t, err)
+	require.Nil(t, resp)
+
+	sts, ok := status.FromError(err)
+	require.True(t, ok, "ошибка должна быть gRPC status")
+	assert.Equal(t, codes.PermissionDenied, sts.Code())
+}
+
+// fail-кейс: ключ неизвестного формата
+func TestAPIKeys_Exchange_InvalidKey(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	resp, err := st.APIKeysClient.ExchangeAPIKey(ctx, &apikeys.ExchangeAPIKeyRequest{Key: "ak_unknown.secret"})
+	require.Error(t, err)
+	require.Nil(t, resp)
+
+	sts, ok := status.FromError(err)
+	require.True(t, ok, "ошибка должна быть gRPC status")
+	assert.Equal(t, codes.Unauthenticated, sts.Code())
+}
@@ -10,7 +10,9 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
@@ -102,3 +104,61 @@ func TestRegisterLogin_DuplicateRegistration(t *testing.T) {
 	assert.Equal(t, codes.AlreadyExists, sts.Code())
 	assert.Equal(t, "user already exists", sts.Message())
 }
+
+// fail-кейс: все нарушения приходят разом, по одному на поле
+func TestRegisterLogin_Register_InvalidArguments(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	respReg, err := st.AuthClient.Register(ctx, &auth.RegisterRequest{
+		Email:    "not-an-email",
+		Password: "short",
+	})
+	require.Error(t, err)
+	require.Nil(t, respReg)
+
+	sts, ok := status.FromError(err)
+	require.True(t, ok, "ошибка должна быть gRPC status")
+	assert.Equal(t, codes.InvalidArgument, sts.Code())
+
+	fields := map[string]bool{}
+	for _, detail := range sts.Details() {
+		if br, ok := detail.(*errdetails.BadRequest); ok {
+			for _, fv := range br.GetFieldViolations() {
+				fields[fv.GetField()] = true
+			}
+		}
+	}
+	assert.Equal(t, map[string]bool{"email": true, "password": true}, fields)
+}
+
+// fail-кейс: IsAdmin без токена и для чужого user_id
+func TestRegisterLogin_IsAdmin_RequiresOwnToken(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	_, err := st.AuthClient.IsAdmin(ctx, &auth.IsAdminRequest{UserId: 1 << 62})
+	require.Error(t, err)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	email := gofakeit.Email()
+	password := gofakeit.Password(true, true, true, true, false, passDefaultLen)
+
+	respReg, err := st.AuthClient.Register(ctx, &auth.RegisterRequest{Email: email, Password: password})
+	require.NoError(t, err)
+	respLogin, err := st.AuthClient.Login(ctx, &auth.LoginRequest{Email: email, Password: password, AppId: appID})
+	require.NoError(t, err)
+
+	userCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+respLogin.GetToken())
+
+	// свою роль пользователь видит
+	resp, err := st.AuthClient.IsAdmin(userCtx, &auth.IsAdminRequest{UserId: respReg.GetUserId()})
+	require.NoError(t, err)
+	assert.False(t, resp.GetIsAdmin())
+
+	// чужую — нет, даже для несуществующего пользователя
+	_, err = st.AuthClient.IsAdmin(userCtx, &auth.IsAdminRequest{UserId: 1 << 62})
+	require.Error(t, err)
+
+	sts, ok := status.FromError(err)
+	require.True(t, ok, "ошибка должна быть gRPC status")
+	assert.Equal(t, codes.PermissionDenied, sts.Code())
+}
@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"auth-service/tests/suite"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// postJSON отправляет JSON в REST шлюз и разбирает ответ в out
+func postJSON(t *testing.T, url, body string, out any) *http.Response {
+	t.Helper()
+
+	resp, err := http.Post(url, "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(out))
+	return resp
+}
+
+// happy-path: регистрация и вход через REST шлюз
+func TestGateway_RegisterLogin_HappyPath(t *testing.T) {
+	_, st := suite.New(t)
+
+	email := gofakeit.Email()
+	password := gofakeit.Password(true, true, true, true, false, passDefaultLen)
+	creds := `{"email":"` + email + `","password":"` + password + `"`
+
+	var reg struct {
+		UserID string `json:"user_id"`
+	}
+	resp := postJSON(t, st.GatewayAddr+"/v1/register", creds+"}", &reg)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NotEmpty(t, reg.UserID)
+
+	var login struct {
+		Token string `json:"token"`
+	}
+	resp = postJSON(t, st.GatewayAddr+"/v1/login", creds+`,"app_id":`+strconv.Itoa(appID)+"}", &login)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NotEmpty(t, login.Token)
+
+	// повторная регистрация — problem+json с причиной из ErrorInfo
+	var problem struct {
+		Status int    `json:"status"`
+		Reason string `json:"reason"`
+	}
+	resp = postJSON(t, st.GatewayAddr+"/v1/register", creds+"}", &problem)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "application/problem+json", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "USER_EXISTS", problem.Reason)
+}
@@ -0,0 +1,35 @@
+package tests
+
+import (
+	"auth-service/internal/health"
+	"auth-service/tests/suite"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// happy-path: работающий сервис с доступной базой жив и готов
+func TestHealth_Check(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	for _, service := range []string{"", health.LivenessService, health.ReadinessService} {
+		resp, err := st.HealthClient.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		require.NoError(t, err, service)
+		assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus(), service)
+	}
+}
+
+// happy-path: HTTP зеркала для проб без gRPC
+func TestHealth_HTTPMirrors(t *testing.T) {
+	_, st := suite.New(t)
+
+	for _, path := range []string{"/healthz", "/readyz"} {
+		resp, err := http.Get(st.HTTPAddr + path)
+		require.NoError(t, err, path)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode, path)
+	}
+}
@@ -1,5 +1,9 @@
 -- +goose Up
-INSERT INTO apps (id, name, secret) VALUES (1, 'test', 'test_secret');
+-- секрет тестового приложения хранится только хешем (secret_enc = NULL)
+INSERT INTO apps (id, name, secret_hash, redirect_uris)
+VALUES (1, 'test', sha256('test_secret'::bytea), '{http://localhost/callback}');
+-- id задан вручную, поэтому сдвигаем sequence, иначе CreateApp упадёт на дубликате
+SELECT setval('apps_id_seq', (SELECT MAX(id) FROM apps));
 
 -- +goose Down
-DELETE FROM apps WHERE id = 1;
\ No newline at end of file
+DELETE FROM apps WHERE id = 1;
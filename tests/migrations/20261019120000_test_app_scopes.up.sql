@@ -0,0 +1,6 @@
+-- +goose Up
+-- /authorize выдаёт только scopes из списка приложения
+UPDATE apps SET scopes = '{openid,email,profile}' WHERE id = 1;
+
+-- +goose Down
+UPDATE apps SET scopes = '{}' WHERE id = 1;
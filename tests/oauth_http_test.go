@@ -0,0 +1,239 @@
+package tests
+
+import (
+	"auth-service/tests/suite"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ILmira-116/protos/gen/auth"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// redirect_uri тестового приложения из tests/migrations
+	redirectURI = "http://localhost/callback"
+
+	defaultPassword = "Passw0rd!-for-tests"
+)
+
+var ticketRe = regexp.MustCompile(`name="ticket" value="([^"]+)"`)
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	Scope       string `json:"scope"`
+	IDToken     string `json:"id_token"`
+}
+
+func TestOAuthHTTP_AuthorizationCode_HappyPath(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email := registerUser(ctx, t, st)
+
+	code, verifier := authorize(t, st, email, "profile", "")
+
+	tokenForm := url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {strconv.Itoa(appID)},
+		"client_secret": {clientSecret},
+		"redirect_uri":  {redirectURI},
+		"code":          {code},
+		"code_verifier": {verifier},
+	}
+
+	token := exchangeCode(t, st, tokenForm)
+	assert.NotEmpty(t, token.AccessToken)
+	assert.Equal(t, "Bearer", token.TokenType)
+	assert.Equal(t, "profile", token.Scope)
+	assert.Empty(t, token.IDToken, "id_token выдаётся только для scope openid")
+
+	// код одноразовый
+	resp, err := http.PostForm(st.HTTPAddr+"/token", tokenForm)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestOIDC_IDTokenAndUserInfo(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email := registerUser(ctx, t, st)
+	nonce := gofakeit.UUID()
+
+	code, verifier := authorize(t, st, email, "openid email", nonce)
+
+	token := exchangeCode(t, st, url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {strconv.Itoa(appID)},
+		"client_secret": {clientSecret},
+		"redirect_uri":  {redirectURI},
+		"code":          {code},
+		"code_verifier": {verifier},
+	})
+	require.NotEmpty(t, token.IDToken)
+
+	// подпись id_token проверяется клиентскими библиотеками по JWKS, здесь сверяем claims
+	idToken, _, err := jwt.NewParser().ParseUnverified(token.IDToken, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, "RS256", idToken.Method.Alg())
+
+	claims := idToken.Claims.(jwt.MapClaims)
+	assert.Equal(t, nonce, claims["nonce"])
+	assert.Equal(t, strconv.Itoa(appID), claims["aud"])
+	assert.Equal(t, email, claims["email"])
+	assert.Contains(t, claims, "auth_time")
+
+	sum := sha256.Sum256([]byte(token.AccessToken))
+	assert.Equal(t, base64.RawURLEncoding.EncodeToString(sum[:16]), claims["at_hash"])
+
+	// /userinfo по токену доступа
+	req, err := http.NewRequest(http.MethodGet, st.HTTPAddr+"/userinfo", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var info map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&info))
+	assert.Equal(t, claims["sub"], info["sub"])
+	assert.Equal(t, email, info["email"])
+	assert.NotContains(t, info, "updated_at", "profile claims требуют scope profile")
+}
+
+func TestOIDC_Discovery(t *testing.T) {
+	_, st := suite.New(t)
+
+	resp, err := http.Get(st.HTTPAddr + "/.well-known/openid-configuration")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var doc map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&doc))
+
+	for _, field := range []string{"issuer", "authorization_endpoint", "token_endpoint", "userinfo_endpoint", "jwks_uri"} {
+		assert.NotEmpty(t, doc[field], field)
+	}
+	assert.Contains(t, doc["scopes_supported"], "openid")
+}
+
+// fail-кейс: redirect_uri не зарегистрирован, редиректа быть не должно
+func TestOAuthHTTP_Authorize_UnknownRedirectURI(t *testing.T) {
+	_, st := suite.New(t)
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {strconv.Itoa(appID)},
+		"redirect_uri":          {"https://evil.example/callback"},
+		"code_challenge":        {strings.Repeat("a", 43)},
+		"code_challenge_method": {"S256"},
+	}
+
+	resp, err := noRedirectClient().Get(st.HTTPAddr + "/authorize?" + query.Encode())
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("Location"))
+}
+
+// registerUser регистрирует пользователя с паролем по умолчанию и возвращает его email
+func registerUser(ctx context.Context, t *testing.T, st *suite.Suite) string {
+	t.Helper()
+
+	email := gofakeit.Email()
+	_, err := st.AuthClient.Register(ctx, &auth.RegisterRequest{
+		Email:    email,
+		Password: defaultPassword,
+	})
+	require.NoError(t, err)
+
+	return email
+}
+
+// authorize проходит вход и согласие и возвращает код и code_verifier
+func authorize(t *testing.T, st *suite.Suite, email, scope, nonce string) (string, string) {
+	t.Helper()
+
+	verifier := gofakeit.LetterN(64)
+	sum := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+	state := gofakeit.UUID()
+
+	client := noRedirectClient()
+
+	resp, err := client.PostForm(st.HTTPAddr+"/authorize", url.Values{
+		"response_type":         {"code"},
+		"client_id":             {strconv.Itoa(appID)},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {scope},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {"S256"},
+		"email":                 {email},
+		"password":              {defaultPassword},
+	})
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// новый пользователь попадает на страницу согласия
+	m := ticketRe.FindSubmatch(body)
+	require.Len(t, m, 2, "consent page must contain a ticket")
+
+	resp, err = client.PostForm(st.HTTPAddr+"/authorize/consent", url.Values{
+		"ticket":   {html.UnescapeString(string(m[1]))},
+		"decision": {"approve"},
+	})
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, state, location.Query().Get("state"))
+
+	code := location.Query().Get("code")
+	require.NotEmpty(t, code)
+
+	return code, verifier
+}
+
+func exchangeCode(t *testing.T, st *suite.Suite, form url.Values) tokenResponse {
+	t.Helper()
+
+	resp, err := http.PostForm(st.HTTPAddr+"/token", form)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var token tokenResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&token))
+
+	return token
+}
+
+// noRedirectClient не следует редиректам, чтобы прочитать code из Location
+func noRedirectClient() *http.Client {
+	return &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+}
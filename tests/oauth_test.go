@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"auth-service/gen/oauth"
+	"auth-service/tests/suite"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// секрет тестового приложения из tests/migrations
+const clientSecret = "test_secret"
+
+func TestOAuth_ClientCredentials_HappyPath(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	resp, err := st.OAuthClient.ClientCredentials(ctx, &oauth.ClientCredentialsRequest{
+		AppId:        appID,
+		ClientSecret: clientSecret,
+	})
+	require.NoError(t, err)
+
+	issuedAt := time.Now()
+
+	assert.Equal(t, "Bearer", resp.GetTokenType())
+	assert.Equal(t, int64(st.Cfg.TokenTTL.Seconds()), resp.GetExpiresIn())
+	require.NotEmpty(t, resp.GetAccessToken())
+
+	tokenParsed, err := jwt.Parse(resp.GetAccessToken(), func(token *jwt.Token) (interface{}, error) {
+		return []byte(st.Cfg.JWTSecret.Reveal()), nil
+	})
+	require.NoError(t, err)
+
+	claims, ok := tokenParsed.Claims.(jwt.MapClaims)
+	require.True(t, ok)
+
+	// машинный токен не привязан к пользователю
+	assert.Equal(t, strconv.Itoa(appID), claims["sub"])
+	assert.NotContains(t, claims, "user_id")
+
+	const deltaSeconds = 1
+	assert.InDelta(t, issuedAt.Add(st.Cfg.TokenTTL).Unix(), claims["exp"].(float64), deltaSeconds)
+}
+
+// fail-кейс: неверный секрет приложения
+func TestOAuth_ClientCredentials_InvalidSecret(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	resp, err := st.OAuthClient.ClientCredentials(ctx, &oauth.ClientCredentialsRequest{
+		AppId:        appID,
+		ClientSecret: "wrong-secret",
+	})
+	require.Error(t, err)
+	require.Nil(t, resp)
+
+	sts, ok := status.FromError(err)
+	require.True(t, ok, "ошибка должна быть gRPC status")
+	assert.Equal(t, codes.Unauthenticated, sts.Code())
+	assert.Equal(t, "invalid client", sts.Message())
+}
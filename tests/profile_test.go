@@ -0,0 +1,169 @@
+package tests
+
+import (
+	"auth-service/gen/profile"
+	"auth-service/tests/suite"
+	"testing"
+
+	"github.com/ILmira-116/protos/gen/auth"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// happy path: пользователь меняет профиль и атрибуты, поля профиля попадают в новый токен
+func TestProfile_UpdateMe_HappyPath(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email := gofakeit.Email()
+	password := gofakeit.Password(true, true, true, true, false, passDefaultLen)
+
+	respReg, err := st.AuthClient.Register(ctx, &auth.RegisterRequest{
+		Email:    email,
+		Password: password,
+	})
+	require.NoError(t, err)
+
+	login := func() string {
+		respLogin, err := st.AuthClient.Login(ctx, &auth.LoginRequest{
+			Email:    email,
+			Password: password,
+			AppId:    appID,
+		})
+		require.NoError(t, err)
+		return respLogin.GetToken()
+	}
+
+	userCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+login())
+
+	attrs, err := structpb.NewStruct(map[string]any{"plan": "pro", "seats": 5})
+	require.NoError(t, err)
+
+	updated, err := st.ProfileClient.UpdateMe(userCtx, &profile.UpdateMeRequest{
+		DisplayName: proto.String("Test User"),
+		Locale:      proto.String("ru-RU"),
+		Timezone:    proto.String("Europe/Moscow"),
+		Attributes:  attrs,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Test User", updated.GetProfile().GetDisplayName())
+
+	me, err := st.ProfileClient.GetMe(userCtx, &profile.GetMeRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, respReg.GetUserId(), me.GetProfile().GetUserId())
+	assert.Equal(t, "ru-RU", me.GetProfile().GetLocale())
+	assert.Equal(t, "Europe/Moscow", me.GetProfile().GetTimezone())
+	assert.Equal(t, int32(appID), me.GetProfile().GetAppId())
+	assert.Equal(t, map[string]any{"plan": "pro", "seats": float64(5)}, me.GetProfile().GetAttributes().AsMap())
+
+	tokenParsed, err := jwt.Parse(login(), func(token *jwt.Token) (interface{}, error) {
+		return []byte(st.Cfg.JWTSecret.Reveal()), nil
+	})
+	require.NoError(t, err)
+
+	claims, ok := tokenParsed.Claims.(jwt.MapClaims)
+	require.True(t, ok)
+	assert.Equal(t, "Test User", claims["name"])
+	assert.Equal(t, "Europe/Moscow", claims["zoneinfo"])
+	assert.NotContains(t, claims, "picture")
+}
+
+// fail-кейс: вложенные объекты в атрибутах не допускаются
+func TestProfile_UpdateMe_InvalidAttributes(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email := gofakeit.Email()
+	password := gofakeit.Password(true, true, true, true, false, passDefaultLen)
+
+	_, err := st.AuthClient.Register(ctx, &auth.RegisterRequest{
+		Email:    email,
+		Password: password,
+	})
+	require.NoError(t, err)
+
+	respLogin, err := st.AuthClient.Login(ctx, &auth.LoginRequest{
+		Email:    email,
+		Password: password,
+		AppId:    appID,
+	})
+	require.NoError(t, err)
+
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+respLogin.GetToken())
+
+	attrs, err := structpb.NewStruct(map[string]any{"address": map[string]any{"city": "Kazan"}})
+	require.NoError(t, err)
+
+	resp, err := st.ProfileClient.UpdateMe(ctx, &profile.UpdateMeRequest{Attributes: attrs})
+	require.Error(t, err)
+	require.Nil(t, resp)
+
+	sts, ok := status.FromError(err)
+	require.True(t, ok, "ошибка должна быть gRPC status")
+	assert.Equal(t, codes.InvalidArgument, sts.Code())
+}
+
+// fail-кейс: вызов без токена
+func TestProfile_GetMe_Unauthenticated(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	resp, err := st.ProfileClient.GetMe(ctx, &profile.GetMeRequest{})
+	require.Error(t, err)
+	require.Nil(t, resp)
+
+	sts, ok := status.FromError(err)
+	require.True(t, ok, "ошибка должна быть gRPC status")
+	assert.Equal(t, codes.Unauthenticated, sts.Code())
+}
+
+// fail-кейс: смена email без верного текущего пароля
+func TestProfile_RequestEmailChange_WrongPassword(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email := gofakeit.Email()
+	password := gofakeit.Password(true, true, true, true, false, passDefaultLen)
+
+	_, err := st.AuthClient.Register(ctx, &auth.RegisterRequest{
+		Email:    email,
+		Password: password,
+	})
+	require.NoError(t, err)
+
+	respLogin, err := st.AuthClient.Login(ctx, &auth.LoginRequest{
+		Email:    email,
+		Password: password,
+		AppId:    appID,
+	})
+	require.NoError(t, err)
+
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+respLogin.GetToken())
+
+	resp, err := st.ProfileClient.RequestEmailChange(ctx, &profile.RequestEmailChangeRequest{
+		NewEmail:        gofakeit.Email(),
+		CurrentPassword: "wrong-password",
+	})
+	require.Error(t, err)
+	require.Nil(t, resp)
+
+	sts, ok := status.FromError(err)
+	require.True(t, ok, "ошибка должна быть gRPC status")
+	assert.Equal(t, codes.PermissionDenied, sts.Code())
+}
+
+// fail-кейс: неизвестный токен подтверждения
+func TestProfile_ConfirmEmailChange_InvalidToken(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	resp, err := st.ProfileClient.ConfirmEmailChange(ctx, &profile.ConfirmEmailChangeRequest{Token: "unknown"})
+	require.Error(t, err)
+	require.Nil(t, resp)
+
+	sts, ok := status.FromError(err)
+	require.True(t, ok, "ошибка должна быть gRPC status")
+	assert.Equal(t, codes.NotFound, sts.Code())
+}
@@ -2,20 +2,47 @@ package suite
 
 import (
 	"auth-service/config"
+	"auth-service/gen/apikeys"
+	"auth-service/gen/apps"
+	"auth-service/gen/oauth"
+	"auth-service/gen/profile"
+	"auth-service/gen/users"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"net"
+	"os"
 	"testing"
 	"time"
 
 	"github.com/ILmira-116/protos/gen/auth"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 type Suite struct {
 	*testing.T
-	Cfg        *config.Config
-	AuthClient auth.AuthClient // grpc клиент
+	Cfg *config.Config
+	// grpc клиент
+	AuthClient auth.AuthClient
+	// grpc клиент админского API приложений
+	AppsClient apps.AppsClient
+	// grpc клиент выдачи машинных токенов
+	OAuthClient oauth.OAuthClient
+	// grpc клиент API ключей
+	APIKeysClient apikeys.APIKeysClient
+	// grpc клиент админского API пользователей
+	UsersClient users.UsersClient
+	// grpc клиент профиля текущего пользователя
+	ProfileClient profile.ProfileClient
+	// grpc клиент grpc.health.v1
+	HealthClient healthpb.HealthClient
+	// базовый URL HTTP сервера (OAuth)
+	HTTPAddr string
+	// базовый URL REST/JSON шлюза
+	GatewayAddr string
 }
 
 func New(t *testing.T) (context.Context, *Suite) {
@@ -41,7 +68,7 @@ func New(t *testing.T) (context.Context, *Suite) {
 	grpcAddress := net.JoinHostPort(cfg.GRPC.ServerHost, cfg.GRPC.ServerPort)
 
 	// Создаём gRPC-клиента
-	cc, err := grpc.NewClient(grpcAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	cc, err := grpc.NewClient(grpcAddress, grpc.WithTransportCredentials(transportCredentials(t, cfg)))
 	if err != nil {
 		t.Fatalf("grpc server connection failed: %v", err)
 	}
@@ -55,9 +82,38 @@ func New(t *testing.T) (context.Context, *Suite) {
 	authClient := auth.NewAuthClient(cc)
 
 	return ctx, &Suite{
-		T:          t,
-		Cfg:        cfg,
-		AuthClient: authClient,
+		T:             t,
+		Cfg:           cfg,
+		AuthClient:    authClient,
+		AppsClient:    apps.NewAppsClient(cc),
+		OAuthClient:   oauth.NewOAuthClient(cc),
+		APIKeysClient: apikeys.NewAPIKeysClient(cc),
+		UsersClient:   users.NewUsersClient(cc),
+		ProfileClient: profile.NewProfileClient(cc),
+		HealthClient:  healthpb.NewHealthClient(cc),
+		HTTPAddr:      "http://" + net.JoinHostPort(cfg.GRPC.ServerHost, cfg.HTTP.ServerPort),
+		GatewayAddr:   "http://" + net.JoinHostPort(cfg.GRPC.ServerHost, cfg.Gateway.ServerPort),
 	}
 
 }
+
+// transportCredentials — без GRPC_TLS_CERT_FILE сервер работает без TLS;
+// иначе сертификат сервера проверяется по CA из TEST_GRPC_TLS_CA_FILE
+func transportCredentials(t *testing.T, cfg *config.Config) credentials.TransportCredentials {
+	t.Helper()
+
+	if cfg.GRPC.TLS.CertFile == "" {
+		return insecure.NewCredentials()
+	}
+
+	caPEM, err := os.ReadFile(os.Getenv("TEST_GRPC_TLS_CA_FILE"))
+	if err != nil {
+		t.Fatalf("cannot read TEST_GRPC_TLS_CA_FILE: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		t.Fatalf("no certificates in TEST_GRPC_TLS_CA_FILE")
+	}
+
+	return credentials.NewTLS(&tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12})
+}
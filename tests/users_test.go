@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"auth-service/gen/users"
+	"auth-service/tests/suite"
+	"testing"
+
+	"github.com/ILmira-116/protos/gen/auth"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// fail-кейс: вызов без токена
+func TestUsers_ListUsers_Unauthenticated(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	resp, err := st.UsersClient.ListUsers(ctx, &users.ListUsersRequest{})
+	require.Error(t, err)
+	require.Nil(t, resp)
+
+	sts, ok := status.FromError(err)
+	require.True(t, ok, "ошибка должна быть gRPC status")
+	assert.Equal(t, codes.Unauthenticated, sts.Code())
+}
+
+// fail-кейс: обычный пользователь не может блокировать пользователей
+func TestUsers_DisableUser_NotAdmin(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	email := gofakeit.Email()
+	password := gofakeit.Password(true, true, true, true, false, passDefaultLen)
+
+	respReg, err := st.AuthClient.Register(ctx, &auth.RegisterRequest{
+		Email:    email,
+		Password: password,
+	})
+	require.NoError(t, err)
+
+	respLogin, err := st.AuthClient.Login(ctx, &auth.LoginRequest{
+		Email:    email,
+		Password: password,
+		AppId:    appID,
+	})
+	require.NoError(t, err)
+
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+respLogin.GetToken())
+
+	resp, err := st.UsersClient.DisableUser(ctx, &users.DisableUserRequest{UserId: respReg.GetUserId()})
+	require.Error(t, err)
+	require.Nil(t, resp)
+
+	sts, ok := status.FromError(err)
+	require.True(t, ok, "ошибка должна быть gRPC status")
+	assert.Equal(t, codes.PermissionDenied, sts.Code())
+}